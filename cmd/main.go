@@ -2,28 +2,53 @@ package main
 
 import (
 	"context"
-	"errors"
+	"io"
 	"net/http"
 	"os"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"golang.org/x/exp/slog"
 
+	"realty-avito/internal/auth"
+	"realty-avito/internal/blobstore"
 	"realty-avito/internal/client/db/pg"
 	"realty-avito/internal/client/db/transaction"
 	"realty-avito/internal/config"
+	"realty-avito/internal/http-server/handlers/admin"
 	"realty-avito/internal/http-server/handlers/dummyLogin"
 	"realty-avito/internal/http-server/handlers/flat"
+	"realty-avito/internal/http-server/handlers/health"
 	"realty-avito/internal/http-server/handlers/house"
 	"realty-avito/internal/http-server/handlers/login"
+	"realty-avito/internal/http-server/handlers/logout"
+	moderationHandlers "realty-avito/internal/http-server/handlers/moderation"
+	"realty-avito/internal/http-server/handlers/refresh"
 	"realty-avito/internal/http-server/handlers/register"
 	myMiddleware "realty-avito/internal/http-server/middleware"
 	mwLogger "realty-avito/internal/http-server/middleware/logger"
+	"realty-avito/internal/idempotency"
 	"realty-avito/internal/lib/logger"
+	"realty-avito/internal/lifecycle"
+	"realty-avito/internal/metrics"
+	"realty-avito/internal/models"
+	"realty-avito/internal/moderation"
+	"realty-avito/internal/notifier"
+	"realty-avito/internal/outbox"
+	"realty-avito/internal/repositories/auditRepo"
 	flatRepo "realty-avito/internal/repositories/flatsRepo"
 	houseRepo "realty-avito/internal/repositories/housesRepo"
+	"realty-avito/internal/repositories/idempotencyRepo"
+	"realty-avito/internal/repositories/migrationsRepo"
+	"realty-avito/internal/repositories/outboxRepo"
+	"realty-avito/internal/repositories/photosRepo"
+	"realty-avito/internal/repositories/subscriptionsRepo"
+	"realty-avito/internal/repositories/tokensRepo"
 	"realty-avito/internal/repositories/usersRepo"
+	"realty-avito/internal/sender"
+	"realty-avito/internal/tracing"
 	"realty-avito/postgres"
 )
 
@@ -41,62 +66,240 @@ func main() {
 		slog.String("env", cfg.Env),
 	)
 
+	// ресурсы останавливаются в порядке, обратном регистрации: пул Postgres закрывается последним
+	// из ресурсов, трассировка выгружается уже после него, чтобы не потерять спаны остановки
+	app := lifecycle.New(log, cfg.HTTPServer.ShutdownDelay, cfg.HTTPServer.ShutdownTimeout)
+
+	shutdownTracing, err := tracing.Setup(ctx, cfg.Tracing)
+	if err != nil {
+		log.Error("failed to initialize tracing", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+	app.AddFunc("tracing", shutdownTracing)
+
 	dsn := postgres.CreatePostgresDSN(cfg.Postgres)
-	pgClient, err := pg.New(ctx, dsn)
+	pgClient, err := pg.New(ctx, dsn, log, cfg.Postgres.QueryLog)
 	if err != nil {
 		log.Error("failed to initialize postgres client", slog.String("error", err.Error()))
 		os.Exit(1)
 	}
+	app.AddCloser("postgres", pgClient)
 
-	defer pgClient.Close()
+	if pool, ok := pgClient.(metrics.PoolStater); ok {
+		prometheus.MustRegister(metrics.NewPoolStatsCollector(pool))
+	}
 
 	// init transaction manager
 	txManager := transaction.NewTransactionManager(pgClient.DB())
 
 	// init repo
-	flatsRepo := flatRepo.NewFlatsRepository(pgClient)
-	housesRepo := houseRepo.NewHousesRepository(pgClient)
+	outboxRepository := outboxRepo.NewOutboxRepository(pgClient)
+	flatsRepo := flatRepo.NewFlatsRepository(pgClient, txManager, outboxRepository)
+	housesRepo := houseRepo.NewHousesRepository(pgClient, txManager, outboxRepository)
 	usersRepository := usersRepo.NewUserRepository(pgClient)
+	subscriptionsRepository := subscriptionsRepo.NewSubscriptionsRepository(pgClient)
+	tokensRepository := tokensRepo.NewTokensRepository(pgClient)
+	auditRepository := auditRepo.NewAuditRepository(pgClient)
+	photosRepository := photosRepo.NewPhotosRepository(pgClient)
+	idempotencyRepository := idempotencyRepo.NewIdempotencyRepository(pgClient)
+	migrationsRepository := migrationsRepo.NewMigrationsRepository(pgClient)
+
+	// версия схемы, с которой собрано приложение, для проверки готовности
+	expectedMigrationVersion, err := postgres.LatestMigrationVersion()
+	if err != nil {
+		log.Error("failed to read embedded migrations", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+
+	// init photo storage
+	photoStore, err := blobstore.NewLocalStore(cfg.Photos.StorageDir)
+	if err != nil {
+		log.Error("failed to initialize photo storage", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+
+	// init notifier
+	emailSender, err := sender.NewStubSender(log, cfg.Notifier.SenderFile)
+	if err != nil {
+		log.Error("failed to initialize sender", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+
+	app.AddCloser("email sender", emailSender)
+
+	flatNotifier := notifier.New(log, emailSender, subscriptionsRepository, cfg.Notifier)
+	app.StartWorker(ctx, "notifier", flatNotifier)
+
+	// init outbox relay
+	eventPublisher, err := outbox.NewPublisher(cfg.Outbox)
+	if err != nil {
+		log.Error("failed to initialize outbox publisher", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+
+	if publisherCloser, ok := eventPublisher.(io.Closer); ok {
+		app.AddCloser("outbox publisher", publisherCloser)
+	}
+
+	if channelPublisher, ok := eventPublisher.(*outbox.ChannelPublisher); ok {
+		go func() {
+			for event := range channelPublisher.Events() {
+				log.Debug("outbox event published",
+					slog.Int64("event_id", event.ID),
+					slog.String("event_type", event.EventType),
+				)
+			}
+		}()
+	}
+
+	outboxRelay := outbox.NewRelay(log, txManager, outboxRepository, eventPublisher, cfg.Outbox)
+	app.StartWorker(ctx, "outbox relay", outboxRelay)
+
+	// init moderation claims releaser
+	claimsReleaser := moderation.NewReleaser(log, flatsRepo, cfg.Moderation)
+	app.StartWorker(ctx, "claims releaser", claimsReleaser)
+
+	// init idempotency keys cleaner
+	idempotencyCleaner := idempotency.NewCleaner(log, idempotencyRepository, cfg.Idempotency)
+	app.StartWorker(ctx, "idempotency cleaner", idempotencyCleaner)
+
+	// init expired tokens cleaner
+	tokensCleaner := auth.NewTokensCleaner(log, tokensRepository, cfg.JWT)
+	app.StartWorker(ctx, "tokens cleaner", tokensCleaner)
+
+	// init jwt
+	tokenManager, err := auth.NewTokenManager(cfg.JWT)
+	if err != nil {
+		log.Error("failed to initialize token manager", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+
+	// init auth middleware
+	authenticate := myMiddleware.Authenticate(tokenManager, tokensRepository)
+	moderatorOnly := myMiddleware.RequireRole(models.Moderator)
+	idempotent := myMiddleware.Idempotency(log, idempotencyRepository, cfg.Idempotency)
 
 	// init router
 	router := chi.NewRouter()
 	router.Use(middleware.RequestID)
+	router.Use(myMiddleware.Tracing())
 	router.Use(mwLogger.New(log))
 	router.Use(middleware.Recoverer)
 	router.Use(middleware.URLFormat)
 
+	// GET /healthz
+	// GET /readyz
+	// GET /version
+	router.Get("/healthz", health.LivenessHandler())
+	router.Get("/readyz", health.ReadinessHandler(log, pgClient.DB(), migrationsRepository, expectedMigrationVersion, app))
+	router.Get("/version", health.VersionHandler())
+
+	// GET /metrics
+	router.Handle("/metrics", promhttp.Handler())
+
 	// GET /dummyLogin
-	router.Get("/dummyLogin", dummyLogin.New(log))
+	router.Get("/dummyLogin", dummyLogin.New(log, tokenManager, usersRepository))
 
 	// GET /house/{id}
+	// PATCH /house/{id}
+	// DELETE /house/{id}
+	// GET /house/{id}/info
+	// GET /house/{id}/flat/{number}
+	// POST /house/{id}/flats/import
+	// POST /house/{id}/subscribe
 	router.Route("/house/{id}", func(r chi.Router) {
-		r.Use(myMiddleware.JWTMiddleware)
-		r.Get("/", house.GetFlatsInHouseHandler(log, flatsRepo))
+		r.Use(authenticate)
+		r.Get("/", house.GetFlatsInHouseHandler(log, flatsRepo, photosRepository))
+		r.With(moderatorOnly).Patch("/", house.UpdateHouseHandler(log, housesRepo))
+		r.With(moderatorOnly).Delete("/", house.DeleteHouseHandler(log, housesRepo, auditRepository, txManager))
+		r.Get("/info", house.GetHouseHandler(log, housesRepo))
+		r.Get("/flat/{number}", house.GetFlatByNumberHandler(log, flatsRepo))
+		r.Post("/flats/import", house.ImportFlatsHandler(log, flatsRepo, housesRepo, txManager, cfg.Import))
+		r.Post("/subscribe", house.SubscribeHandler(log, subscriptionsRepository))
+	})
+
+	// GET /houses
+	router.Route("/houses", func(r chi.Router) {
+		r.Use(authenticate)
+		r.Get("/", house.ListHousesHandler(log, housesRepo))
 	})
 
 	// POST /house/create
 	router.Route("/house/create", func(r chi.Router) {
-		r.Use(myMiddleware.JWTModeratorOnlyMiddleware)
+		r.Use(authenticate, moderatorOnly, idempotent)
 		r.Post("/", house.CreateHouseHandler(log, housesRepo))
 	})
 
 	// POST /flatsRepo/create
 	router.Route("/flat/create", func(r chi.Router) {
-		r.Use(myMiddleware.JWTMiddleware)
+		r.Use(authenticate, idempotent)
 		r.Post("/", flat.CreateFlatHandler(log, flatsRepo, housesRepo, txManager))
 	})
 
 	// POST /flatsRepo/update
 	router.Route("/flat/update", func(r chi.Router) {
-		r.Use(myMiddleware.JWTModeratorOnlyMiddleware)
-		r.Post("/", flat.UpdateFlatHandler(log, flatsRepo))
+		r.Use(authenticate, moderatorOnly)
+		r.Post("/", flat.UpdateFlatHandler(log, flatsRepo, flatNotifier))
+	})
+
+	// DELETE /flat/{id}
+	// PATCH /flat/{id}/price
+	// GET /flat/{id}/history
+	// POST /flat/{id}/photos
+	// GET /flat/{id}/photos/{photoID}
+	// POST /flat/{id}/photos/{photoID}/status
+	router.Route("/flat/{id}", func(r chi.Router) {
+		r.Use(authenticate)
+		r.With(moderatorOnly).Delete("/", flat.DeleteFlatHandler(log, flatsRepo, auditRepository, txManager))
+		r.With(moderatorOnly).Patch("/price", flat.ChangePriceHandler(log, flatsRepo))
+		r.Get("/history", flat.GetHistoryHandler(log, flatsRepo))
+		r.Post("/photos", flat.UploadPhotosHandler(log, flatsRepo, photosRepository, photoStore, cfg.Photos))
+		r.Get("/photos/{photoID}", flat.GetPhotoHandler(log, flatsRepo, photosRepository, photoStore))
+		r.With(moderatorOnly).Post("/photos/{photoID}/status", flat.UpdatePhotoStatusHandler(log, photosRepository))
+	})
+
+	// GET /flats
+	router.Route("/flats", func(r chi.Router) {
+		r.Use(authenticate)
+		r.Get("/", flat.SearchFlatsHandler(log, flatsRepo))
+	})
+
+	// GET /export/flats
+	router.Route("/export", func(r chi.Router) {
+		r.Use(authenticate, moderatorOnly)
+		r.Get("/flats", flat.ExportFlatsHandler(log, flatsRepo))
+	})
+
+	// GET /moderation/queue
+	// POST /moderation/claim
+	router.Route("/moderation", func(r chi.Router) {
+		r.Use(authenticate, moderatorOnly)
+		r.Get("/queue", moderationHandlers.GetQueueHandler(log, flatsRepo))
+		r.Post("/claim", moderationHandlers.ClaimHandler(log, flatsRepo))
+	})
+
+	// POST /admin/flat/{id}/restore
+	// POST /admin/house/{id}/restore
+	router.Route("/admin", func(r chi.Router) {
+		r.Use(authenticate, moderatorOnly)
+		r.Post("/flat/{id}/restore", admin.RestoreFlatHandler(log, flatsRepo, auditRepository, txManager))
+		r.Post("/house/{id}/restore", admin.RestoreHouseHandler(log, housesRepo, auditRepository, txManager))
 	})
 
 	// POST /register
 	router.Post("/register", register.RegisterHandler(log, usersRepository))
 
 	// POST /login
-	router.Post("/login", login.LoginHandler(log, usersRepository))
+	router.Post("/login", login.LoginHandler(log, usersRepository, tokenManager, tokensRepository))
+
+	// POST /token/refresh
+	router.Post("/token/refresh", refresh.RefreshHandler(log, tokensRepository, tokenManager, txManager))
+
+	// POST /logout
+	router.Route("/logout", func(r chi.Router) {
+		r.Use(authenticate)
+		r.Post("/", logout.LogoutHandler(log, tokensRepository))
+	})
 
 	// Run server
 	srv := &http.Server{
@@ -109,7 +312,8 @@ func main() {
 
 	log.Info("server is listening", slog.String("address", cfg.HTTPServer.Address))
 
-	if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+	// блокируется до SIGINT/SIGTERM, затем останавливает сервер, воркеры и закрывает ресурсы
+	if err := app.Run(ctx, srv); err != nil {
 		log.Error("could not listen on",
 			slog.String("address", cfg.HTTPServer.Address),
 			slog.String("error", err.Error()),
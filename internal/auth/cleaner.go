@@ -0,0 +1,73 @@
+package auth
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"golang.org/x/exp/slog"
+
+	"realty-avito/internal/config"
+	"realty-avito/internal/lib/logger/sl"
+)
+
+type ExpiredTokensDeleter interface {
+	DeleteExpired(ctx context.Context) (int64, error)
+}
+
+// TokensCleaner периодически удаляет истекшие refresh-токены и записи denylist access-токенов,
+// чтобы таблица, которую читает каждый аутентифицированный запрос, не росла бесконечно
+type TokensCleaner struct {
+	log      *slog.Logger
+	tokens   ExpiredTokensDeleter
+	interval time.Duration
+
+	wg sync.WaitGroup
+}
+
+func NewTokensCleaner(log *slog.Logger, tokens ExpiredTokensDeleter, cfg config.JWTConfig) *TokensCleaner {
+	return &TokensCleaner{
+		log:      log.With(slog.String("component", "auth/tokens_cleaner")),
+		tokens:   tokens,
+		interval: cfg.CleanupInterval,
+	}
+}
+
+// Start запускает фоновую очистку, которая работает до отмены ctx
+func (c *TokensCleaner) Start(ctx context.Context) {
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		c.run(ctx)
+	}()
+}
+
+// Wait дожидается остановки фоновой очистки
+func (c *TokensCleaner) Wait() {
+	c.wg.Wait()
+}
+
+func (c *TokensCleaner) run(ctx context.Context) {
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		deleted, err := c.tokens.DeleteExpired(ctx)
+		if err != nil {
+			if ctx.Err() == nil {
+				c.log.Error("failed to delete expired tokens", sl.Err(err))
+			}
+			continue
+		}
+
+		if deleted > 0 {
+			c.log.Info("expired tokens deleted", slog.Int64("count", deleted))
+		}
+	}
+}
@@ -0,0 +1,142 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"fmt"
+	"os"
+
+	"github.com/golang-jwt/jwt/v5"
+
+	"realty-avito/internal/config"
+)
+
+const (
+	AlgorithmHS256 = "HS256"
+	AlgorithmRS256 = "RS256"
+	AlgorithmES256 = "ES256"
+)
+
+// signingKey ключ, которым подписываются новые токены
+type signingKey struct {
+	id     string
+	method jwt.SigningMethod
+	key    interface{}
+}
+
+// verificationKey ключ, которым проверяется подпись токена с соответствующим kid
+type verificationKey struct {
+	method jwt.SigningMethod
+	key    interface{}
+}
+
+func loadSigningKey(cfg config.JWTConfig) (*signingKey, *verificationKey, error) {
+	method, err := signingMethod(cfg.Algorithm)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	switch cfg.Algorithm {
+	case AlgorithmHS256:
+		if cfg.Secret == "" {
+			return nil, nil, fmt.Errorf("jwt secret is required for %s", cfg.Algorithm)
+		}
+
+		secret := []byte(cfg.Secret)
+
+		return &signingKey{id: cfg.KeyID, method: method, key: secret},
+			&verificationKey{method: method, key: secret},
+			nil
+	case AlgorithmRS256:
+		pemBytes, err := readPEM(cfg.PrivateKeyFile)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		privateKey, err := jwt.ParseRSAPrivateKeyFromPEM(pemBytes)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to parse RSA private key: %w", err)
+		}
+
+		return &signingKey{id: cfg.KeyID, method: method, key: privateKey},
+			&verificationKey{method: method, key: &privateKey.PublicKey},
+			nil
+	default: // AlgorithmES256
+		pemBytes, err := readPEM(cfg.PrivateKeyFile)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		privateKey, err := jwt.ParseECPrivateKeyFromPEM(pemBytes)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to parse EC private key: %w", err)
+		}
+
+		return &signingKey{id: cfg.KeyID, method: method, key: privateKey},
+			&verificationKey{method: method, key: &privateKey.PublicKey},
+			nil
+	}
+}
+
+func loadVerificationKey(cfg config.JWTVerificationKey) (*verificationKey, error) {
+	method, err := signingMethod(cfg.Algorithm)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.Algorithm == AlgorithmHS256 {
+		if cfg.Secret == "" {
+			return nil, fmt.Errorf("jwt secret is required for verification key %q", cfg.KeyID)
+		}
+
+		return &verificationKey{method: method, key: []byte(cfg.Secret)}, nil
+	}
+
+	pemBytes, err := readPEM(cfg.PublicKeyFile)
+	if err != nil {
+		return nil, err
+	}
+
+	var key interface{}
+	if cfg.Algorithm == AlgorithmRS256 {
+		var rsaKey *rsa.PublicKey
+		rsaKey, err = jwt.ParseRSAPublicKeyFromPEM(pemBytes)
+		key = rsaKey
+	} else {
+		var ecKey *ecdsa.PublicKey
+		ecKey, err = jwt.ParseECPublicKeyFromPEM(pemBytes)
+		key = ecKey
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse public key %q: %w", cfg.KeyID, err)
+	}
+
+	return &verificationKey{method: method, key: key}, nil
+}
+
+func signingMethod(algorithm string) (jwt.SigningMethod, error) {
+	switch algorithm {
+	case AlgorithmHS256:
+		return jwt.SigningMethodHS256, nil
+	case AlgorithmRS256:
+		return jwt.SigningMethodRS256, nil
+	case AlgorithmES256:
+		return jwt.SigningMethodES256, nil
+	default:
+		return nil, fmt.Errorf("unsupported jwt algorithm: %q", algorithm)
+	}
+}
+
+func readPEM(path string) ([]byte, error) {
+	if path == "" {
+		return nil, fmt.Errorf("path to PEM file is required")
+	}
+
+	pemBytes, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read PEM file %s: %w", path, err)
+	}
+
+	return pemBytes, nil
+}
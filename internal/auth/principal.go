@@ -0,0 +1,62 @@
+package auth
+
+import (
+	"context"
+	"time"
+
+	"realty-avito/internal/models"
+)
+
+type AuthMethod string
+
+const (
+	AuthMethodPassword AuthMethod = "password"
+	AuthMethodDummy    AuthMethod = "dummy"
+	AuthMethodRefresh  AuthMethod = "refresh"
+)
+
+// Principal аутентифицированный пользователь, от имени которого выполняется запрос
+type Principal struct {
+	Subject   string // uuid пользователя из users
+	Role      models.UserType
+	TokenID   string // jti access-токена
+	Method    AuthMethod
+	ExpiresAt time.Time
+}
+
+func (p Principal) HasRole(roles ...models.UserType) bool {
+	for _, role := range roles {
+		if p.Role == role {
+			return true
+		}
+	}
+
+	return false
+}
+
+// NewPrincipal собирает Principal из claims проверенного access-токена
+func NewPrincipal(claims *Claims) Principal {
+	principal := Principal{
+		Subject: claims.Subject,
+		Role:    models.UserType(claims.UserType),
+		TokenID: claims.ID,
+		Method:  claims.AuthMethod,
+	}
+
+	if claims.ExpiresAt != nil {
+		principal.ExpiresAt = claims.ExpiresAt.Time
+	}
+
+	return principal
+}
+
+type principalContextKey struct{}
+
+func WithPrincipal(ctx context.Context, principal Principal) context.Context {
+	return context.WithValue(ctx, principalContextKey{}, principal)
+}
+
+func PrincipalFromContext(ctx context.Context) (Principal, bool) {
+	principal, ok := ctx.Value(principalContextKey{}).(Principal)
+	return principal, ok
+}
@@ -0,0 +1,66 @@
+package auth
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"time"
+
+	"realty-avito/internal/repositories/tokensRepo"
+)
+
+type Issuer interface {
+	Issue(claims Claims) (string, error)
+	RefreshTTL() time.Duration
+}
+
+type RefreshTokenWriter interface {
+	CreateRefreshToken(ctx context.Context, createRefreshTokenEntity tokensRepo.CreateRefreshTokenEntity) error
+}
+
+// IssueTokenPair выпускает access-токен и refresh-токен для сессии familyID.
+// Refresh-токен сохраняется в БД в виде хеша.
+func IssueTokenPair(ctx context.Context, issuer Issuer, store RefreshTokenWriter, claims Claims, familyID string) (string, string, error) {
+	refreshToken, refreshTokenHash, err := NewRefreshToken()
+	if err != nil {
+		return "", "", err
+	}
+
+	err = store.CreateRefreshToken(ctx, tokensRepo.CreateRefreshTokenEntity{
+		TokenHash: refreshTokenHash,
+		FamilyID:  familyID,
+		Subject:   claims.Subject,
+		UserType:  claims.UserType,
+		TTL:       issuer.RefreshTTL(),
+	})
+	if err != nil {
+		return "", "", err
+	}
+
+	accessToken, err := issuer.Issue(claims)
+	if err != nil {
+		return "", "", err
+	}
+
+	return accessToken, refreshToken, nil
+}
+
+// NewRefreshToken генерирует непрозрачный refresh-токен. Клиенту отдается сам токен,
+// а в БД сохраняется только его хеш.
+func NewRefreshToken() (token string, tokenHash string, err error) {
+	raw := make([]byte, 32)
+	if _, err := rand.Read(raw); err != nil {
+		return "", "", err
+	}
+
+	token = base64.RawURLEncoding.EncodeToString(raw)
+
+	return token, HashRefreshToken(token), nil
+}
+
+func HashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
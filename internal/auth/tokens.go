@@ -0,0 +1,164 @@
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+
+	"realty-avito/internal/config"
+)
+
+var ErrInvalidToken = errors.New("invalid token")
+
+type Claims struct {
+	UserType   string     `json:"user_type"`
+	AuthMethod AuthMethod `json:"auth_method,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// TokenManager выпускает и проверяет JWT.
+// Новые токены подписываются активным ключом, а проверяются любым из ключей, перечисленных в конфиге:
+// так можно ротировать ключи, не разлогинивая пользователей.
+type TokenManager struct {
+	signingKey       *signingKey
+	verificationKeys map[string]*verificationKey
+	validMethods     []string
+
+	issuer     string
+	audience   string
+	ttl        time.Duration
+	refreshTTL time.Duration
+	leeway     time.Duration
+	now        func() time.Time
+}
+
+func NewTokenManager(cfg config.JWTConfig) (*TokenManager, error) {
+	signing, verification, err := loadSigningKey(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &TokenManager{
+		signingKey:       signing,
+		verificationKeys: map[string]*verificationKey{cfg.KeyID: verification},
+		validMethods:     []string{signing.method.Alg()},
+		issuer:           cfg.Issuer,
+		audience:         cfg.Audience,
+		ttl:              cfg.AccessTokenTTL,
+		refreshTTL:       cfg.RefreshTokenTTL,
+		leeway:           cfg.Leeway,
+		now:              time.Now,
+	}
+
+	for _, keyCfg := range cfg.VerificationKeys {
+		if _, exists := m.verificationKeys[keyCfg.KeyID]; exists {
+			return nil, fmt.Errorf("duplicate jwt key id: %q", keyCfg.KeyID)
+		}
+
+		key, err := loadVerificationKey(keyCfg)
+		if err != nil {
+			return nil, err
+		}
+
+		m.verificationKeys[keyCfg.KeyID] = key
+		m.validMethods = appendUnique(m.validMethods, key.method.Alg())
+	}
+
+	return m, nil
+}
+
+// Issue подписывает токен с переданными claims. Срок жизни, издатель, аудитория и jti проставляются из конфига,
+// если не заданы явно.
+func (m *TokenManager) Issue(claims Claims) (string, error) {
+	now := m.now()
+
+	if claims.ID == "" {
+		claims.ID = uuid.New().String()
+	}
+	if claims.Issuer == "" {
+		claims.Issuer = m.issuer
+	}
+	if len(claims.Audience) == 0 && m.audience != "" {
+		claims.Audience = jwt.ClaimStrings{m.audience}
+	}
+	if claims.IssuedAt == nil {
+		claims.IssuedAt = jwt.NewNumericDate(now)
+	}
+	if claims.NotBefore == nil {
+		claims.NotBefore = jwt.NewNumericDate(now)
+	}
+	if claims.ExpiresAt == nil {
+		claims.ExpiresAt = jwt.NewNumericDate(now.Add(m.ttl))
+	}
+
+	token := jwt.NewWithClaims(m.signingKey.method, claims)
+	if m.signingKey.id != "" {
+		token.Header["kid"] = m.signingKey.id
+	}
+
+	return token.SignedString(m.signingKey.key)
+}
+
+// RefreshTTL срок жизни refresh-токена
+func (m *TokenManager) RefreshTTL() time.Duration {
+	return m.refreshTTL
+}
+
+// Parse проверяет подпись и стандартные claims токена
+func (m *TokenManager) Parse(tokenString string) (*Claims, error) {
+	options := []jwt.ParserOption{
+		jwt.WithValidMethods(m.validMethods),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+		jwt.WithLeeway(m.leeway),
+		jwt.WithTimeFunc(m.now),
+	}
+	if m.issuer != "" {
+		options = append(options, jwt.WithIssuer(m.issuer))
+	}
+	if m.audience != "" {
+		options = append(options, jwt.WithAudience(m.audience))
+	}
+
+	claims := &Claims{}
+
+	token, err := jwt.ParseWithClaims(tokenString, claims, m.keyFunc, options...)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	if !token.Valid {
+		return nil, ErrInvalidToken
+	}
+
+	return claims, nil
+}
+
+func (m *TokenManager) keyFunc(token *jwt.Token) (interface{}, error) {
+	kid, _ := token.Header["kid"].(string)
+
+	key, ok := m.verificationKeys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+
+	// не даем подменить алгоритм: токен должен быть подписан тем же алгоритмом, что и ключ
+	if token.Method.Alg() != key.method.Alg() {
+		return nil, fmt.Errorf("unexpected signing method %q for key %q", token.Method.Alg(), kid)
+	}
+
+	return key.key, nil
+}
+
+func appendUnique(values []string, value string) []string {
+	for _, v := range values {
+		if v == value {
+			return values
+		}
+	}
+
+	return append(values, value)
+}
@@ -0,0 +1,114 @@
+package auth
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"realty-avito/internal/config"
+)
+
+func writePEM(t *testing.T, name string, blockType string, der []byte) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600))
+
+	return path
+}
+
+func baseConfig() config.JWTConfig {
+	return config.JWTConfig{
+		Issuer:         "realty-avito",
+		Audience:       "realty-avito",
+		AccessTokenTTL: time.Hour,
+	}
+}
+
+func TestTokenManagerKeyRotation(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	rsaPublic, err := x509.MarshalPKIXPublicKey(&rsaKey.PublicKey)
+	require.NoError(t, err)
+
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	ecPrivate, err := x509.MarshalECPrivateKey(ecKey)
+	require.NoError(t, err)
+
+	// старый ключ RS256
+	oldCfg := baseConfig()
+	oldCfg.Algorithm = AlgorithmRS256
+	oldCfg.KeyID = "rsa-1"
+	oldCfg.PrivateKeyFile = writePEM(t, "rsa.pem", "RSA PRIVATE KEY", x509.MarshalPKCS1PrivateKey(rsaKey))
+
+	oldManager, err := NewTokenManager(oldCfg)
+	require.NoError(t, err)
+
+	oldToken, err := oldManager.Issue(Claims{UserType: "client"})
+	require.NoError(t, err)
+
+	// новый ключ ES256, старый оставлен только для проверки
+	newCfg := baseConfig()
+	newCfg.Algorithm = AlgorithmES256
+	newCfg.KeyID = "ec-1"
+	newCfg.PrivateKeyFile = writePEM(t, "ec.pem", "EC PRIVATE KEY", ecPrivate)
+	newCfg.VerificationKeys = []config.JWTVerificationKey{{
+		KeyID:         "rsa-1",
+		Algorithm:     AlgorithmRS256,
+		PublicKeyFile: writePEM(t, "rsa.pub", "PUBLIC KEY", rsaPublic),
+	}}
+
+	newManager, err := NewTokenManager(newCfg)
+	require.NoError(t, err)
+
+	newToken, err := newManager.Issue(Claims{UserType: "moderator"})
+	require.NoError(t, err)
+
+	claims, err := newManager.Parse(oldToken)
+	require.NoError(t, err)
+	require.Equal(t, "client", claims.UserType)
+
+	claims, err = newManager.Parse(newToken)
+	require.NoError(t, err)
+	require.Equal(t, "moderator", claims.UserType)
+	require.Equal(t, "realty-avito", claims.Issuer)
+	require.NotEmpty(t, claims.ID)
+
+	// старый менеджер о новом ключе ничего не знает
+	_, err = oldManager.Parse(newToken)
+	require.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestTokenManagerRejectsInvalidClaims(t *testing.T) {
+	cfg := baseConfig()
+	cfg.Algorithm = AlgorithmHS256
+	cfg.Secret = "secret"
+
+	manager, err := NewTokenManager(cfg)
+	require.NoError(t, err)
+
+	token, err := manager.Issue(Claims{UserType: "client"})
+	require.NoError(t, err)
+
+	manager.now = func() time.Time { return time.Now().Add(2 * time.Hour) }
+	_, err = manager.Parse(token)
+	require.ErrorIs(t, err, ErrInvalidToken, "expired token must be rejected")
+
+	otherCfg := cfg
+	otherCfg.Audience = "another-service"
+	otherManager, err := NewTokenManager(otherCfg)
+	require.NoError(t, err)
+
+	_, err = otherManager.Parse(token)
+	require.ErrorIs(t, err, ErrInvalidToken, "token for another audience must be rejected")
+}
@@ -0,0 +1,36 @@
+package blobstore
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalStore(t *testing.T) {
+	ctx := context.Background()
+
+	store, err := NewLocalStore(t.TempDir())
+	require.NoError(t, err)
+
+	require.NoError(t, store.Put(ctx, "flats/1/photo.jpg", strings.NewReader("content")))
+
+	r, err := store.Get(ctx, "flats/1/photo.jpg")
+	require.NoError(t, err)
+	data, err := io.ReadAll(r)
+	require.NoError(t, err)
+	require.NoError(t, r.Close())
+	require.Equal(t, "content", string(data))
+
+	require.NoError(t, store.Delete(ctx, "flats/1/photo.jpg"))
+	_, err = store.Get(ctx, "flats/1/photo.jpg")
+	require.ErrorIs(t, err, ErrNotFound)
+
+	require.NoError(t, store.Delete(ctx, "flats/1/photo.jpg"))
+
+	for _, key := range []string{"", "/etc/passwd", "../outside", "flats/../../outside", "flats//1"} {
+		require.ErrorIs(t, store.Put(ctx, key, strings.NewReader("x")), ErrInvalidKey, key)
+	}
+}
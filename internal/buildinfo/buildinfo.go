@@ -0,0 +1,17 @@
+package buildinfo
+
+import "runtime"
+
+// Значения подставляются при сборке:
+//
+//	go build -ldflags "-X realty-avito/internal/buildinfo.Commit=$(git rev-parse HEAD) \
+//		-X realty-avito/internal/buildinfo.BuildTime=$(date -u +%Y-%m-%dT%H:%M:%SZ)" ./cmd
+var (
+	Commit    = "unknown"
+	BuildTime = "unknown"
+)
+
+// GoVersion версия Go, которой собран бинарник
+func GoVersion() string {
+	return runtime.Version()
+}
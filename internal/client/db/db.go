@@ -52,6 +52,11 @@ type QueryExecer interface {
 	QueryRowContext(ctx context.Context, q Query, args ...interface{}) pgx.Row
 }
 
+// CopyFromer интерфейс для массовой вставки строк через протокол COPY
+type CopyFromer interface {
+	CopyFromContext(ctx context.Context, tableName pgx.Identifier, columnNames []string, rowSrc pgx.CopyFromSource) (int64, error)
+}
+
 // Pinger интерфейс для проверки соединения с БД
 type Pinger interface {
 	Ping(ctx context.Context) error
@@ -60,6 +65,7 @@ type Pinger interface {
 // DB интерфейс для работы с БД
 type DB interface {
 	SQLExecer
+	CopyFromer
 	Transactor
 	Pinger
 	Close()
@@ -5,25 +5,34 @@ import (
 
 	"github.com/jackc/pgx/v4/pgxpool"
 	"github.com/pkg/errors"
+	"golang.org/x/exp/slog"
 
 	"realty-avito/internal/client/db"
+	"realty-avito/internal/config"
 )
 
 type pgClient struct {
 	masterDBC db.DB
+	pool      *pgxpool.Pool
 }
 
-func New(ctx context.Context, dsn string) (db.Client, error) {
+func New(ctx context.Context, dsn string, log *slog.Logger, queryLog config.QueryLogConfig) (db.Client, error) {
 	dbc, err := pgxpool.Connect(ctx, dsn)
 	if err != nil {
 		return nil, errors.Errorf("failed to connect to db: %v", err)
 	}
 
 	return &pgClient{
-		masterDBC: &pg{dbc: dbc},
+		masterDBC: NewDB(dbc, log, queryLog),
+		pool:      dbc,
 	}, nil
 }
 
+// Stat статистика пула соединений для метрик
+func (c *pgClient) Stat() *pgxpool.Stat {
+	return c.pool.Stat()
+}
+
 func (c *pgClient) DB() db.DB {
 	return c.masterDBC
 }
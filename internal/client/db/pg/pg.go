@@ -2,36 +2,47 @@ package pg
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/georgysavva/scany/pgxscan"
 	"github.com/jackc/pgconn"
 	"github.com/jackc/pgx/v4"
 	"github.com/jackc/pgx/v4/pgxpool"
-	"log"
-	"realty-avito/internal/client/db/prettier"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/exp/slog"
+	"strings"
+	"time"
 
 	"realty-avito/internal/client/db"
+	"realty-avito/internal/config"
+	"realty-avito/internal/metrics"
+	"realty-avito/internal/tracing"
 )
 
 type key string
 
+var tracer = tracing.Tracer("client/db/pg")
+
 const (
 	TxKey key = "tx"
 )
 
 type pg struct {
-	dbc *pgxpool.Pool
+	dbc      *pgxpool.Pool
+	queryLog *queryLogger
 }
 
-func NewDB(dbc *pgxpool.Pool) db.DB {
+func NewDB(dbc *pgxpool.Pool, log *slog.Logger, cfg config.QueryLogConfig) db.DB {
 	return &pg{
-		dbc: dbc,
+		dbc:      dbc,
+		queryLog: newQueryLogger(log, cfg),
 	}
 }
 
 func (p *pg) ScanOneContext(ctx context.Context, dest interface{}, q db.Query, args ...interface{}) error {
-	logQuery(ctx, q, args...)
-
 	row, err := p.QueryContext(ctx, q, args...)
 	if err != nil {
 		return err
@@ -41,8 +52,6 @@ func (p *pg) ScanOneContext(ctx context.Context, dest interface{}, q db.Query, a
 }
 
 func (p *pg) ScanAllContext(ctx context.Context, dest interface{}, q db.Query, args ...interface{}) error {
-	logQuery(ctx, q, args...)
-
 	rows, err := p.QueryContext(ctx, q, args...)
 	if err != nil {
 		return err
@@ -52,36 +61,77 @@ func (p *pg) ScanAllContext(ctx context.Context, dest interface{}, q db.Query, a
 }
 
 func (p *pg) ExecContext(ctx context.Context, q db.Query, args ...interface{}) (pgconn.CommandTag, error) {
-	logQuery(ctx, q, args...)
+	ctx, query := p.startQuery(ctx, q.Name, q.QueryRaw, args)
+
+	var tag pgconn.CommandTag
+	var err error
 
 	tx, ok := ctx.Value(TxKey).(pgx.Tx)
 	if ok {
-		return tx.Exec(ctx, q.QueryRaw, args...)
+		tag, err = tx.Exec(ctx, q.QueryRaw, args...)
+	} else {
+		tag, err = p.dbc.Exec(ctx, q.QueryRaw, args...)
 	}
 
-	return p.dbc.Exec(ctx, q.QueryRaw, args...)
+	query.finish(err, tag.RowsAffected())
+
+	return tag, err
 }
 
+// QueryContext завершает метрики и спан запроса, когда строки прочитаны до конца или закрыты
 func (p *pg) QueryContext(ctx context.Context, q db.Query, args ...interface{}) (pgx.Rows, error) {
-	logQuery(ctx, q, args...)
+	ctx, query := p.startQuery(ctx, q.Name, q.QueryRaw, args)
+
+	var rows pgx.Rows
+	var err error
 
 	tx, ok := ctx.Value(TxKey).(pgx.Tx)
 	if ok {
-		return tx.Query(ctx, q.QueryRaw, args...)
+		rows, err = tx.Query(ctx, q.QueryRaw, args...)
+	} else {
+		rows, err = p.dbc.Query(ctx, q.QueryRaw, args...)
 	}
 
-	return p.dbc.Query(ctx, q.QueryRaw, args...)
+	if err != nil {
+		query.finish(err, 0)
+		return nil, err
+	}
+
+	return &observedRows{Rows: rows, query: query}, nil
 }
 
 func (p *pg) QueryRowContext(ctx context.Context, q db.Query, args ...interface{}) pgx.Row {
-	logQuery(ctx, q, args...)
+	ctx, query := p.startQuery(ctx, q.Name, q.QueryRaw, args)
+
+	row := observedRow{query: query}
+
+	tx, ok := ctx.Value(TxKey).(pgx.Tx)
+	if ok {
+		row.row = tx.QueryRow(ctx, q.QueryRaw, args...)
+	} else {
+		row.row = p.dbc.QueryRow(ctx, q.QueryRaw, args...)
+	}
+
+	return row
+}
+
+func (p *pg) CopyFromContext(ctx context.Context, tableName pgx.Identifier, columnNames []string, rowSrc pgx.CopyFromSource) (int64, error) {
+	ctx, query := p.startQuery(ctx, "copy "+tableName.Sanitize(),
+		fmt.Sprintf("COPY %s (%s) FROM STDIN", tableName.Sanitize(), strings.Join(columnNames, ", ")), nil)
+
+	var n int64
+	var err error
 
 	tx, ok := ctx.Value(TxKey).(pgx.Tx)
 	if ok {
-		return tx.QueryRow(ctx, q.QueryRaw, args...)
+		n, err = tx.CopyFrom(ctx, tableName, columnNames, rowSrc)
+	} else {
+		n, err = p.dbc.CopyFrom(ctx, tableName, columnNames, rowSrc)
 	}
 
-	return p.dbc.QueryRow(ctx, q.QueryRaw, args...)
+	query.finish(err, n)
+
+	return n, err
 }
 
 func (p *pg) BeginTx(ctx context.Context, txOptions pgx.TxOptions) (pgx.Tx, error) {
@@ -100,11 +150,100 @@ func MakeContextTx(ctx context.Context, tx pgx.Tx) context.Context {
 	return context.WithValue(ctx, TxKey, tx)
 }
 
-func logQuery(ctx context.Context, q db.Query, args ...interface{}) {
-	prettyQuery := prettier.Pretty(q.QueryRaw, prettier.PlaceholderDollar, args...)
-	log.Println(
-		ctx,
-		fmt.Sprintf("sql: %s", q.Name),
-		fmt.Sprintf("query: %s", prettyQuery),
+// queryObserver метрики, спан и запись в лог одного запроса. Спан называется именем db.Query,
+// в него пишется текст запроса с плейсхолдерами, без значений аргументов.
+type queryObserver struct {
+	ctx       context.Context
+	name      string
+	statement string
+	args      []interface{}
+	start     time.Time
+	span      trace.Span
+	log       *queryLogger
+}
+
+func (p *pg) startQuery(ctx context.Context, name string, statement string, args []interface{}) (context.Context, *queryObserver) {
+	statement = strings.Join(strings.Fields(statement), " ")
+
+	ctx, span := tracer.Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemPostgreSQL,
+			semconv.DBOperation(name),
+			semconv.DBStatement(statement),
+		),
 	)
+
+	return ctx, &queryObserver{
+		ctx:       ctx,
+		name:      name,
+		statement: statement,
+		args:      args,
+		start:     time.Now(),
+		span:      span,
+		log:       p.queryLog,
+	}
+}
+
+func (o *queryObserver) finish(err error, rowsAffected int64) {
+	metrics.ObserveQuery(o.name, o.start, err)
+	o.log.logQuery(o.ctx, o.name, o.statement, o.args, time.Since(o.start), rowsAffected, err)
+
+	o.span.SetAttributes(attribute.Int64("db.rows_affected", rowsAffected))
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		o.span.RecordError(err)
+		o.span.SetStatus(codes.Error, err.Error())
+	}
+
+	o.span.End()
+}
+
+// observedRow откладывает запись метрик и завершение спана до Scan: ошибка QueryRow становится известна только там
+type observedRow struct {
+	row   pgx.Row
+	query *queryObserver
+}
+
+func (r observedRow) Scan(dest ...interface{}) error {
+	err := r.row.Scan(dest...)
+
+	var rowsAffected int64
+	if err == nil {
+		rowsAffected = 1
+	}
+	r.query.finish(err, rowsAffected)
+
+	return err
+}
+
+// observedRows считает прочитанные строки и завершает запрос на последней строке или при Close
+type observedRows struct {
+	pgx.Rows
+	query *queryObserver
+	count int64
+	done  bool
+}
+
+func (r *observedRows) Next() bool {
+	if r.Rows.Next() {
+		r.count++
+		return true
+	}
+
+	r.finish()
+	return false
+}
+
+func (r *observedRows) Close() {
+	r.Rows.Close()
+	r.finish()
+}
+
+func (r *observedRows) finish() {
+	if r.done {
+		return
+	}
+	r.done = true
+
+	r.query.finish(r.Rows.Err(), r.count)
 }
@@ -0,0 +1,180 @@
+package pg
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"golang.org/x/exp/slog"
+
+	"realty-avito/internal/config"
+	"realty-avito/internal/lib/logger/sl"
+)
+
+const (
+	redactedValue = "[REDACTED]"
+
+	// длинные значения (тела ответов, payload) обрезаются, чтобы не раздувать лог
+	maxArgLength = 200
+)
+
+var (
+	// col = $1, f.price >= $2, email ILIKE $3
+	comparisonRe = regexp.MustCompile(`(?i)([\w."]+)\s*(?:=|<>|!=|<=|>=|<|>|\bI?LIKE\b)\s*\$(\d+)`)
+	// col IN ($1,$2)
+	inListRe = regexp.MustCompile(`(?i)([\w."]+)\s+IN\s*\(([^()]*)\)`)
+	// INSERT INTO t (col1,col2) VALUES ($1,$2),($3,$4)
+	insertRe      = regexp.MustCompile(`(?is)INSERT\s+INTO\s+[\w."]+\s*\(([^()]*)\)\s*VALUES\s*(.*)`)
+	valuesGroupRe = regexp.MustCompile(`\(([^()]*)\)`)
+	placeholderRe = regexp.MustCompile(`\$(\d+)`)
+)
+
+// queryLogger пишет выполненные SQL-запросы в логгер запроса из контекста
+type queryLogger struct {
+	log    *slog.Logger
+	cfg    config.QueryLogConfig
+	redact map[string]struct{}
+}
+
+func newQueryLogger(log *slog.Logger, cfg config.QueryLogConfig) *queryLogger {
+	redact := make(map[string]struct{}, len(cfg.RedactColumns))
+	for _, column := range cfg.RedactColumns {
+		redact[strings.ToLower(strings.TrimSpace(column))] = struct{}{}
+	}
+
+	return &queryLogger{
+		log:    log.With(slog.String("component", "db/pg")),
+		cfg:    cfg,
+		redact: redact,
+	}
+}
+
+func (l *queryLogger) logQuery(ctx context.Context, name string, statement string, args []interface{}, duration time.Duration, rows int64, err error) {
+	if !l.cfg.Enabled {
+		return
+	}
+
+	log := sl.FromContext(ctx, l.log)
+
+	level := slog.LevelDebug
+	msg := "sql query"
+	if l.cfg.SlowThreshold > 0 && duration >= l.cfg.SlowThreshold {
+		level = slog.LevelWarn
+		msg = "slow sql query"
+	} else if !log.Enabled(ctx, level) || rand.Float64() >= l.cfg.SampleRatio {
+		return
+	}
+
+	attrs := []slog.Attr{
+		slog.String("query", name),
+		slog.String("statement", statement),
+		slog.Any("args", l.redactArgs(statement, args)),
+		slog.Int64("rows", rows),
+		slog.String("duration", duration.String()),
+	}
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		attrs = append(attrs, sl.Err(err))
+	}
+
+	log.LogAttrs(ctx, level, msg, attrs...)
+}
+
+// redactArgs форматирует аргументы для лога. Значение скрывается, если плейсхолдер
+// сравнивается с колонкой из списка или вставляется в нее через INSERT ... VALUES.
+func (l *queryLogger) redactArgs(statement string, args []interface{}) []string {
+	columns := placeholderColumns(statement)
+
+	formatted := make([]string, len(args))
+	for i, arg := range args {
+		if _, ok := l.redact[columns[i+1]]; ok {
+			formatted[i] = redactedValue
+			continue
+		}
+
+		formatted[i] = formatArg(arg)
+	}
+
+	return formatted
+}
+
+// placeholderColumns сопоставляет номер плейсхолдера с колонкой для запросов, которые строит squirrel
+func placeholderColumns(statement string) map[int]string {
+	columns := make(map[int]string)
+
+	set := func(placeholder string, column string) {
+		n, err := strconv.Atoi(placeholder)
+		if err != nil {
+			return
+		}
+
+		column = strings.Trim(column, `"`)
+		if i := strings.LastIndex(column, "."); i >= 0 {
+			column = strings.Trim(column[i+1:], `"`)
+		}
+		columns[n] = strings.ToLower(column)
+	}
+
+	for _, m := range comparisonRe.FindAllStringSubmatch(statement, -1) {
+		set(m[2], m[1])
+	}
+
+	for _, m := range inListRe.FindAllStringSubmatch(statement, -1) {
+		for _, p := range placeholderRe.FindAllStringSubmatch(m[2], -1) {
+			set(p[1], m[1])
+		}
+	}
+
+	if m := insertRe.FindStringSubmatch(statement); m != nil {
+		names := strings.Split(m[1], ",")
+		for _, group := range valuesGroupRe.FindAllStringSubmatch(m[2], -1) {
+			for i, value := range strings.Split(group[1], ",") {
+				p := placeholderRe.FindStringSubmatch(strings.TrimSpace(value))
+				if p == nil || i >= len(names) {
+					continue
+				}
+				set(p[1], strings.TrimSpace(names[i]))
+			}
+		}
+	}
+
+	return columns
+}
+
+func formatArg(arg interface{}) string {
+	if arg == nil {
+		return "NULL"
+	}
+
+	v := reflect.ValueOf(arg)
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return "NULL"
+		}
+		arg = v.Elem().Interface()
+	}
+
+	var value string
+	switch a := arg.(type) {
+	case string:
+		value = a
+	case []byte:
+		value = string(a)
+	case time.Time:
+		value = a.Format(time.RFC3339Nano)
+	default:
+		value = fmt.Sprintf("%v", a)
+	}
+
+	if len(value) > maxArgLength {
+		value = value[:maxArgLength] + "..."
+	}
+
+	return value
+}
@@ -0,0 +1,75 @@
+package pg
+
+import (
+	"bytes"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/exp/slog"
+
+	"realty-avito/internal/config"
+	"realty-avito/internal/lib/logger/sl"
+)
+
+func TestRedactArgs(t *testing.T) {
+	l := newQueryLogger(slog.Default(), config.QueryLogConfig{RedactColumns: []string{"password_hash", "email"}})
+
+	tests := []struct {
+		name      string
+		statement string
+		args      []interface{}
+		expected  []string
+	}{
+		{
+			name:      "insert",
+			statement: "INSERT INTO users (email,password_hash,user_type,uuid) VALUES ($1,$2,$3,$4) RETURNING id",
+			args:      []interface{}{"user@example.com", "hash", "client", "uuid-1"},
+			expected:  []string{redactedValue, redactedValue, "client", "uuid-1"},
+		},
+		{
+			name:      "where",
+			statement: "SELECT id FROM users u WHERE u.email = $1 AND user_type IN ($2,$3)",
+			args:      []interface{}{"user@example.com", "client", "moderator"},
+			expected:  []string{redactedValue, "client", "moderator"},
+		},
+		{
+			name:      "update",
+			statement: "UPDATE users SET password_hash = $1 WHERE id = $2",
+			args:      []interface{}{[]byte("hash"), int64(7)},
+			expected:  []string{redactedValue, "7"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.Equal(t, tt.expected, l.redactArgs(tt.statement, tt.args))
+		})
+	}
+}
+
+func TestLogQuery(t *testing.T) {
+	var buf bytes.Buffer
+	requestLog := slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelInfo})).
+		With(slog.String("request_id", "req-1"))
+	ctx := sl.WithLogger(context.Background(), requestLog)
+
+	l := newQueryLogger(slog.Default(), config.QueryLogConfig{
+		Enabled:       true,
+		SampleRatio:   1,
+		SlowThreshold: 100 * time.Millisecond,
+		RedactColumns: []string{"password_hash"},
+	})
+	statement := "SELECT id FROM users WHERE password_hash = $1"
+
+	// быстрый запрос пишется на уровне debug и отсекается уровнем логгера
+	l.logQuery(ctx, "users.Get", statement, []interface{}{"secret"}, time.Millisecond, 1, nil)
+	require.Empty(t, buf.String())
+
+	l.logQuery(ctx, "users.Get", statement, []interface{}{"secret"}, time.Second, 1, nil)
+	require.Contains(t, buf.String(), `"level":"WARN"`)
+	require.Contains(t, buf.String(), `"request_id":"req-1"`)
+	require.Contains(t, buf.String(), redactedValue)
+	require.NotContains(t, buf.String(), "secret")
+}
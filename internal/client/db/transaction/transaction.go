@@ -6,10 +6,16 @@ import (
 
 	"github.com/jackc/pgx/v4"
 	"github.com/pkg/errors"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 
 	"realty-avito/internal/client/db"
+	"realty-avito/internal/tracing"
 )
 
+var tracer = tracing.Tracer("client/db/transaction")
+
 type manager struct {
 	db db.Transactor
 }
@@ -29,6 +35,20 @@ func (m *manager) transaction(ctx context.Context, opts pgx.TxOptions, fn db.Han
 		return fn(ctx)
 	}
 
+	// Спан охватывает всю транзакцию, запросы внутри нее становятся дочерними спанами.
+	ctx, span := tracer.Start(ctx, "db.transaction",
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("db.isolation_level", string(opts.IsoLevel))),
+	)
+	defer func() {
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.SetAttributes(attribute.Bool("db.transaction.committed", err == nil))
+		span.End()
+	}()
+
 	// Стартуем новую транзакцию.
 	tx, err = m.db.BeginTx(ctx, opts)
 	if err != nil {
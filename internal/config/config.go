@@ -11,9 +11,17 @@ import (
 )
 
 type Config struct {
-	Env        string `yaml:"env" env:"ENV" env-default:"local" env-required:"true"`
-	HTTPServer `yaml:"http_server"`
-	Postgres   PostgresConfig `yaml:"postgres"`
+	Env         string `yaml:"env" env:"ENV" env-default:"local" env-required:"true"`
+	HTTPServer  `yaml:"http_server"`
+	Postgres    PostgresConfig    `yaml:"postgres"`
+	Notifier    NotifierConfig    `yaml:"notifier"`
+	Outbox      OutboxConfig      `yaml:"outbox"`
+	Moderation  ModerationConfig  `yaml:"moderation"`
+	JWT         JWTConfig         `yaml:"jwt"`
+	Photos      PhotosConfig      `yaml:"photos"`
+	Import      ImportConfig      `yaml:"import"`
+	Idempotency IdempotencyConfig `yaml:"idempotency"`
+	Tracing     TracingConfig     `yaml:"tracing"`
 }
 
 type HTTPServer struct {
@@ -22,6 +30,8 @@ type HTTPServer struct {
 	ProcessingTimeout time.Duration `yaml:"processing_timeout" env-default:"20ms"`
 	WriteTimeout      time.Duration `yaml:"write_timeout" env-default:"15ms"`
 	IdleTimeout       time.Duration `yaml:"idle_timeout" env-default:"30s"`
+	ShutdownDelay     time.Duration `yaml:"shutdown_delay" env-default:"5s"`    // /readyz уже отвечает 503, соединения еще принимаются
+	ShutdownTimeout   time.Duration `yaml:"shutdown_timeout" env-default:"15s"` // на завершение запросов и остановку воркеров
 }
 
 type PostgresConfig struct {
@@ -30,6 +40,130 @@ type PostgresConfig struct {
 	Password string `yaml:"password" env:"PG_PASSWORD" env-required:"true"`
 	Port     string `yaml:"port" env:"PG_PORT" env-required:"true"`
 	Host     string `yaml:"host" env:"PG_HOST" env-required:"true" env-default:"localhost"`
+
+	QueryLog QueryLogConfig `yaml:"query_log"`
+}
+
+func (c PostgresConfig) String() string {
+	type plain PostgresConfig
+
+	c.Password = redact(c.Password)
+
+	return fmt.Sprintf("%+v", plain(c))
+}
+
+// QueryLogConfig логирование SQL-запросов. Обычные запросы пишутся на уровне debug с долей SampleRatio,
+// запросы дольше SlowThreshold пишутся всегда на уровне warn. Значения аргументов для колонок
+// из RedactColumns в лог не попадают.
+type QueryLogConfig struct {
+	Enabled       bool          `yaml:"enabled" env:"PG_QUERY_LOG_ENABLED" env-default:"true"`
+	SampleRatio   float64       `yaml:"sample_ratio" env:"PG_QUERY_LOG_SAMPLE_RATIO" env-default:"1"`
+	SlowThreshold time.Duration `yaml:"slow_threshold" env:"PG_QUERY_LOG_SLOW_THRESHOLD" env-default:"200ms"`
+	RedactColumns []string      `yaml:"redact_columns" env:"PG_QUERY_LOG_REDACT_COLUMNS" env-separator:"," env-default:"password_hash,token_hash,email,payload,response_body"`
+}
+
+type NotifierConfig struct {
+	SenderFile   string        `yaml:"sender_file" env:"NOTIFIER_SENDER_FILE"`
+	Workers      int           `yaml:"workers" env-default:"2"`
+	QueueSize    int           `yaml:"queue_size" env-default:"100"`
+	MaxRetries   int           `yaml:"max_retries" env-default:"5"`
+	RetryBackoff time.Duration `yaml:"retry_backoff" env-default:"1s"`
+}
+
+type OutboxConfig struct {
+	Publisher      string        `yaml:"publisher" env:"OUTBOX_PUBLISHER" env-default:"channel"` // channel, file, webhook
+	ChannelSize    int           `yaml:"channel_size" env-default:"100"`
+	FilePath       string        `yaml:"file_path" env:"OUTBOX_FILE_PATH" env-default:"./outbox.ndjson"`
+	WebhookURL     string        `yaml:"webhook_url" env:"OUTBOX_WEBHOOK_URL"`
+	WebhookTimeout time.Duration `yaml:"webhook_timeout" env-default:"5s"`
+	PollInterval   time.Duration `yaml:"poll_interval" env-default:"1s"`
+	BatchSize      int           `yaml:"batch_size" env-default:"100"`
+	RetryBackoff   time.Duration `yaml:"retry_backoff" env-default:"1s"`
+	MaxBackoff     time.Duration `yaml:"max_backoff" env-default:"1m"`
+}
+
+type ModerationConfig struct {
+	ClaimTimeout    time.Duration `yaml:"claim_timeout" env-default:"30m"`
+	ReleaseInterval time.Duration `yaml:"release_interval" env-default:"1m"`
+}
+
+type JWTConfig struct {
+	Algorithm        string               `yaml:"algorithm" env:"JWT_ALGORITHM" env-default:"HS256"` // HS256, RS256, ES256
+	Secret           string               `yaml:"secret" env:"JWT_SECRET"`                           // для HS256
+	PrivateKeyFile   string               `yaml:"private_key_file" env:"JWT_PRIVATE_KEY_FILE"`       // PEM для RS256/ES256
+	KeyID            string               `yaml:"key_id" env:"JWT_KEY_ID"`
+	VerificationKeys []JWTVerificationKey `yaml:"verification_keys"` // предыдущие ключи, которые еще принимаются
+	Issuer           string               `yaml:"issuer" env-default:"realty-avito"`
+	Audience         string               `yaml:"audience" env-default:"realty-avito"`
+	AccessTokenTTL   time.Duration        `yaml:"access_token_ttl" env-default:"15m"`
+	RefreshTokenTTL  time.Duration        `yaml:"refresh_token_ttl" env-default:"720h"`
+	Leeway           time.Duration        `yaml:"leeway" env-default:"30s"`
+	CleanupInterval  time.Duration        `yaml:"cleanup_interval" env-default:"1h"` // как часто удаляются истекшие токены
+}
+
+type JWTVerificationKey struct {
+	KeyID         string `yaml:"key_id"`
+	Algorithm     string `yaml:"algorithm"`
+	Secret        string `yaml:"secret"`
+	PublicKeyFile string `yaml:"public_key_file"`
+}
+
+// redacted заменяет секрет в выводе конфига, пустое значение остается пустым, чтобы было видно, что оно не задано
+const redacted = "[REDACTED]"
+
+func redact(secret string) string {
+	if secret == "" {
+		return ""
+	}
+
+	return redacted
+}
+
+// String скрывает ключ подписи: конфиг целиком пишется в лог при старте
+func (c JWTConfig) String() string {
+	type plain JWTConfig
+
+	c.Secret = redact(c.Secret)
+
+	return fmt.Sprintf("%+v", plain(c))
+}
+
+func (k JWTVerificationKey) String() string {
+	type plain JWTVerificationKey
+
+	k.Secret = redact(k.Secret)
+
+	return fmt.Sprintf("%+v", plain(k))
+}
+
+type PhotosConfig struct {
+	StorageDir         string `yaml:"storage_dir" env:"PHOTOS_STORAGE_DIR" env-default:"./photos"`
+	MaxPhotoSize       int64  `yaml:"max_photo_size" env-default:"10485760"` // байт на один файл
+	MaxPhotosPerUpload int    `yaml:"max_photos_per_upload" env-default:"10"`
+	ThumbnailSize      int    `yaml:"thumbnail_size" env-default:"320"`       // пикселей по большей стороне
+	MaxPhotoDimension  int    `yaml:"max_photo_dimension" env-default:"8000"` // пикселей по любой стороне
+	MaxPhotoPixels     int64  `yaml:"max_photo_pixels" env-default:"40000000"`
+}
+
+type ImportConfig struct {
+	MaxRows     int   `yaml:"max_rows" env-default:"5000"`
+	MaxFileSize int64 `yaml:"max_file_size" env-default:"10485760"` // байт
+}
+
+type IdempotencyConfig struct {
+	TTL             time.Duration `yaml:"ttl" env-default:"24h"` // сколько хранится ответ на запрос с ключом
+	MaxBodySize     int64         `yaml:"max_body_size" env-default:"1048576"`
+	CleanupInterval time.Duration `yaml:"cleanup_interval" env-default:"1h"`
+	LockTimeout     time.Duration `yaml:"lock_timeout" env-default:"1m"` // после него повтор занимает ключ, ответ на который так и не сохранен
+}
+
+type TracingConfig struct {
+	Exporter     string  `yaml:"exporter" env:"TRACING_EXPORTER" env-default:"none"` // none, otlp, file
+	OTLPEndpoint string  `yaml:"otlp_endpoint" env:"TRACING_OTLP_ENDPOINT" env-default:"localhost:4318"`
+	OTLPInsecure bool    `yaml:"otlp_insecure" env:"TRACING_OTLP_INSECURE" env-default:"true"`
+	FilePath     string  `yaml:"file_path" env:"TRACING_FILE_PATH" env-default:"./traces.ndjson"`
+	ServiceName  string  `yaml:"service_name" env-default:"realty-avito"`
+	SampleRatio  float64 `yaml:"sample_ratio" env-default:"1"` // доля трасс, которые начинаются в этом сервисе
 }
 
 func MustLoad() *Config {
@@ -0,0 +1,29 @@
+package config
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfigStringRedactsSecrets(t *testing.T) {
+	cfg := Config{
+		Postgres: PostgresConfig{User: "realty-user", Password: "pg-password"},
+		JWT: JWTConfig{
+			Secret: "jwt-secret",
+			KeyID:  "key-1",
+			VerificationKeys: []JWTVerificationKey{
+				{KeyID: "key-0", Secret: "old-jwt-secret"},
+			},
+		},
+	}
+
+	dump := fmt.Sprintf("%+v", cfg)
+
+	require.NotContains(t, dump, "pg-password")
+	require.NotContains(t, dump, "jwt-secret")
+	require.Contains(t, dump, "realty-user")
+	require.Contains(t, dump, "key-0")
+	require.Contains(t, dump, redacted)
+}
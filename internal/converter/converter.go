@@ -1,6 +1,7 @@
 package converter
 
 import (
+	"fmt"
 	"time"
 
 	"golang.org/x/crypto/bcrypt"
@@ -8,15 +9,30 @@ import (
 	handlers "realty-avito/internal/http-server/handlers"
 	flatRepo "realty-avito/internal/repositories/flatsRepo"
 	houseRepo "realty-avito/internal/repositories/housesRepo"
+	"realty-avito/internal/repositories/photosRepo"
+	"realty-avito/internal/repositories/subscriptionsRepo"
 	"realty-avito/internal/repositories/usersRepo"
 )
 
 func ConvertCreateFlatRequestToEntity(req handlers.CreateFlatRequest) flatRepo.CreateFlatEntity {
 	return flatRepo.CreateFlatEntity{
-		HouseID: req.HouseID,
-		Price:   req.Price,
-		Rooms:   req.Rooms,
-		Status:  flatRepo.StatusCreated,
+		HouseID:        req.HouseID,
+		Price:          req.Price,
+		Rooms:          req.Rooms,
+		Status:         flatRepo.StatusCreated,
+		FlatNumber:     req.FlatNumber,
+		FlatAttributes: ConvertFlatAttributesToEntity(req.FlatAttributes),
+	}
+}
+
+func ConvertImportFlatRowToEntity(houseID int64, row handlers.ImportFlatRow) flatRepo.CreateFlatEntity {
+	return flatRepo.CreateFlatEntity{
+		HouseID:        houseID,
+		Price:          row.Price,
+		Rooms:          row.Rooms,
+		Status:         flatRepo.StatusCreated,
+		FlatNumber:     row.FlatNumber,
+		FlatAttributes: ConvertFlatAttributesToEntity(row.FlatAttributes),
 	}
 }
 
@@ -29,24 +45,105 @@ func ConvertUpdateFlatRequestToEntity(req handlers.UpdateFlatRequest) flatRepo.U
 
 func ConvertFlatEntityToCreateResponse(entity *flatRepo.FlatEntity) handlers.CreateFlatResponse {
 	return handlers.CreateFlatResponse{
-		ID:      entity.ID,
-		HouseID: entity.HouseID,
-		Price:   entity.Price,
-		Rooms:   entity.Rooms,
-		Status:  handlers.FlatModerationStatus(entity.Status),
+		ID:             entity.ID,
+		HouseID:        entity.HouseID,
+		Price:          entity.Price,
+		Rooms:          entity.Rooms,
+		FlatNumber:     entity.FlatNumber,
+		Status:         handlers.FlatModerationStatus(entity.Status),
+		FlatAttributes: ConvertEntityToFlatAttributes(entity.FlatAttributes),
+		Version:        entity.Version,
 	}
 }
 
 func ConvertFlatEntityToUpdateResponse(entity *flatRepo.FlatEntity) handlers.UpdateFlatResponse {
 	return handlers.UpdateFlatResponse{
-		ID:      entity.ID,
-		HouseID: entity.HouseID,
-		Price:   entity.Price,
-		Rooms:   entity.Rooms,
-		Status:  handlers.FlatModerationStatus(entity.Status),
+		ID:             entity.ID,
+		HouseID:        entity.HouseID,
+		Price:          entity.Price,
+		Rooms:          entity.Rooms,
+		FlatNumber:     entity.FlatNumber,
+		Status:         handlers.FlatModerationStatus(entity.Status),
+		FlatAttributes: ConvertEntityToFlatAttributes(entity.FlatAttributes),
+		Version:        entity.Version,
 	}
 }
 
+func ConvertFlatAttributesToEntity(attributes handlers.FlatAttributes) flatRepo.FlatAttributes {
+	entity := flatRepo.FlatAttributes{
+		TotalArea:   attributes.TotalArea,
+		LivingArea:  attributes.LivingArea,
+		Floor:       attributes.Floor,
+		TotalFloors: attributes.TotalFloors,
+		HasBalcony:  attributes.HasBalcony,
+		Description: attributes.Description,
+	}
+
+	if attributes.Layout != nil {
+		layout := flatRepo.FlatLayout(*attributes.Layout)
+		entity.Layout = &layout
+	}
+
+	return entity
+}
+
+func ConvertEntityToFlatAttributes(entity flatRepo.FlatAttributes) handlers.FlatAttributes {
+	attributes := handlers.FlatAttributes{
+		TotalArea:   entity.TotalArea,
+		LivingArea:  entity.LivingArea,
+		Floor:       entity.Floor,
+		TotalFloors: entity.TotalFloors,
+		HasBalcony:  entity.HasBalcony,
+		Description: entity.Description,
+	}
+
+	if entity.Layout != nil {
+		layout := handlers.FlatLayout(*entity.Layout)
+		attributes.Layout = &layout
+	}
+
+	return attributes
+}
+
+func ConvertPhotoEntityToPhoto(entity photosRepo.PhotoEntity) handlers.Photo {
+	url := fmt.Sprintf("/flat/%d/photos/%d", entity.FlatID, entity.ID)
+
+	return handlers.Photo{
+		ID:           entity.ID,
+		URL:          url,
+		ThumbnailURL: url + "?size=thumbnail",
+		ContentType:  entity.ContentType,
+		Size:         entity.Size,
+		Width:        entity.Width,
+		Height:       entity.Height,
+		Status:       string(entity.Status),
+	}
+}
+
+func ConvertPhotoEntitiesToPhotos(entities []photosRepo.PhotoEntity) []handlers.Photo {
+	photos := make([]handlers.Photo, len(entities))
+
+	for i, entity := range entities {
+		photos[i] = ConvertPhotoEntityToPhoto(entity)
+	}
+	return photos
+}
+
+func ConvertFlatHistoryEntitiesToRecords(entities []flatRepo.FlatHistoryEntity) []handlers.FlatHistoryRecord {
+	records := make([]handlers.FlatHistoryRecord, len(entities))
+
+	for i, entity := range entities {
+		records[i] = handlers.FlatHistoryRecord{
+			ChangeType: string(entity.ChangeType),
+			Price:      entity.Price,
+			Status:     handlers.FlatModerationStatus(entity.Status),
+			ChangedBy:  entity.ChangedBy,
+			ChangedAt:  entity.CreatedAt,
+		}
+	}
+	return records
+}
+
 func ConvertCreateHouseRequestToEntity(req handlers.CreateHouseRequest) houseRepo.CreateHouseEntity {
 	return houseRepo.CreateHouseEntity{
 		Address:   req.Address,
@@ -62,6 +159,52 @@ func ConvertEntityToCreateHouseResponse(entity *houseRepo.HouseEntity) handlers.
 		Year:      entity.Year,
 		Developer: entity.Developer,
 		CreatedAt: entity.CreatedAt.Format(time.RFC3339),
+		Version:   entity.Version,
+	}
+}
+
+func ConvertHouseEntityToHouse(entity houseRepo.HouseEntity) handlers.House {
+	return handlers.House{
+		ID:        entity.ID,
+		Address:   entity.Address,
+		Year:      entity.Year,
+		Developer: entity.Developer,
+		CreatedAt: entity.CreatedAt,
+		UpdatedAt: entity.UpdatedAt,
+		Version:   entity.Version,
+	}
+}
+
+func ConvertHouseEntitiesToHouses(entities []houseRepo.HouseEntity) []handlers.House {
+	houses := make([]handlers.House, len(entities))
+
+	for i, entity := range entities {
+		houses[i] = ConvertHouseEntityToHouse(entity)
+	}
+	return houses
+}
+
+func ConvertUpdateHouseRequestToEntity(houseID int64, req handlers.UpdateHouseRequest) houseRepo.UpdateHouseEntity {
+	return houseRepo.UpdateHouseEntity{
+		ID:        houseID,
+		Address:   req.Address,
+		Year:      req.Year,
+		Developer: req.Developer,
+	}
+}
+
+func ConvertSubscribeRequestToEntity(houseID int64, req handlers.SubscribeRequest) subscriptionsRepo.CreateSubscriptionEntity {
+	return subscriptionsRepo.CreateSubscriptionEntity{
+		HouseID: houseID,
+		Email:   req.Email,
+	}
+}
+
+func ConvertSubscriptionEntityToResponse(entity *subscriptionsRepo.SubscriptionEntity) handlers.SubscribeResponse {
+	return handlers.SubscribeResponse{
+		ID:      entity.ID,
+		HouseID: entity.HouseID,
+		Email:   entity.Email,
 	}
 }
 
@@ -76,11 +219,30 @@ func ConvertFlatEntitiesToFlats(entities []flatRepo.FlatEntity) []handlers.Flat
 
 func ConvertEntityToFlat(entity flatRepo.FlatEntity) handlers.Flat {
 	return handlers.Flat{
-		ID:      entity.ID,
-		HouseID: entity.HouseID,
-		Price:   entity.Price,
-		Rooms:   entity.Rooms,
-		Status:  handlers.FlatModerationStatus(entity.Status),
+		ID:             entity.ID,
+		HouseID:        entity.HouseID,
+		Price:          entity.Price,
+		Rooms:          entity.Rooms,
+		FlatNumber:     entity.FlatNumber,
+		Status:         handlers.FlatModerationStatus(entity.Status),
+		FlatAttributes: ConvertEntityToFlatAttributes(entity.FlatAttributes),
+		Version:        entity.Version,
+	}
+}
+
+func ConvertExportFlatEntityToExportFlat(entity *flatRepo.ExportFlatEntity) handlers.ExportFlat {
+	return handlers.ExportFlat{
+		ID:             entity.ID,
+		HouseID:        entity.HouseID,
+		FlatNumber:     entity.FlatNumber,
+		Price:          entity.Price,
+		Rooms:          entity.Rooms,
+		Status:         handlers.FlatModerationStatus(entity.Status),
+		FlatAttributes: ConvertEntityToFlatAttributes(entity.FlatAttributes),
+		CreatedAt:      entity.CreatedAt,
+		HouseAddress:   entity.HouseAddress,
+		HouseYear:      entity.HouseYear,
+		HouseDeveloper: entity.HouseDeveloper,
 	}
 }
 
@@ -1,6 +1,7 @@
 package repo_errors
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/jackc/pgconn"
@@ -14,6 +15,57 @@ func (e *ErrHouseNotFound) Error() string {
 	return fmt.Sprintf("house with ID %d not found", e.HouseID)
 }
 
+type ErrFlatNotFound struct {
+	FlatID int64
+}
+
+func (e *ErrFlatNotFound) Error() string {
+	return fmt.Sprintf("flat with ID %d not found", e.FlatID)
+}
+
+type ErrFlatNumberTaken struct {
+	HouseID    int64
+	FlatNumber int64
+}
+
+func (e *ErrFlatNumberTaken) Error() string {
+	return fmt.Sprintf("flat number %d is already taken in house with ID %d", e.FlatNumber, e.HouseID)
+}
+
+// ErrFlatNumbersTaken при импорте часть номеров квартир уже занята в доме
+type ErrFlatNumbersTaken struct {
+	HouseID     int64
+	FlatNumbers []int64
+}
+
+func (e *ErrFlatNumbersTaken) Error() string {
+	return fmt.Sprintf("flat numbers %v are already taken in house with ID %d", e.FlatNumbers, e.HouseID)
+}
+
+type ErrFlatNumberNotFound struct {
+	HouseID    int64
+	FlatNumber int64
+}
+
+func (e *ErrFlatNumberNotFound) Error() string {
+	return fmt.Sprintf("flat number %d not found in house with ID %d", e.FlatNumber, e.HouseID)
+}
+
+type ErrPhotoNotFound struct {
+	PhotoID int64
+}
+
+func (e *ErrPhotoNotFound) Error() string {
+	return fmt.Sprintf("photo with ID %d not found", e.PhotoID)
+}
+
+// ErrFlatStateConflict условное обновление квартиры не выполнилось:
+// квартира не найдена, находится в другом статусе или на модерации у другого модератора
+var ErrFlatStateConflict = errors.New("flat state conflict")
+
+// ErrVersionMismatch ресурс изменился после того, как клиент прочитал его версию
+var ErrVersionMismatch = errors.New("resource version mismatch")
+
 // Функция проверкяет что ошибка = нарушение внешнего ключа
 func IsForeignKeyViolation(err error) bool {
 	if pgErr, ok := err.(*pgconn.PgError); ok {
@@ -21,3 +73,12 @@ func IsForeignKeyViolation(err error) bool {
 	}
 	return false
 }
+
+// IsUniqueViolation проверяет, что ошибка = нарушение ограничения уникальности
+func IsUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code == "23505" // SQLSTATE 23505 - нарушение уникальности
+	}
+	return false
+}
@@ -0,0 +1,146 @@
+package admin
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+
+	"realty-avito/internal/auth"
+	"realty-avito/internal/client/db"
+	"realty-avito/internal/converter"
+	repo_errors "realty-avito/internal/errors"
+	"realty-avito/internal/lib/logger/sl"
+	"realty-avito/internal/models"
+	"realty-avito/internal/repositories/auditRepo"
+	"realty-avito/internal/repositories/flatsRepo"
+	"realty-avito/internal/repositories/housesRepo"
+)
+
+type FlatsRestorer interface {
+	RestoreFlat(ctx context.Context, flatID int64) (*flatsRepo.FlatEntity, error)
+}
+
+type HousesRestorer interface {
+	RestoreHouse(ctx context.Context, houseID int64) (*housesRepo.HouseEntity, error)
+}
+
+type AuditWriter interface {
+	AddRecord(ctx context.Context, createAuditRecordEntity auditRepo.CreateAuditRecordEntity) error
+}
+
+// RestoreFlatHandler возвращает мягко удаленную квартиру
+func RestoreFlatHandler(log *slog.Logger, flatsRestorer FlatsRestorer, auditWriter AuditWriter, txManager db.TxManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.admin.restoreFlat"
+
+		restore(w, r, log, op, auditWriter, txManager, auditRepo.EntityFlat, func(ctx context.Context, id int64) (interface{}, error) {
+			flat, err := flatsRestorer.RestoreFlat(ctx, id)
+			if err != nil {
+				return nil, err
+			}
+			return converter.ConvertEntityToFlat(*flat), nil
+		})
+	}
+}
+
+// RestoreHouseHandler возвращает мягко удаленный дом вместе с квартирами, удаленными вместе с ним
+func RestoreHouseHandler(log *slog.Logger, housesRestorer HousesRestorer, auditWriter AuditWriter, txManager db.TxManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.admin.restoreHouse"
+
+		restore(w, r, log, op, auditWriter, txManager, auditRepo.EntityHouse, func(ctx context.Context, id int64) (interface{}, error) {
+			house, err := housesRestorer.RestoreHouse(ctx, id)
+			if err != nil {
+				return nil, err
+			}
+			return converter.ConvertHouseEntityToHouse(*house), nil
+		})
+	}
+}
+
+// restore общая часть восстановления: разбор id, восстановление и запись в журнал в одной транзакции
+func restore(
+	w http.ResponseWriter,
+	r *http.Request,
+	log *slog.Logger,
+	op string,
+	auditWriter AuditWriter,
+	txManager db.TxManager,
+	entityType string,
+	restoreEntity func(ctx context.Context, id int64) (interface{}, error),
+) {
+	ctx := r.Context()
+
+	log = log.With(
+		slog.String("op", op),
+		slog.String("request_id", middleware.GetReqID(ctx)),
+	)
+
+	principal, ok := auth.PrincipalFromContext(ctx)
+	if !ok {
+		log.Error("principal not found in context")
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	var idStr = chi.URLParam(r, "id")
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil || id < 1 {
+		log.Error("invalid ID", slog.String("id", idStr))
+		http.Error(w, "Invalid ID", http.StatusBadRequest)
+		return
+	}
+
+	var response interface{}
+
+	err = txManager.ReadCommitted(ctx, func(ctx context.Context) error {
+		var errTx error
+		if response, errTx = restoreEntity(ctx, id); errTx != nil {
+			return errTx
+		}
+
+		return auditWriter.AddRecord(ctx, auditRepo.CreateAuditRecordEntity{
+			ActorID:    principal.Subject,
+			Action:     auditRepo.ActionRestore,
+			EntityType: entityType,
+			EntityID:   id,
+		})
+	})
+	if err != nil {
+		var flatNotFoundErr *repo_errors.ErrFlatNotFound
+		var houseNotFoundErr *repo_errors.ErrHouseNotFound
+		if errors.As(err, &flatNotFoundErr) || errors.As(err, &houseNotFoundErr) {
+			log.Info("deleted entity not found", slog.Int64("id", id))
+			http.Error(w, "deleted "+entityType+" not found", http.StatusNotFound)
+			return
+		}
+
+		if errors.Is(err, repo_errors.ErrFlatStateConflict) {
+			log.Info("restore conflict", slog.Int64("id", id), sl.Err(err))
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+
+		log.Error("failed to restore", sl.Err(err))
+
+		w.Header().Set("Retry-After", "60")
+		w.WriteHeader(http.StatusInternalServerError)
+
+		errResponse := models.InternalServerErrorResponse{
+			Message:   err.Error(),
+			RequestID: middleware.GetReqID(ctx),
+			Code:      12345,
+		}
+		render.JSON(w, r, errResponse)
+		return
+	}
+
+	render.JSON(w, r, response)
+	log.Info("restored", slog.String("entity_type", entityType), slog.Int64("id", id))
+}
@@ -1,21 +1,39 @@
 package dummyLogin
 
 import (
+	"context"
+	"fmt"
 	"net/http"
 
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/render"
+	"github.com/golang-jwt/jwt/v5"
 	"golang.org/x/exp/slog"
 
-	myMiddleware "realty-avito/internal/http-server/middleware"
+	"realty-avito/internal/auth"
 	"realty-avito/internal/models"
+	"realty-avito/internal/repositories/usersRepo"
 )
 
+// dummyPasswordHash заведомо невалидный bcrypt-хеш: под dummy-пользователем нельзя войти через /login
+const dummyPasswordHash = "!"
+
 type DummyLoginResponse struct {
 	Token string `json:"token"`
 }
 
-func New(log *slog.Logger) http.HandlerFunc {
+type TokenIssuer interface {
+	Issue(claims auth.Claims) (string, error)
+}
+
+type UsersEnsurer interface {
+	EnsureUser(ctx context.Context, user usersRepo.UserEntity) (*usersRepo.UserEntity, error)
+}
+
+// New выдает токен без пароля. На каждую роль заводится один общий dummy-пользователь,
+// чтобы у dummy-модератора был стабильный идентификатор, на который ссылаются квартиры,
+// а публичная ручка не добавляла строк в users при каждом вызове.
+func New(log *slog.Logger, tokens TokenIssuer, users UsersEnsurer) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.dummyLogin.dummyLogin"
 
@@ -32,7 +50,34 @@ func New(log *slog.Logger) http.HandlerFunc {
 			return
 		}
 
-		token, err := myMiddleware.GenerateDummyJWT(userType)
+		user, err := users.EnsureUser(r.Context(), usersRepo.UserEntity{
+			Email:        fmt.Sprintf("dummy-%s@%s", userType, usersRepo.DummyEmailDomain),
+			PasswordHash: dummyPasswordHash,
+			UserType:     userType,
+		})
+		if err == nil && (user.PasswordHash != dummyPasswordHash || user.UserType != userType) {
+			// адрес занят обычным пользователем: выдавать токен от его имени нельзя
+			err = fmt.Errorf("email %s belongs to a regular user", user.Email)
+		}
+		if err != nil {
+			log.Error("failed to get dummy user", slog.StringValue(err.Error()))
+
+			w.Header().Set("Retry-After", "60")
+			w.WriteHeader(http.StatusInternalServerError)
+
+			render.JSON(w, r, models.InternalServerErrorResponse{
+				Message:   "failed to get dummy user",
+				RequestID: middleware.GetReqID(r.Context()),
+				Code:      12345,
+			})
+			return
+		}
+
+		token, err := tokens.Issue(auth.Claims{
+			UserType:         userType,
+			AuthMethod:       auth.AuthMethodDummy,
+			RegisteredClaims: jwt.RegisteredClaims{Subject: user.UUID},
+		})
 		if err != nil {
 			log.Error("failed to create dummy token", slog.String("op", op), slog.StringValue(err.Error()))
 
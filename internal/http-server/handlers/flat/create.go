@@ -16,7 +16,9 @@ import (
 	"realty-avito/internal/converter"
 	repo_errors "realty-avito/internal/errors"
 	"realty-avito/internal/http-server/handlers"
+	"realty-avito/internal/lib/etag"
 	"realty-avito/internal/lib/logger/sl"
+	"realty-avito/internal/metrics"
 	"realty-avito/internal/models"
 	"realty-avito/internal/repositories/flatsRepo"
 )
@@ -66,13 +68,14 @@ func CreateFlatHandler(log *slog.Logger, flatsWriter FlatsWriter, housesWriter H
 		var successfullyCreatedFlatEntity *flatsRepo.FlatEntity
 
 		err = txManager.ReadCommitted(ctx, func(ctx context.Context) error {
-			var errTx error
-			createdFlatEntity, errTx := flatsWriter.CreateFlat(ctx, flatEntity)
+			// дом блокируется до вставки квартиры, как и при импорте: при обратном порядке
+			// параллельные создание и импорт с одинаковым номером квартиры взаимно блокируются
+			errTx := housesWriter.UpdateHouseUpdatedAt(ctx, req.HouseID)
 			if errTx != nil {
 				return errTx
 			}
 
-			errTx = housesWriter.UpdateHouseUpdatedAt(ctx, req.HouseID)
+			createdFlatEntity, errTx := flatsWriter.CreateFlat(ctx, flatEntity)
 			if errTx != nil {
 				return errTx
 			}
@@ -92,6 +95,13 @@ func CreateFlatHandler(log *slog.Logger, flatsWriter FlatsWriter, housesWriter H
 				return
 			}
 
+			var flatNumberTakenErr *repo_errors.ErrFlatNumberTaken
+			if errors.As(err, &flatNumberTakenErr) {
+				errorDescription := fmt.Sprintf("Cannot create flat: %s", flatNumberTakenErr.Error())
+				http.Error(w, errorDescription, http.StatusConflict)
+				return
+			}
+
 			w.WriteHeader(http.StatusInternalServerError)
 			w.Header().Set("Retry-After", "60")
 
@@ -104,8 +114,11 @@ func CreateFlatHandler(log *slog.Logger, flatsWriter FlatsWriter, housesWriter H
 			return
 		}
 
+		metrics.FlatsCreated.WithLabelValues(metrics.SourceAPI).Inc()
+
 		response := converter.ConvertFlatEntityToCreateResponse(successfullyCreatedFlatEntity)
 
+		w.Header().Set("ETag", etag.FromVersion(successfullyCreatedFlatEntity.Version))
 		render.JSON(w, r, response)
 		log.Info("request handled successfully", slog.String("op", op))
 	}
@@ -0,0 +1,95 @@
+package flat
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+
+	"realty-avito/internal/auth"
+	"realty-avito/internal/client/db"
+	repo_errors "realty-avito/internal/errors"
+	"realty-avito/internal/lib/logger/sl"
+	"realty-avito/internal/models"
+	"realty-avito/internal/repositories/auditRepo"
+	"realty-avito/internal/repositories/flatsRepo"
+)
+
+type FlatsDeleter interface {
+	DeleteFlat(ctx context.Context, flatID int64) (*flatsRepo.FlatEntity, error)
+}
+
+type AuditWriter interface {
+	AddRecord(ctx context.Context, createAuditRecordEntity auditRepo.CreateAuditRecordEntity) error
+}
+
+// DeleteFlatHandler мягко удаляет квартиру и в той же транзакции пишет запись в журнал действий
+func DeleteFlatHandler(log *slog.Logger, flatsDeleter FlatsDeleter, auditWriter AuditWriter, txManager db.TxManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.flat.delete"
+
+		ctx := r.Context()
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(ctx)),
+		)
+
+		principal, ok := auth.PrincipalFromContext(ctx)
+		if !ok {
+			log.Error("principal not found in context")
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		var flatIDStr = chi.URLParam(r, "id")
+		flatID, err := strconv.ParseInt(flatIDStr, 10, 64)
+		if err != nil || flatID < 1 {
+			log.Error("invalid flat ID", slog.String("flat_id", flatIDStr))
+			http.Error(w, "Invalid flat ID", http.StatusBadRequest)
+			return
+		}
+
+		err = txManager.ReadCommitted(ctx, func(ctx context.Context) error {
+			if _, errTx := flatsDeleter.DeleteFlat(ctx, flatID); errTx != nil {
+				return errTx
+			}
+
+			return auditWriter.AddRecord(ctx, auditRepo.CreateAuditRecordEntity{
+				ActorID:    principal.Subject,
+				Action:     auditRepo.ActionDelete,
+				EntityType: auditRepo.EntityFlat,
+				EntityID:   flatID,
+			})
+		})
+		if err != nil {
+			var flatNotFoundErr *repo_errors.ErrFlatNotFound
+			if errors.As(err, &flatNotFoundErr) {
+				log.Info("flat not found", slog.Int64("flat_id", flatID))
+				http.Error(w, flatNotFoundErr.Error(), http.StatusNotFound)
+				return
+			}
+
+			log.Error("failed to delete flat", sl.Err(err))
+
+			w.Header().Set("Retry-After", "60")
+			w.WriteHeader(http.StatusInternalServerError)
+
+			response := models.InternalServerErrorResponse{
+				Message:   err.Error(),
+				RequestID: middleware.GetReqID(ctx),
+				Code:      12345,
+			}
+			render.JSON(w, r, response)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+		log.Info("flat deleted", slog.Int64("flat_id", flatID), slog.String("moderator_id", principal.Subject))
+	}
+}
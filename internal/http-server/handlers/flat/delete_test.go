@@ -0,0 +1,89 @@
+package flat
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"realty-avito/internal/auth"
+	"realty-avito/internal/client/db"
+	repo_errors "realty-avito/internal/errors"
+	"realty-avito/internal/lib/logger"
+	"realty-avito/internal/models"
+	"realty-avito/internal/repositories/auditRepo"
+	auditMocks "realty-avito/internal/repositories/auditRepo/mocks"
+	"realty-avito/internal/repositories/flatsRepo"
+	"realty-avito/internal/repositories/flatsRepo/mocks"
+)
+
+type noTxManager struct{}
+
+func (noTxManager) ReadCommitted(ctx context.Context, f db.Handler) error {
+	return f(ctx)
+}
+
+func TestDeleteFlatHandler(t *testing.T) {
+	tests := []struct {
+		name               string
+		flatID             string
+		prepareMocks       func(flats *mocks.FlatsRepository, audit *auditMocks.AuditRepository)
+		expectedStatusCode int
+	}{
+		{
+			name:   "flat deleted with audit record",
+			flatID: "5",
+			prepareMocks: func(flats *mocks.FlatsRepository, audit *auditMocks.AuditRepository) {
+				flats.On("DeleteFlat", mock.Anything, int64(5)).Return(&flatsRepo.FlatEntity{ID: 5}, nil).Once()
+				audit.On("AddRecord", mock.Anything, auditRepo.CreateAuditRecordEntity{
+					ActorID:    "moderator-uuid",
+					Action:     auditRepo.ActionDelete,
+					EntityType: auditRepo.EntityFlat,
+					EntityID:   5,
+				}).Return(nil).Once()
+			},
+			expectedStatusCode: http.StatusNoContent,
+		},
+		{
+			name:   "flat not found",
+			flatID: "6",
+			prepareMocks: func(flats *mocks.FlatsRepository, audit *auditMocks.AuditRepository) {
+				flats.On("DeleteFlat", mock.Anything, int64(6)).Return(nil, &repo_errors.ErrFlatNotFound{FlatID: 6}).Once()
+			},
+			expectedStatusCode: http.StatusNotFound,
+		},
+		{
+			name:               "invalid flat ID",
+			flatID:             "abc",
+			prepareMocks:       func(flats *mocks.FlatsRepository, audit *auditMocks.AuditRepository) {},
+			expectedStatusCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockFlatsRepo := new(mocks.FlatsRepository)
+			mockAuditRepo := new(auditMocks.AuditRepository)
+			tt.prepareMocks(mockFlatsRepo, mockAuditRepo)
+
+			req := httptest.NewRequest(http.MethodDelete, "/flat/"+tt.flatID, nil)
+
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("id", tt.flatID)
+			ctx := context.WithValue(req.Context(), chi.RouteCtxKey, rctx)
+			ctx = auth.WithPrincipal(ctx, auth.Principal{Subject: "moderator-uuid", Role: models.Moderator})
+			req = req.WithContext(ctx)
+
+			rr := httptest.NewRecorder()
+			DeleteFlatHandler(logger.SetupLogger("local"), mockFlatsRepo, mockAuditRepo, noTxManager{}).ServeHTTP(rr, req)
+
+			require.Equal(t, tt.expectedStatusCode, rr.Code)
+			mockFlatsRepo.AssertExpectations(t)
+			mockAuditRepo.AssertExpectations(t)
+		})
+	}
+}
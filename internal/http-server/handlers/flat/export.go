@@ -0,0 +1,299 @@
+package flat
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+
+	"realty-avito/internal/auth"
+	"realty-avito/internal/converter"
+	"realty-avito/internal/http-server/handlers"
+	"realty-avito/internal/lib/logger/sl"
+	"realty-avito/internal/models"
+	"realty-avito/internal/repositories/flatsRepo"
+)
+
+const (
+	exportFormatCSV    = "csv"
+	exportFormatNDJSON = "ndjson"
+	exportFormatXLSX   = "xlsx" // CSV, который Excel открывает без мастера импорта
+
+	utf8BOM = "\ufeff"
+)
+
+// exportColumns колонки CSV-выгрузки, названия совпадают с полями NDJSON
+var exportColumns = []string{
+	"id", "house_id", "flat_number", "price", "rooms", "status",
+	"total_area", "living_area", "floor", "total_floors", "layout", "has_balcony", "description",
+	"created_at", "house_address", "house_year", "house_developer",
+}
+
+type FlatsExporter interface {
+	ExportFlats(ctx context.Context, filter flatsRepo.SearchFlatsFilter, fn func(flat *flatsRepo.ExportFlatEntity) error) error
+}
+
+// flatExportWriter пишет строки выгрузки в одном из форматов
+type flatExportWriter interface {
+	WriteHeader() error
+	Write(flat handlers.ExportFlat) error
+	Flush() error
+}
+
+// ExportFlatsHandler потоково выгружает квартиры с данными домов в CSV, NDJSON или CSV для Excel (?format=xlsx).
+// Фильтры и сортировка такие же, как у GET /flats, limit не используется, выгружаются все подходящие квартиры.
+// Строки пишутся в ответ по мере чтения из базы, поэтому память не зависит от размера выгрузки.
+func ExportFlatsHandler(log *slog.Logger, flatsExporter FlatsExporter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.flat.export"
+
+		ctx := r.Context()
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(ctx)),
+		)
+
+		principal, ok := auth.PrincipalFromContext(ctx)
+		if !ok {
+			log.Error("principal not found in context")
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		query := r.URL.Query()
+
+		format := query.Get("format")
+		if format == "" {
+			format = exportFormatCSV
+		}
+
+		var exportWriter flatExportWriter
+		contentType := "text/csv; charset=utf-8"
+		extension := "csv"
+
+		switch format {
+		case exportFormatCSV:
+			exportWriter = newCSVExportWriter(w, ',', false)
+		case exportFormatXLSX:
+			exportWriter = newCSVExportWriter(w, ';', true)
+		case exportFormatNDJSON:
+			exportWriter = newNDJSONExportWriter(w)
+			contentType = "application/x-ndjson"
+			extension = "ndjson"
+		default:
+			log.Error("invalid export format", slog.String("format", format))
+			http.Error(w, "format must be csv, ndjson or xlsx", http.StatusBadRequest)
+			return
+		}
+
+		query.Del("format")
+		query.Del("limit")
+
+		filter, err := parseSearchFilter(query, principal)
+		if err != nil {
+			log.Error("invalid export parameters", sl.Err(err))
+
+			if errors.Is(err, errStatusFilterForbidden) {
+				http.Error(w, err.Error(), http.StatusForbidden)
+				return
+			}
+
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		filter.Limit = 0
+
+		// большая выгрузка идет дольше, чем WriteTimeout сервера
+		if err := http.NewResponseController(w).SetWriteDeadline(time.Time{}); err != nil {
+			log.Debug("failed to reset write deadline", sl.Err(err))
+		}
+
+		filename := fmt.Sprintf("flats-%s.%s", time.Now().UTC().Format("20060102-150405"), extension)
+
+		// заголовки отправляются с первой строкой, чтобы ошибку запроса еще можно было вернуть как 500
+		started := false
+		start := func() error {
+			started = true
+
+			w.Header().Set("Content-Type", contentType)
+			w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+			w.WriteHeader(http.StatusOK)
+
+			return exportWriter.WriteHeader()
+		}
+
+		count := 0
+
+		err = flatsExporter.ExportFlats(ctx, filter, func(flat *flatsRepo.ExportFlatEntity) error {
+			if !started {
+				if err := start(); err != nil {
+					return err
+				}
+			}
+
+			count++
+
+			return exportWriter.Write(converter.ConvertExportFlatEntityToExportFlat(flat))
+		})
+		if err == nil && !started {
+			err = start()
+		}
+		if err == nil {
+			err = exportWriter.Flush()
+		}
+
+		if err != nil {
+			if !started {
+				log.Error("failed to export flats", sl.Err(err))
+
+				w.Header().Set("Retry-After", "60")
+				w.WriteHeader(http.StatusInternalServerError)
+
+				response := models.InternalServerErrorResponse{
+					Message:   err.Error(),
+					RequestID: middleware.GetReqID(ctx),
+					Code:      12345,
+				}
+				render.JSON(w, r, response)
+				return
+			}
+
+			// часть файла уже отправлена: обрываем соединение, чтобы клиент не принял обрезанную выгрузку за полную
+			log.Error("export interrupted", sl.Err(err), slog.Int("rows", count))
+			panic(http.ErrAbortHandler)
+		}
+
+		log.Info("request handled successfully", slog.String("format", format), slog.Int("rows", count))
+	}
+}
+
+type csvExportWriter struct {
+	out      io.Writer
+	writer   *csv.Writer
+	forExcel bool
+	record   []string
+}
+
+// newCSVExportWriter для Excel добавляет BOM, чтобы кириллица открывалась в UTF-8, использует CRLF
+// и экранирует значения, которые Excel принял бы за формулы
+func newCSVExportWriter(out io.Writer, comma rune, forExcel bool) *csvExportWriter {
+	writer := csv.NewWriter(out)
+	writer.Comma = comma
+	writer.UseCRLF = forExcel
+
+	return &csvExportWriter{
+		out:      out,
+		writer:   writer,
+		forExcel: forExcel,
+		record:   make([]string, len(exportColumns)),
+	}
+}
+
+func (e *csvExportWriter) WriteHeader() error {
+	if e.forExcel {
+		if _, err := io.WriteString(e.out, utf8BOM); err != nil {
+			return err
+		}
+	}
+
+	return e.writer.Write(exportColumns)
+}
+
+func (e *csvExportWriter) Write(flat handlers.ExportFlat) error {
+	e.record[0] = strconv.FormatInt(flat.ID, 10)
+	e.record[1] = strconv.FormatInt(flat.HouseID, 10)
+	e.record[2] = formatOptionalInt(flat.FlatNumber)
+	e.record[3] = strconv.FormatInt(flat.Price, 10)
+	e.record[4] = strconv.FormatInt(flat.Rooms, 10)
+	e.record[5] = string(flat.Status)
+	e.record[6] = formatOptionalFloat(flat.TotalArea)
+	e.record[7] = formatOptionalFloat(flat.LivingArea)
+	e.record[8] = formatOptionalInt(flat.Floor)
+	e.record[9] = formatOptionalInt(flat.TotalFloors)
+	e.record[10] = ""
+	if flat.Layout != nil {
+		e.record[10] = string(*flat.Layout)
+	}
+	e.record[11] = ""
+	if flat.HasBalcony != nil {
+		e.record[11] = strconv.FormatBool(*flat.HasBalcony)
+	}
+	e.record[12] = e.text(flat.Description)
+	e.record[13] = flat.CreatedAt.Format(time.RFC3339)
+	e.record[14] = e.text(&flat.HouseAddress)
+	e.record[15] = strconv.Itoa(flat.HouseYear)
+	e.record[16] = e.text(flat.HouseDeveloper)
+
+	return e.writer.Write(e.record)
+}
+
+func (e *csvExportWriter) Flush() error {
+	e.writer.Flush()
+	return e.writer.Error()
+}
+
+// text форматирует пользовательский текст; для Excel значения, начинающиеся с =, +, - или @,
+// предваряются апострофом, иначе Excel выполнит их как формулу
+func (e *csvExportWriter) text(value *string) string {
+	if value == nil {
+		return ""
+	}
+
+	if e.forExcel && *value != "" && strings.ContainsRune("=+-@", rune((*value)[0])) {
+		return "'" + *value
+	}
+
+	return *value
+}
+
+type ndjsonExportWriter struct {
+	buffer  *bufio.Writer
+	encoder *json.Encoder
+}
+
+func newNDJSONExportWriter(out io.Writer) *ndjsonExportWriter {
+	buffer := bufio.NewWriter(out)
+
+	return &ndjsonExportWriter{
+		buffer:  buffer,
+		encoder: json.NewEncoder(buffer),
+	}
+}
+
+func (e *ndjsonExportWriter) WriteHeader() error {
+	return nil
+}
+
+func (e *ndjsonExportWriter) Write(flat handlers.ExportFlat) error {
+	return e.encoder.Encode(flat)
+}
+
+func (e *ndjsonExportWriter) Flush() error {
+	return e.buffer.Flush()
+}
+
+func formatOptionalInt(value *int64) string {
+	if value == nil {
+		return ""
+	}
+	return strconv.FormatInt(*value, 10)
+}
+
+func formatOptionalFloat(value *float64) string {
+	if value == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*value, 'f', -1, 64)
+}
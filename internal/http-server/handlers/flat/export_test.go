@@ -0,0 +1,141 @@
+package flat
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"realty-avito/internal/auth"
+	"realty-avito/internal/lib/logger"
+	"realty-avito/internal/models"
+	"realty-avito/internal/repositories/flatsRepo"
+	"realty-avito/internal/repositories/flatsRepo/mocks"
+)
+
+func TestExportFlatsHandler(t *testing.T) {
+	description := "=HYPERLINK(\"http://evil\")"
+	flatNumber := int64(12)
+
+	exported := []flatsRepo.ExportFlatEntity{
+		{
+			FlatEntity: flatsRepo.FlatEntity{
+				ID: 1, HouseID: 3, Price: 5000000, Rooms: 2, Status: flatsRepo.StatusApproved, FlatNumber: &flatNumber,
+				CreatedAt:      time.Date(2024, 8, 20, 10, 0, 0, 0, time.UTC),
+				FlatAttributes: flatsRepo.FlatAttributes{Description: &description},
+			},
+			HouseAddress: "Лесная, 5",
+			HouseYear:    2020,
+		},
+	}
+
+	exportRows := func(rows []flatsRepo.ExportFlatEntity) func(args mock.Arguments) {
+		return func(args mock.Arguments) {
+			fn := args.Get(2).(func(flat *flatsRepo.ExportFlatEntity) error)
+			for i := range rows {
+				require.NoError(t, fn(&rows[i]))
+			}
+		}
+	}
+
+	tests := []struct {
+		name               string
+		query              string
+		prepareMock        func(m *mocks.FlatsRepository)
+		expectedStatusCode int
+		expectedFilename   string
+		expectedBody       string
+	}{
+		{
+			name:  "csv with filters",
+			query: "?status=approved&min_price=100&limit=5",
+			prepareMock: func(m *mocks.FlatsRepository) {
+				m.On("ExportFlats", mock.Anything, mock.MatchedBy(func(f flatsRepo.SearchFlatsFilter) bool {
+					return f.Limit == 0 && *f.MinPrice == 100 && len(f.Statuses) == 1
+				}), mock.Anything).Return(nil).Run(exportRows(exported)).Once()
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedFilename:   ".csv\"",
+			expectedBody: "id,house_id,flat_number,price,rooms,status,total_area,living_area,floor,total_floors,layout," +
+				"has_balcony,description,created_at,house_address,house_year,house_developer\n" +
+				"1,3,12,5000000,2,approved,,,,,,,\"=HYPERLINK(\"\"http://evil\"\")\",2024-08-20T10:00:00Z,\"Лесная, 5\",2020,\n",
+		},
+		{
+			name:  "csv for excel",
+			query: "?format=xlsx",
+			prepareMock: func(m *mocks.FlatsRepository) {
+				m.On("ExportFlats", mock.Anything, mock.Anything, mock.Anything).Return(nil).Run(exportRows(exported)).Once()
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedFilename:   ".csv\"",
+			expectedBody: "\ufeffid;house_id;flat_number;price;rooms;status;total_area;living_area;floor;total_floors;layout;" +
+				"has_balcony;description;created_at;house_address;house_year;house_developer\r\n" +
+				"1;3;12;5000000;2;approved;;;;;;;\"'=HYPERLINK(\"\"http://evil\"\")\";2024-08-20T10:00:00Z;Лесная, 5;2020;\r\n",
+		},
+		{
+			name:  "ndjson",
+			query: "?format=ndjson",
+			prepareMock: func(m *mocks.FlatsRepository) {
+				m.On("ExportFlats", mock.Anything, mock.Anything, mock.Anything).Return(nil).Run(exportRows(exported)).Once()
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedFilename:   ".ndjson\"",
+			expectedBody: `{"id":1,"house_id":3,"flat_number":12,"price":5000000,"rooms":2,"status":"approved",` +
+				`"description":"=HYPERLINK(\"http://evil\")","created_at":"2024-08-20T10:00:00Z",` +
+				`"house_address":"Лесная, 5","house_year":2020}` + "\n",
+		},
+		{
+			name:  "empty export has header only",
+			query: "",
+			prepareMock: func(m *mocks.FlatsRepository) {
+				m.On("ExportFlats", mock.Anything, mock.Anything, mock.Anything).Return(nil).Once()
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedFilename:   ".csv\"",
+			expectedBody: "id,house_id,flat_number,price,rooms,status,total_area,living_area,floor,total_floors,layout," +
+				"has_balcony,description,created_at,house_address,house_year,house_developer\n",
+		},
+		{
+			name: "query error before first row",
+			prepareMock: func(m *mocks.FlatsRepository) {
+				m.On("ExportFlats", mock.Anything, mock.Anything, mock.Anything).Return(errors.New("db is down")).Once()
+			},
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+		{
+			name:               "invalid format",
+			query:              "?format=xml",
+			prepareMock:        func(m *mocks.FlatsRepository) {},
+			expectedStatusCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockFlatsRepo := new(mocks.FlatsRepository)
+			tt.prepareMock(mockFlatsRepo)
+
+			req := httptest.NewRequest(http.MethodGet, "/export/flats"+tt.query, nil)
+			req = req.WithContext(auth.WithPrincipal(req.Context(), auth.Principal{Subject: "moderator", Role: models.Moderator}))
+
+			rr := httptest.NewRecorder()
+			ExportFlatsHandler(logger.SetupLogger("local"), mockFlatsRepo).ServeHTTP(rr, req)
+
+			require.Equal(t, tt.expectedStatusCode, rr.Code)
+
+			if tt.expectedStatusCode == http.StatusOK {
+				disposition := rr.Header().Get("Content-Disposition")
+				require.True(t, strings.HasPrefix(disposition, "attachment; filename=\"flats-"), disposition)
+				require.True(t, strings.HasSuffix(disposition, tt.expectedFilename), disposition)
+				require.Equal(t, tt.expectedBody, rr.Body.String())
+			}
+
+			mockFlatsRepo.AssertExpectations(t)
+		})
+	}
+}
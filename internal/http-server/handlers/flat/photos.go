@@ -0,0 +1,518 @@
+package flat
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"golang.org/x/exp/slog"
+
+	"realty-avito/internal/auth"
+	"realty-avito/internal/blobstore"
+	"realty-avito/internal/config"
+	"realty-avito/internal/converter"
+	repo_errors "realty-avito/internal/errors"
+	"realty-avito/internal/http-server/handlers"
+	"realty-avito/internal/lib/etag"
+	"realty-avito/internal/lib/logger/sl"
+	"realty-avito/internal/lib/thumbnail"
+	"realty-avito/internal/models"
+	"realty-avito/internal/repositories/flatsRepo"
+	"realty-avito/internal/repositories/photosRepo"
+)
+
+const (
+	photosFormField      = "photos"
+	thumbnailContentType = "image/jpeg"
+	multipartMemoryLimit = 32 << 20
+	photoCacheControl    = "private, no-cache"
+)
+
+// photoExtensions допустимые типы фотографий, тип определяется по содержимому файла, а не по заголовку клиента
+var photoExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+}
+
+type FlatGetter interface {
+	GetFlatByFlatID(ctx context.Context, flatID int64) (*flatsRepo.FlatEntity, error)
+}
+
+type PhotosWriter interface {
+	CreatePhoto(ctx context.Context, createPhotoEntity photosRepo.CreatePhotoEntity) (*photosRepo.PhotoEntity, error)
+}
+
+type PhotosGetter interface {
+	GetPhoto(ctx context.Context, flatID int64, photoID int64) (*photosRepo.PhotoEntity, error)
+}
+
+type PhotoStatusSetter interface {
+	SetPhotoStatus(ctx context.Context, flatID int64, photoID int64, status photosRepo.PhotoStatus) (*photosRepo.PhotoEntity, error)
+}
+
+// preparedPhoto проверенная фотография, готовая к сохранению
+type preparedPhoto struct {
+	data        []byte
+	thumbnail   []byte
+	contentType string
+	width       int
+	height      int
+}
+
+type photoError struct {
+	status  int
+	message string
+}
+
+func (e *photoError) Error() string {
+	return e.message
+}
+
+// UploadPhotosHandler принимает multipart-загрузку фотографий квартиры в поле "photos".
+// Сначала проверяются все файлы, и только потом что-то сохраняется. Новые фотографии
+// ждут модерации: их одобряет решение по квартире или UpdatePhotoStatusHandler.
+func UploadPhotosHandler(
+	log *slog.Logger,
+	flatGetter FlatGetter,
+	photosWriter PhotosWriter,
+	blobs blobstore.BlobStore,
+	cfg config.PhotosConfig,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.flat.uploadPhotos"
+
+		ctx := r.Context()
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(ctx)),
+		)
+
+		flatID, ok := parseFlatID(w, r, log)
+		if !ok {
+			return
+		}
+
+		if _, err := flatGetter.GetFlatByFlatID(ctx, flatID); err != nil {
+			writeFlatLookupError(w, r, log, err)
+			return
+		}
+
+		r.Body = http.MaxBytesReader(w, r.Body, cfg.MaxPhotoSize*int64(cfg.MaxPhotosPerUpload)+multipartMemoryLimit)
+
+		if err := r.ParseMultipartForm(multipartMemoryLimit); err != nil {
+			log.Error("failed to parse multipart form", sl.Err(err))
+
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				http.Error(w, "request body is too large", http.StatusRequestEntityTooLarge)
+				return
+			}
+
+			http.Error(w, "failed to parse multipart form", http.StatusBadRequest)
+			return
+		}
+		defer r.MultipartForm.RemoveAll()
+
+		files := r.MultipartForm.File[photosFormField]
+		if len(files) == 0 || len(files) > cfg.MaxPhotosPerUpload {
+			log.Error("invalid number of photos", slog.Int("count", len(files)))
+			http.Error(w, fmt.Sprintf("from 1 to %d photos are expected in field %q", cfg.MaxPhotosPerUpload, photosFormField), http.StatusBadRequest)
+			return
+		}
+
+		prepared := make([]preparedPhoto, 0, len(files))
+
+		for _, file := range files {
+			photo, err := preparePhoto(file, cfg)
+			if err != nil {
+				log.Error("invalid photo", slog.String("filename", file.Filename), sl.Err(err))
+
+				var photoErr *photoError
+				if errors.As(err, &photoErr) {
+					http.Error(w, fmt.Sprintf("%s: %s", file.Filename, photoErr.message), photoErr.status)
+					return
+				}
+
+				http.Error(w, "failed to read photo", http.StatusBadRequest)
+				return
+			}
+
+			prepared = append(prepared, photo)
+		}
+
+		response := handlers.UploadPhotosResponse{Photos: make([]handlers.Photo, 0, len(prepared))}
+
+		for _, photo := range prepared {
+			created, err := storePhoto(ctx, flatID, photo, photosWriter, blobs)
+			if err != nil {
+				log.Error("failed to store photo", sl.Err(err))
+
+				var flatNotFoundErr *repo_errors.ErrFlatNotFound
+				if errors.As(err, &flatNotFoundErr) {
+					http.Error(w, flatNotFoundErr.Error(), http.StatusNotFound)
+					return
+				}
+
+				w.Header().Set("Retry-After", "60")
+				w.WriteHeader(http.StatusInternalServerError)
+
+				errResponse := models.InternalServerErrorResponse{
+					Message:   err.Error(),
+					RequestID: middleware.GetReqID(ctx),
+					Code:      12345,
+				}
+				render.JSON(w, r, errResponse)
+				return
+			}
+
+			response.Photos = append(response.Photos, converter.ConvertPhotoEntityToPhoto(*created))
+		}
+
+		render.Status(r, http.StatusCreated)
+		render.JSON(w, r, response)
+		log.Info("photos uploaded", slog.Int64("flat_id", flatID), slog.Int("count", len(prepared)))
+	}
+}
+
+// GetPhotoHandler отдает фотографию или ее миниатюру (?size=thumbnail).
+// Клиентам доступны только одобренные фотографии одобренных квартир.
+func GetPhotoHandler(log *slog.Logger, flatGetter FlatGetter, photosGetter PhotosGetter, blobs blobstore.BlobStore) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.flat.getPhoto"
+
+		ctx := r.Context()
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(ctx)),
+		)
+
+		principal, ok := auth.PrincipalFromContext(ctx)
+		if !ok {
+			log.Error("principal not found in context")
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		flatID, ok := parseFlatID(w, r, log)
+		if !ok {
+			return
+		}
+
+		var photoIDStr = chi.URLParam(r, "photoID")
+		photoID, err := strconv.ParseInt(photoIDStr, 10, 64)
+		if err != nil || photoID < 1 {
+			log.Error("invalid photo ID", slog.String("photo_id", photoIDStr))
+			http.Error(w, "Invalid photo ID", http.StatusBadRequest)
+			return
+		}
+
+		flat, err := flatGetter.GetFlatByFlatID(ctx, flatID)
+		if err != nil {
+			writeFlatLookupError(w, r, log, err)
+			return
+		}
+
+		if principal.Role != models.Moderator && flat.Status != flatsRepo.StatusApproved {
+			log.Info("photo of not approved flat requested", slog.Int64("flat_id", flatID))
+			http.Error(w, (&repo_errors.ErrPhotoNotFound{PhotoID: photoID}).Error(), http.StatusNotFound)
+			return
+		}
+
+		photo, err := photosGetter.GetPhoto(ctx, flatID, photoID)
+		if err != nil {
+			var photoNotFoundErr *repo_errors.ErrPhotoNotFound
+			if errors.As(err, &photoNotFoundErr) {
+				http.Error(w, photoNotFoundErr.Error(), http.StatusNotFound)
+				return
+			}
+
+			log.Error("failed to get photo", sl.Err(err))
+			w.Header().Set("Retry-After", "60")
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+
+		if principal.Role != models.Moderator && photo.Status != photosRepo.PhotoStatusApproved {
+			log.Info("not approved photo requested", slog.Int64("photo_id", photoID))
+			http.Error(w, (&repo_errors.ErrPhotoNotFound{PhotoID: photoID}).Error(), http.StatusNotFound)
+			return
+		}
+
+		key, contentType := photo.BlobKey, photo.ContentType
+		if r.URL.Query().Get("size") == "thumbnail" {
+			key, contentType = photo.ThumbnailKey, thumbnailContentType
+		}
+
+		// файл по ключу не меняется, но кеш клиента перепроверяет каждый показ:
+		// фотографию могут отклонить, а квартиру снять с публикации
+		photoETag := etag.FromContent([]byte(key))
+		if etag.NoneMatch(r.Header.Get("If-None-Match"), photoETag) {
+			w.Header().Set("ETag", photoETag)
+			w.Header().Set("Cache-Control", photoCacheControl)
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		blob, err := blobs.Get(ctx, key)
+		if err != nil {
+			log.Error("failed to read photo from blob store", slog.String("key", key), sl.Err(err))
+
+			if errors.Is(err, blobstore.ErrNotFound) {
+				http.Error(w, (&repo_errors.ErrPhotoNotFound{PhotoID: photoID}).Error(), http.StatusNotFound)
+				return
+			}
+
+			w.Header().Set("Retry-After", "60")
+			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+			return
+		}
+		defer blob.Close()
+
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("Cache-Control", photoCacheControl)
+		w.Header().Set("ETag", photoETag)
+
+		if _, err := io.Copy(w, blob); err != nil {
+			log.Error("failed to write photo", sl.Err(err))
+			return
+		}
+
+		log.Info("photo served", slog.Int64("photo_id", photoID))
+	}
+}
+
+// UpdatePhotoStatusHandler одобряет или отклоняет отдельную фотографию.
+// Нужен для фотографий, загруженных к уже одобренной квартире.
+func UpdatePhotoStatusHandler(log *slog.Logger, photoStatusSetter PhotoStatusSetter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.flat.updatePhotoStatus"
+
+		ctx := r.Context()
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(ctx)),
+		)
+
+		flatID, ok := parseFlatID(w, r, log)
+		if !ok {
+			return
+		}
+
+		var photoIDStr = chi.URLParam(r, "photoID")
+		photoID, err := strconv.ParseInt(photoIDStr, 10, 64)
+		if err != nil || photoID < 1 {
+			log.Error("invalid photo ID", slog.String("photo_id", photoIDStr))
+			http.Error(w, "Invalid photo ID", http.StatusBadRequest)
+			return
+		}
+
+		var req handlers.UpdatePhotoStatusRequest
+
+		err = render.DecodeJSON(r.Body, &req)
+		if errors.Is(err, io.EOF) {
+			log.Error("request body is empty")
+			http.Error(w, "request body is empty", http.StatusBadRequest)
+			return
+		}
+
+		if err != nil {
+			log.Error("failed to decode request body", sl.Err(err))
+			http.Error(w, "failed to decode request body", http.StatusBadRequest)
+			return
+		}
+
+		if err := validator.New().Struct(req); err != nil {
+			log.Error("failed to validate request body", sl.Err(err))
+			http.Error(w, "failed to validate request body", http.StatusBadRequest)
+			return
+		}
+
+		photo, err := photoStatusSetter.SetPhotoStatus(ctx, flatID, photoID, photosRepo.PhotoStatus(req.Status))
+		if err != nil {
+			var photoNotFoundErr *repo_errors.ErrPhotoNotFound
+			if errors.As(err, &photoNotFoundErr) {
+				http.Error(w, photoNotFoundErr.Error(), http.StatusNotFound)
+				return
+			}
+
+			log.Error("failed to update photo status", sl.Err(err))
+
+			w.Header().Set("Retry-After", "60")
+			w.WriteHeader(http.StatusInternalServerError)
+
+			response := models.InternalServerErrorResponse{
+				Message:   err.Error(),
+				RequestID: middleware.GetReqID(ctx),
+				Code:      12345,
+			}
+			render.JSON(w, r, response)
+			return
+		}
+
+		render.JSON(w, r, converter.ConvertPhotoEntityToPhoto(*photo))
+		log.Info("photo status updated", slog.Int64("photo_id", photoID), slog.String("status", req.Status))
+	}
+}
+
+func preparePhoto(file *multipart.FileHeader, cfg config.PhotosConfig) (preparedPhoto, error) {
+	if file.Size > cfg.MaxPhotoSize {
+		return preparedPhoto{}, &photoError{
+			status:  http.StatusRequestEntityTooLarge,
+			message: fmt.Sprintf("photo is larger than %d bytes", cfg.MaxPhotoSize),
+		}
+	}
+
+	f, err := file.Open()
+	if err != nil {
+		return preparedPhoto{}, err
+	}
+	defer f.Close()
+
+	data, err := io.ReadAll(io.LimitReader(f, cfg.MaxPhotoSize+1))
+	if err != nil {
+		return preparedPhoto{}, err
+	}
+	if int64(len(data)) > cfg.MaxPhotoSize {
+		return preparedPhoto{}, &photoError{
+			status:  http.StatusRequestEntityTooLarge,
+			message: fmt.Sprintf("photo is larger than %d bytes", cfg.MaxPhotoSize),
+		}
+	}
+
+	contentType := http.DetectContentType(data)
+	if _, ok := photoExtensions[contentType]; !ok {
+		return preparedPhoto{}, &photoError{
+			status:  http.StatusUnsupportedMediaType,
+			message: fmt.Sprintf("unsupported photo type %q, expected jpeg, png or gif", contentType),
+		}
+	}
+
+	// размеры из заголовка проверяются до декодирования: память под картинку выделяется по ним, а не по размеру файла
+	imgConfig, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return preparedPhoto{}, &photoError{
+			status:  http.StatusUnsupportedMediaType,
+			message: "photo cannot be decoded",
+		}
+	}
+	if imgConfig.Width > cfg.MaxPhotoDimension || imgConfig.Height > cfg.MaxPhotoDimension ||
+		int64(imgConfig.Width)*int64(imgConfig.Height) > cfg.MaxPhotoPixels {
+		return preparedPhoto{}, &photoError{
+			status: http.StatusRequestEntityTooLarge,
+			message: fmt.Sprintf("photo is %dx%d pixels, at most %d pixels per side and %d pixels in total are allowed",
+				imgConfig.Width, imgConfig.Height, cfg.MaxPhotoDimension, cfg.MaxPhotoPixels),
+		}
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return preparedPhoto{}, &photoError{
+			status:  http.StatusUnsupportedMediaType,
+			message: "photo cannot be decoded",
+		}
+	}
+
+	var thumb bytes.Buffer
+	if err := jpeg.Encode(&thumb, thumbnail.Resize(img, cfg.ThumbnailSize), &jpeg.Options{Quality: 80}); err != nil {
+		return preparedPhoto{}, err
+	}
+
+	return preparedPhoto{
+		data:        data,
+		thumbnail:   thumb.Bytes(),
+		contentType: contentType,
+		width:       img.Bounds().Dx(),
+		height:      img.Bounds().Dy(),
+	}, nil
+}
+
+// storePhoto кладет файлы в хранилище и записывает фотографию в базу.
+// Если запись в базу не удалась, файлы удаляются, чтобы не оставлять сирот.
+func storePhoto(
+	ctx context.Context,
+	flatID int64,
+	photo preparedPhoto,
+	photosWriter PhotosWriter,
+	blobs blobstore.BlobStore,
+) (*photosRepo.PhotoEntity, error) {
+	base := fmt.Sprintf("flats/%d/%s", flatID, uuid.NewString())
+	blobKey := base + photoExtensions[photo.contentType]
+	thumbnailKey := base + "-thumbnail.jpg"
+
+	if err := blobs.Put(ctx, blobKey, bytes.NewReader(photo.data)); err != nil {
+		return nil, err
+	}
+
+	if err := blobs.Put(ctx, thumbnailKey, bytes.NewReader(photo.thumbnail)); err != nil {
+		_ = blobs.Delete(ctx, blobKey)
+		return nil, err
+	}
+
+	created, err := photosWriter.CreatePhoto(ctx, photosRepo.CreatePhotoEntity{
+		FlatID:       flatID,
+		BlobKey:      blobKey,
+		ThumbnailKey: thumbnailKey,
+		ContentType:  photo.contentType,
+		Size:         int64(len(photo.data)),
+		Width:        photo.width,
+		Height:       photo.height,
+	})
+	if err != nil {
+		_ = blobs.Delete(ctx, blobKey)
+		_ = blobs.Delete(ctx, thumbnailKey)
+		return nil, err
+	}
+
+	return created, nil
+}
+
+func parseFlatID(w http.ResponseWriter, r *http.Request, log *slog.Logger) (int64, bool) {
+	var flatIDStr = chi.URLParam(r, "id")
+	flatID, err := strconv.ParseInt(flatIDStr, 10, 64)
+	if err != nil || flatID < 1 {
+		log.Error("invalid flat ID", slog.String("flat_id", flatIDStr))
+		http.Error(w, "Invalid flat ID", http.StatusBadRequest)
+		return 0, false
+	}
+
+	return flatID, true
+}
+
+func writeFlatLookupError(w http.ResponseWriter, r *http.Request, log *slog.Logger, err error) {
+	var flatNotFoundErr *repo_errors.ErrFlatNotFound
+	if errors.As(err, &flatNotFoundErr) {
+		log.Info("flat not found", sl.Err(err))
+		http.Error(w, flatNotFoundErr.Error(), http.StatusNotFound)
+		return
+	}
+
+	log.Error("failed to get flat", sl.Err(err))
+
+	w.Header().Set("Retry-After", "60")
+	w.WriteHeader(http.StatusInternalServerError)
+
+	response := models.InternalServerErrorResponse{
+		Message:   err.Error(),
+		RequestID: middleware.GetReqID(r.Context()),
+		Code:      12345,
+	}
+	render.JSON(w, r, response)
+}
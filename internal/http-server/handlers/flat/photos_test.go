@@ -0,0 +1,165 @@
+package flat
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"image"
+	"image/png"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"realty-avito/internal/auth"
+	"realty-avito/internal/blobstore"
+	"realty-avito/internal/config"
+	"realty-avito/internal/http-server/handlers"
+	"realty-avito/internal/lib/logger"
+	"realty-avito/internal/models"
+	"realty-avito/internal/repositories/flatsRepo"
+	"realty-avito/internal/repositories/flatsRepo/mocks"
+	"realty-avito/internal/repositories/photosRepo"
+	photoMocks "realty-avito/internal/repositories/photosRepo/mocks"
+)
+
+func TestUploadPhotosHandler(t *testing.T) {
+	var pngPhoto bytes.Buffer
+	require.NoError(t, png.Encode(&pngPhoto, image.NewRGBA(image.Rect(0, 0, 640, 480))))
+
+	var hugePhoto bytes.Buffer
+	require.NoError(t, png.Encode(&hugePhoto, image.NewGray(image.Rect(0, 0, 1000, 1000))))
+
+	cfg := config.PhotosConfig{
+		MaxPhotoSize:       1 << 20,
+		MaxPhotosPerUpload: 2,
+		ThumbnailSize:      100,
+		MaxPhotoDimension:  2000,
+		MaxPhotoPixels:     500_000,
+	}
+
+	tests := []struct {
+		name               string
+		content            []byte
+		expectedStatusCode int
+	}{
+		{
+			name:               "png photo",
+			content:            pngPhoto.Bytes(),
+			expectedStatusCode: http.StatusCreated,
+		},
+		{
+			name:               "not an image",
+			content:            []byte("definitely not a photo"),
+			expectedStatusCode: http.StatusUnsupportedMediaType,
+		},
+		{
+			name:               "too many pixels",
+			content:            hugePhoto.Bytes(),
+			expectedStatusCode: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:               "too large",
+			content:            bytes.Repeat([]byte{0}, int(cfg.MaxPhotoSize)+1),
+			expectedStatusCode: http.StatusRequestEntityTooLarge,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, err := blobstore.NewLocalStore(t.TempDir())
+			require.NoError(t, err)
+
+			mockFlatsRepo := new(mocks.FlatsRepository)
+			mockFlatsRepo.On("GetFlatByFlatID", mock.Anything, int64(3)).Return(&flatsRepo.FlatEntity{ID: 3}, nil)
+
+			mockPhotosRepo := new(photoMocks.PhotosRepository)
+			mockPhotosRepo.On("CreatePhoto", mock.Anything, mock.MatchedBy(func(e photosRepo.CreatePhotoEntity) bool {
+				return e.FlatID == 3 && e.ContentType == "image/png" && e.Width == 640 && e.Height == 480
+			})).Return(&photosRepo.PhotoEntity{ID: 1, FlatID: 3, ContentType: "image/png"}, nil).Maybe()
+
+			var body bytes.Buffer
+			writer := multipart.NewWriter(&body)
+			part, err := writer.CreateFormFile("photos", "photo.png")
+			require.NoError(t, err)
+			_, err = part.Write(tt.content)
+			require.NoError(t, err)
+			require.NoError(t, writer.Close())
+
+			req := httptest.NewRequest(http.MethodPost, "/flat/3/photos", &body)
+			req.Header.Set("Content-Type", writer.FormDataContentType())
+
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("id", "3")
+			req = req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+
+			rr := httptest.NewRecorder()
+			UploadPhotosHandler(logger.SetupLogger("local"), mockFlatsRepo, mockPhotosRepo, store, cfg).ServeHTTP(rr, req)
+
+			require.Equal(t, tt.expectedStatusCode, rr.Code)
+
+			if tt.expectedStatusCode == http.StatusCreated {
+				var response handlers.UploadPhotosResponse
+				require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+				require.Len(t, response.Photos, 1)
+				require.Equal(t, "/flat/3/photos/1", response.Photos[0].URL)
+				mockPhotosRepo.AssertExpectations(t)
+			}
+		})
+	}
+}
+
+func TestGetPhotoHandlerRevalidates(t *testing.T) {
+	store, err := blobstore.NewLocalStore(t.TempDir())
+	require.NoError(t, err)
+	require.NoError(t, store.Put(context.Background(), "flats/3/photo.png", bytes.NewReader([]byte("png"))))
+
+	mockFlatsRepo := new(mocks.FlatsRepository)
+	mockFlatsRepo.On("GetFlatByFlatID", mock.Anything, int64(3)).Return(&flatsRepo.FlatEntity{ID: 3, Status: flatsRepo.StatusApproved}, nil)
+
+	photo := photosRepo.PhotoEntity{ID: 1, FlatID: 3, BlobKey: "flats/3/photo.png", ContentType: "image/png", Status: photosRepo.PhotoStatusApproved}
+	declined := photo
+	declined.Status = photosRepo.PhotoStatusDeclined
+
+	mockPhotosRepo := new(photoMocks.PhotosRepository)
+	mockPhotosRepo.On("GetPhoto", mock.Anything, int64(3), int64(1)).Return(&photo, nil).Twice()
+	mockPhotosRepo.On("GetPhoto", mock.Anything, int64(3), int64(1)).Return(&declined, nil).Once()
+
+	handler := GetPhotoHandler(logger.SetupLogger("local"), mockFlatsRepo, mockPhotosRepo, store)
+
+	send := func(ifNoneMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/flat/3/photos/1", nil)
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("id", "3")
+		rctx.URLParams.Add("photoID", "1")
+		ctx := context.WithValue(req.Context(), chi.RouteCtxKey, rctx)
+		req = req.WithContext(auth.WithPrincipal(ctx, auth.Principal{Role: models.Client}))
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	first := send("")
+	require.Equal(t, http.StatusOK, first.Code)
+	require.Equal(t, "png", first.Body.String())
+	require.Equal(t, "private, no-cache", first.Header().Get("Cache-Control"))
+	photoETag := first.Header().Get("ETag")
+	require.NotEmpty(t, photoETag)
+
+	notModified := send(photoETag)
+	require.Equal(t, http.StatusNotModified, notModified.Code)
+	require.Equal(t, photoETag, notModified.Header().Get("ETag"))
+
+	// после отклонения закешированная копия больше не подтверждается
+	require.Equal(t, http.StatusNotFound, send(photoETag).Code)
+	mockPhotosRepo.AssertExpectations(t)
+}
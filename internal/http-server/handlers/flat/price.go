@@ -0,0 +1,185 @@
+package flat
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"golang.org/x/exp/slog"
+
+	"realty-avito/internal/auth"
+	"realty-avito/internal/converter"
+	repo_errors "realty-avito/internal/errors"
+	"realty-avito/internal/http-server/handlers"
+	"realty-avito/internal/lib/etag"
+	"realty-avito/internal/lib/logger/sl"
+	"realty-avito/internal/models"
+	"realty-avito/internal/moderation"
+	"realty-avito/internal/repositories/flatsRepo"
+)
+
+type FlatPriceChanger interface {
+	ChangeFlatPrice(ctx context.Context, changeFlatPriceEntity flatsRepo.ChangeFlatPriceEntity) (*flatsRepo.FlatEntity, error)
+	GetFlatByFlatID(ctx context.Context, flatID int64) (*flatsRepo.FlatEntity, error)
+}
+
+type FlatHistoryGetter interface {
+	GetFlatByFlatID(ctx context.Context, flatID int64) (*flatsRepo.FlatEntity, error)
+	GetFlatHistory(ctx context.Context, flatID int64) ([]flatsRepo.FlatHistoryEntity, error)
+}
+
+// ChangePriceHandler меняет цену квартиры и отправляет ее на повторную модерацию.
+// Доступен только модераторам: смена цены снимает квартиру с публикации. Поддерживает If-Match.
+func ChangePriceHandler(log *slog.Logger, flatPriceChanger FlatPriceChanger) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.flat.changePrice"
+
+		ctx := r.Context()
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(ctx)),
+		)
+
+		principal, ok := auth.PrincipalFromContext(ctx)
+		if !ok {
+			log.Error("principal not found in context")
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		flatID, ok := parseFlatID(w, r, log)
+		if !ok {
+			return
+		}
+
+		expectedVersion, err := etag.ParseIfMatch(r.Header.Get("If-Match"))
+		if err != nil {
+			log.Error(err.Error())
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		var req handlers.ChangePriceRequest
+
+		err = render.DecodeJSON(r.Body, &req)
+		if errors.Is(err, io.EOF) {
+			log.Error("request body is empty")
+			http.Error(w, "request body is empty", http.StatusBadRequest)
+			return
+		}
+
+		if err != nil {
+			log.Error("failed to decode request body", sl.Err(err))
+			http.Error(w, "failed to decode request body", http.StatusBadRequest)
+			return
+		}
+
+		if err := validator.New().Struct(req); err != nil {
+			log.Error("failed to validate request body", sl.Err(err))
+			http.Error(w, "failed to validate request body", http.StatusBadRequest)
+			return
+		}
+
+		flat, err := flatPriceChanger.ChangeFlatPrice(ctx, flatsRepo.ChangeFlatPriceEntity{
+			ID:              flatID,
+			Price:           req.Price,
+			FromStatuses:    moderation.PriceChangeSources(),
+			ChangedBy:       principal.Subject,
+			ExpectedVersion: expectedVersion,
+		})
+		if errors.Is(err, repo_errors.ErrFlatStateConflict) {
+			// условие не выполнилось: квартиры нет, версия устарела или квартира сейчас на модерации
+			var current *flatsRepo.FlatEntity
+			current, err = flatPriceChanger.GetFlatByFlatID(ctx, flatID)
+			if err == nil && expectedVersion != nil && current.Version != *expectedVersion {
+				log.Info("price change rejected: version mismatch", slog.Int64("flat_id", flatID))
+				http.Error(w, fmt.Sprintf("flat was modified, current version is %d", current.Version), http.StatusPreconditionFailed)
+				return
+			}
+			if err == nil {
+				log.Info("price change rejected: flat is on moderation", slog.Int64("flat_id", flatID))
+				http.Error(w, "flat is on moderation, its price cannot be changed now", http.StatusConflict)
+				return
+			}
+		}
+		if err != nil {
+			writeFlatLookupError(w, r, log, err)
+			return
+		}
+
+		w.Header().Set("ETag", etag.FromVersion(flat.Version))
+		render.JSON(w, r, converter.ConvertFlatEntityToUpdateResponse(flat))
+		log.Info("flat price changed", slog.Int64("flat_id", flatID), slog.Int64("price", req.Price))
+	}
+}
+
+// GetHistoryHandler отдает историю цены и статусов квартиры. Клиенты видят историю только одобренных
+// квартир и без идентификаторов тех, кто вносил изменения.
+func GetHistoryHandler(log *slog.Logger, historyGetter FlatHistoryGetter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.flat.history"
+
+		ctx := r.Context()
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(ctx)),
+		)
+
+		principal, ok := auth.PrincipalFromContext(ctx)
+		if !ok {
+			log.Error("principal not found in context")
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		flatID, ok := parseFlatID(w, r, log)
+		if !ok {
+			return
+		}
+
+		flat, err := historyGetter.GetFlatByFlatID(ctx, flatID)
+		if err != nil {
+			writeFlatLookupError(w, r, log, err)
+			return
+		}
+
+		if principal.Role != models.Moderator && flat.Status != flatsRepo.StatusApproved {
+			log.Info("history of not approved flat requested", slog.Int64("flat_id", flatID))
+			http.Error(w, (&repo_errors.ErrFlatNotFound{FlatID: flatID}).Error(), http.StatusNotFound)
+			return
+		}
+
+		history, err := historyGetter.GetFlatHistory(ctx, flatID)
+		if err != nil {
+			log.Error("failed to get flat history", sl.Err(err))
+
+			w.Header().Set("Retry-After", "60")
+			w.WriteHeader(http.StatusInternalServerError)
+
+			response := models.InternalServerErrorResponse{
+				Message:   err.Error(),
+				RequestID: middleware.GetReqID(ctx),
+				Code:      12345,
+			}
+			render.JSON(w, r, response)
+			return
+		}
+
+		records := converter.ConvertFlatHistoryEntitiesToRecords(history)
+		if principal.Role != models.Moderator {
+			for i := range records {
+				records[i].ChangedBy = nil
+			}
+		}
+
+		render.JSON(w, r, handlers.FlatHistoryResponse{FlatID: flatID, History: records})
+		log.Info("request handled successfully", slog.Int64("flat_id", flatID), slog.Int("count", len(records)))
+	}
+}
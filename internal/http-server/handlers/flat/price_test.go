@@ -0,0 +1,113 @@
+package flat
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"realty-avito/internal/auth"
+	repo_errors "realty-avito/internal/errors"
+	"realty-avito/internal/lib/logger"
+	"realty-avito/internal/models"
+	"realty-avito/internal/repositories/flatsRepo"
+	"realty-avito/internal/repositories/flatsRepo/mocks"
+)
+
+func TestChangePriceHandler(t *testing.T) {
+	tests := []struct {
+		name               string
+		body               string
+		ifMatch            string
+		prepareMock        func(m *mocks.FlatsRepository)
+		expectedStatusCode int
+	}{
+		{
+			name: "price changed and flat sent to moderation",
+			body: `{"price": 7000000}`,
+			prepareMock: func(m *mocks.FlatsRepository) {
+				m.On("ChangeFlatPrice", mock.Anything, mock.MatchedBy(func(e flatsRepo.ChangeFlatPriceEntity) bool {
+					return e.ID == 2 && e.Price == 7000000 && e.ChangedBy == "user-uuid" &&
+						len(e.FromStatuses) == 3
+				})).Return(&flatsRepo.FlatEntity{ID: 2, Price: 7000000, Status: flatsRepo.StatusCreated}, nil).Once()
+			},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name: "flat is on moderation",
+			body: `{"price": 7000000}`,
+			prepareMock: func(m *mocks.FlatsRepository) {
+				m.On("ChangeFlatPrice", mock.Anything, mock.Anything).Return(nil, repo_errors.ErrFlatStateConflict).Once()
+				m.On("GetFlatByFlatID", mock.Anything, int64(2)).
+					Return(&flatsRepo.FlatEntity{ID: 2, Status: flatsRepo.StatusOnModeration}, nil).Once()
+			},
+			expectedStatusCode: http.StatusConflict,
+		},
+		{
+			name: "flat not found",
+			body: `{"price": 7000000}`,
+			prepareMock: func(m *mocks.FlatsRepository) {
+				m.On("ChangeFlatPrice", mock.Anything, mock.Anything).Return(nil, repo_errors.ErrFlatStateConflict).Once()
+				m.On("GetFlatByFlatID", mock.Anything, int64(2)).Return(nil, &repo_errors.ErrFlatNotFound{FlatID: 2}).Once()
+			},
+			expectedStatusCode: http.StatusNotFound,
+		},
+		{
+			name:    "version matches",
+			body:    `{"price": 7000000}`,
+			ifMatch: `"4"`,
+			prepareMock: func(m *mocks.FlatsRepository) {
+				m.On("ChangeFlatPrice", mock.Anything, mock.MatchedBy(func(e flatsRepo.ChangeFlatPriceEntity) bool {
+					return e.ExpectedVersion != nil && *e.ExpectedVersion == 4
+				})).Return(&flatsRepo.FlatEntity{ID: 2, Price: 7000000, Status: flatsRepo.StatusCreated, Version: 5}, nil).Once()
+			},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:    "stale version",
+			body:    `{"price": 7000000}`,
+			ifMatch: `"4"`,
+			prepareMock: func(m *mocks.FlatsRepository) {
+				m.On("ChangeFlatPrice", mock.Anything, mock.Anything).Return(nil, repo_errors.ErrFlatStateConflict).Once()
+				m.On("GetFlatByFlatID", mock.Anything, int64(2)).
+					Return(&flatsRepo.FlatEntity{ID: 2, Status: flatsRepo.StatusApproved, Version: 6}, nil).Once()
+			},
+			expectedStatusCode: http.StatusPreconditionFailed,
+		},
+		{
+			name:               "invalid price",
+			body:               `{"price": -1}`,
+			prepareMock:        func(m *mocks.FlatsRepository) {},
+			expectedStatusCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockFlatsRepo := new(mocks.FlatsRepository)
+			tt.prepareMock(mockFlatsRepo)
+
+			req := httptest.NewRequest(http.MethodPatch, "/flat/2/price", strings.NewReader(tt.body))
+			if tt.ifMatch != "" {
+				req.Header.Set("If-Match", tt.ifMatch)
+			}
+
+			rctx := chi.NewRouteContext()
+			rctx.URLParams.Add("id", "2")
+			ctx := context.WithValue(req.Context(), chi.RouteCtxKey, rctx)
+			ctx = auth.WithPrincipal(ctx, auth.Principal{Subject: "user-uuid", Role: models.Moderator})
+			req = req.WithContext(ctx)
+
+			rr := httptest.NewRecorder()
+			ChangePriceHandler(logger.SetupLogger("local"), mockFlatsRepo).ServeHTTP(rr, req)
+
+			require.Equal(t, tt.expectedStatusCode, rr.Code)
+			mockFlatsRepo.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,251 @@
+package flat
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+
+	"realty-avito/internal/auth"
+	"realty-avito/internal/converter"
+	"realty-avito/internal/http-server/handlers"
+	"realty-avito/internal/lib/cursor"
+	"realty-avito/internal/lib/logger/sl"
+	"realty-avito/internal/models"
+	"realty-avito/internal/repositories/flatsRepo"
+)
+
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 100
+)
+
+var errStatusFilterForbidden = errors.New("status filter is available only for moderators")
+
+type FlatsSearcher interface {
+	SearchFlats(ctx context.Context, filter flatsRepo.SearchFlatsFilter) ([]flatsRepo.FlatEntity, error)
+}
+
+type SearchResponse struct {
+	Flats      []handlers.Flat `json:"flats"`
+	NextCursor string          `json:"next_cursor,omitempty"`
+}
+
+// SearchFlatsHandler ищет квартиры по фильтрам из query-параметров.
+// Клиенты видят только одобренные квартиры, фильтр по статусу доступен модераторам.
+func SearchFlatsHandler(log *slog.Logger, flatsSearcher FlatsSearcher) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.flat.search"
+
+		ctx := r.Context()
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(ctx)),
+		)
+
+		principal, ok := auth.PrincipalFromContext(ctx)
+		if !ok {
+			log.Error("principal not found in context")
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		filter, err := parseSearchFilter(r.URL.Query(), principal)
+		if err != nil {
+			log.Error("invalid search parameters", sl.Err(err))
+
+			if errors.Is(err, errStatusFilterForbidden) {
+				http.Error(w, err.Error(), http.StatusForbidden)
+				return
+			}
+
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		flatEntities, err := flatsSearcher.SearchFlats(ctx, filter)
+		if err != nil {
+			log.Error("failed to search flats", sl.Err(err))
+
+			w.Header().Set("Retry-After", "60")
+			w.WriteHeader(http.StatusInternalServerError)
+
+			response := models.InternalServerErrorResponse{
+				Message:   err.Error(),
+				RequestID: middleware.GetReqID(ctx),
+				Code:      12345,
+			}
+			render.JSON(w, r, response)
+			return
+		}
+
+		flats := converter.ConvertFlatEntitiesToFlats(flatEntities)
+		if len(flats) == 0 {
+			flats = []handlers.Flat{}
+		}
+
+		response := SearchResponse{
+			Flats: flats,
+		}
+
+		// полная страница - возможно, есть еще квартиры
+		if uint64(len(flatEntities)) == filter.Limit {
+			last := flatEntities[len(flatEntities)-1]
+
+			c := cursor.Cursor{Value: last.CreatedAt.Format(time.RFC3339Nano), ID: last.ID}
+			if filter.SortBy == flatsRepo.SortByPrice {
+				c.Value = strconv.FormatInt(last.Price, 10)
+			}
+
+			response.NextCursor = cursor.Encode(c)
+		}
+
+		render.JSON(w, r, response)
+		log.Info("request handled successfully", slog.Int("count", len(flatEntities)))
+	}
+}
+
+func parseSearchFilter(query url.Values, principal auth.Principal) (flatsRepo.SearchFlatsFilter, error) {
+	filter := flatsRepo.SearchFlatsFilter{
+		SortBy: flatsRepo.SortByCreatedAt,
+		Limit:  defaultSearchLimit,
+	}
+
+	var err error
+
+	if filter.MinPrice, err = parseOptionalInt64(query, "min_price"); err != nil {
+		return filter, err
+	}
+	if filter.MaxPrice, err = parseOptionalInt64(query, "max_price"); err != nil {
+		return filter, err
+	}
+	if filter.MinPrice != nil && filter.MaxPrice != nil && *filter.MinPrice > *filter.MaxPrice {
+		return filter, errors.New("min_price must not be greater than max_price")
+	}
+
+	for _, roomsStr := range query["rooms"] {
+		rooms, err := strconv.ParseInt(roomsStr, 10, 64)
+		if err != nil || rooms < 1 {
+			return filter, errors.New("invalid rooms")
+		}
+		filter.Rooms = append(filter.Rooms, rooms)
+	}
+
+	if filter.HouseID, err = parseOptionalInt64(query, "house_id"); err != nil {
+		return filter, err
+	}
+
+	if developer := query.Get("developer"); developer != "" {
+		filter.Developer = &developer
+	}
+
+	minYear, err := parseOptionalInt64(query, "min_year")
+	if err != nil {
+		return filter, err
+	}
+	maxYear, err := parseOptionalInt64(query, "max_year")
+	if err != nil {
+		return filter, err
+	}
+	if minYear != nil {
+		year := int(*minYear)
+		filter.MinYear = &year
+	}
+	if maxYear != nil {
+		year := int(*maxYear)
+		filter.MaxYear = &year
+	}
+	if minYear != nil && maxYear != nil && *minYear > *maxYear {
+		return filter, errors.New("min_year must not be greater than max_year")
+	}
+
+	statuses := query["status"]
+	if principal.Role != models.Moderator {
+		if len(statuses) > 0 {
+			return filter, errStatusFilterForbidden
+		}
+		statuses = []string{string(flatsRepo.StatusApproved)}
+	}
+	for _, status := range statuses {
+		switch flatsRepo.FlatModerationStatus(status) {
+		case flatsRepo.StatusCreated, flatsRepo.StatusApproved, flatsRepo.StatusDeclined, flatsRepo.StatusOnModeration:
+			filter.Statuses = append(filter.Statuses, flatsRepo.FlatModerationStatus(status))
+		default:
+			return filter, errors.New("invalid status")
+		}
+	}
+
+	switch sortBy := query.Get("sort"); sortBy {
+	case "", string(flatsRepo.SortByCreatedAt):
+	case string(flatsRepo.SortByPrice):
+		filter.SortBy = flatsRepo.SortByPrice
+	default:
+		return filter, errors.New("sort must be price or created_at")
+	}
+
+	switch order := query.Get("order"); order {
+	case "", "asc":
+	case "desc":
+		filter.Desc = true
+	default:
+		return filter, errors.New("order must be asc or desc")
+	}
+
+	if limitStr := query.Get("limit"); limitStr != "" {
+		limit, err := strconv.ParseUint(limitStr, 10, 64)
+		if err != nil || limit < 1 || limit > maxSearchLimit {
+			return filter, errors.New("limit must be between 1 and 100")
+		}
+		filter.Limit = limit
+	}
+
+	if filter.After, err = decodeSearchPosition(query.Get("cursor"), filter.SortBy); err != nil {
+		return filter, err
+	}
+
+	return filter, nil
+}
+
+func parseOptionalInt64(query url.Values, name string) (*int64, error) {
+	raw := query.Get(name)
+	if raw == "" {
+		return nil, nil
+	}
+
+	value, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || value < 0 {
+		return nil, errors.New("invalid " + name)
+	}
+
+	return &value, nil
+}
+
+// decodeSearchPosition разбирает курсор; курсор, выданный для другой сортировки, считается невалидным
+func decodeSearchPosition(raw string, sortBy flatsRepo.FlatSortField) (*flatsRepo.SearchPosition, error) {
+	c, err := cursor.Decode(raw)
+	if err != nil || c == nil {
+		return nil, err
+	}
+
+	position := &flatsRepo.SearchPosition{ID: c.ID}
+
+	if sortBy == flatsRepo.SortByPrice {
+		if position.Price, err = strconv.ParseInt(c.Value, 10, 64); err != nil {
+			return nil, cursor.ErrInvalidCursor
+		}
+		return position, nil
+	}
+
+	if position.CreatedAt, err = time.Parse(time.RFC3339Nano, c.Value); err != nil {
+		return nil, cursor.ErrInvalidCursor
+	}
+
+	return position, nil
+}
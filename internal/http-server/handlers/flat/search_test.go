@@ -0,0 +1,101 @@
+package flat
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"realty-avito/internal/auth"
+	"realty-avito/internal/lib/cursor"
+	"realty-avito/internal/lib/logger"
+	"realty-avito/internal/models"
+	"realty-avito/internal/repositories/flatsRepo"
+	"realty-avito/internal/repositories/flatsRepo/mocks"
+)
+
+func TestSearchFlatsHandler(t *testing.T) {
+	log := logger.SetupLogger("local")
+
+	tests := []struct {
+		name               string
+		role               models.UserType
+		query              string
+		prepareMock        func(m *mocks.FlatsRepository)
+		expectedStatusCode int
+		expectedCursor     string
+	}{
+		{
+			name:  "client sees only approved flats",
+			role:  models.Client,
+			query: "?min_price=100&max_price=500&rooms=2&sort=price&limit=1",
+			prepareMock: func(m *mocks.FlatsRepository) {
+				m.On("SearchFlats", mock.Anything, mock.MatchedBy(func(f flatsRepo.SearchFlatsFilter) bool {
+					return len(f.Statuses) == 1 && f.Statuses[0] == flatsRepo.StatusApproved &&
+						*f.MinPrice == 100 && *f.MaxPrice == 500 && f.SortBy == flatsRepo.SortByPrice
+				})).Return([]flatsRepo.FlatEntity{{ID: 7, HouseID: 1, Price: 300, Rooms: 2, Status: "approved"}}, nil).Once()
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedCursor:     cursor.Encode(cursor.Cursor{Value: "300", ID: 7}),
+		},
+		{
+			name:               "client cannot filter by status",
+			role:               models.Client,
+			query:              "?status=created",
+			prepareMock:        func(m *mocks.FlatsRepository) {},
+			expectedStatusCode: http.StatusForbidden,
+		},
+		{
+			name:  "moderator filters by status",
+			role:  models.Moderator,
+			query: "?status=created&status=on%20moderation",
+			prepareMock: func(m *mocks.FlatsRepository) {
+				m.On("SearchFlats", mock.Anything, mock.MatchedBy(func(f flatsRepo.SearchFlatsFilter) bool {
+					return len(f.Statuses) == 2
+				})).Return(nil, nil).Once()
+			},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:               "invalid price range",
+			role:               models.Client,
+			query:              "?min_price=500&max_price=100",
+			prepareMock:        func(m *mocks.FlatsRepository) {},
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "cursor from another sort",
+			role:               models.Client,
+			query:              "?sort=price&cursor=" + cursor.Encode(cursor.Cursor{Value: "2024-08-20T10:00:00Z", ID: 1}),
+			prepareMock:        func(m *mocks.FlatsRepository) {},
+			expectedStatusCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockFlatsRepo := new(mocks.FlatsRepository)
+			tt.prepareMock(mockFlatsRepo)
+
+			req := httptest.NewRequest(http.MethodGet, "/flats"+tt.query, nil)
+			req = req.WithContext(auth.WithPrincipal(req.Context(), auth.Principal{Subject: "user", Role: tt.role}))
+
+			rr := httptest.NewRecorder()
+			SearchFlatsHandler(log, mockFlatsRepo).ServeHTTP(rr, req)
+
+			require.Equal(t, tt.expectedStatusCode, rr.Code)
+
+			if tt.expectedStatusCode == http.StatusOK {
+				var response SearchResponse
+				require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+				require.NotNil(t, response.Flats)
+				require.Equal(t, tt.expectedCursor, response.NextCursor)
+			}
+
+			mockFlatsRepo.AssertExpectations(t)
+		})
+	}
+}
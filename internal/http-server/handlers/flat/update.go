@@ -1,6 +1,9 @@
 package flat
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"net/http"
 	"time"
 
@@ -9,13 +12,24 @@ import (
 	"github.com/go-playground/validator/v10"
 	"golang.org/x/exp/slog"
 
+	"realty-avito/internal/auth"
 	"realty-avito/internal/converter"
+	repo_errors "realty-avito/internal/errors"
 	"realty-avito/internal/http-server/handlers"
+	"realty-avito/internal/lib/etag"
+	"realty-avito/internal/metrics"
 	"realty-avito/internal/models"
+	"realty-avito/internal/moderation"
 	"realty-avito/internal/repositories/flatsRepo"
 )
 
-func UpdateFlatHandler(log *slog.Logger, flatsRepository flatsRepo.FlatsRepository) http.HandlerFunc {
+type FlatApprovedNotifier interface {
+	NotifyFlatApproved(flat flatsRepo.FlatEntity)
+}
+
+// UpdateFlatHandler меняет статус квартиры. С заголовком If-Match квартира обновляется,
+// только если не менялась с момента чтения, иначе возвращается 412.
+func UpdateFlatHandler(log *slog.Logger, flatsRepository flatsRepo.FlatsRepository, notifier FlatApprovedNotifier) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.flat.update"
 
@@ -33,32 +47,20 @@ func UpdateFlatHandler(log *slog.Logger, flatsRepository flatsRepo.FlatsReposito
 			return
 		}
 
-		moderatorIDFromRequest, ok := r.Context().Value("moderator_id").(string)
-		if !ok {
-			log.Error("Error: no moderator_id in context", slog.String("op", op))
-			w.WriteHeader(http.StatusInternalServerError)
-
-			response := models.InternalServerErrorResponse{
-				Message:   "Error: no moderator_id in context",
-				RequestID: middleware.GetReqID(r.Context()),
-				Code:      12345,
-			}
-
-			render.JSON(w, r, response)
+		expectedVersion, err := etag.ParseIfMatch(r.Header.Get("If-Match"))
+		if err != nil {
+			log.Error(err.Error())
+			http.Error(w, err.Error(), http.StatusBadRequest)
 			return
 		}
 
-		// TODO: вынести в func checkModerator()
-		flatToUpdate, err := flatsRepository.GetFlatByFlatID(r.Context(), req.ID)
-		if err != nil {
-			// TODO: создать 1 метод, который это все заполняет
-			log.Error("failed to update flat", slog.String("op", op), slog.StringValue(err.Error()))
-
-			w.Header().Set("Retry-After", "60")
+		principal, ok := auth.PrincipalFromContext(r.Context())
+		if !ok {
+			log.Error("Error: no principal in context", slog.String("op", op))
 			w.WriteHeader(http.StatusInternalServerError)
 
 			response := models.InternalServerErrorResponse{
-				Message:   err.Error(),
+				Message:   "Error: no principal in context",
 				RequestID: middleware.GetReqID(r.Context()),
 				Code:      12345,
 			}
@@ -67,21 +69,41 @@ func UpdateFlatHandler(log *slog.Logger, flatsRepository flatsRepo.FlatsReposito
 			return
 		}
 
-		if flatToUpdate.ModeratorID != nil && *flatToUpdate.ModeratorID != moderatorIDFromRequest {
-			log.Error("failed to update flat, flat is under moderation by another moderator",
-				slog.String("op", op))
+		targetStatus := flatsRepo.FlatModerationStatus(req.Status)
+
+		fromStatuses := moderation.Sources(targetStatus)
+		if len(fromStatuses) == 0 {
+			log.Error("illegal flat status transition",
+				slog.String("op", op),
+				slog.String("status", string(targetStatus)))
 			http.Error(w,
-				"failed to update flat, flat is under moderation by another moderator",
-				http.StatusForbidden)
+				fmt.Sprintf("flat cannot be moved to status %q", targetStatus),
+				http.StatusConflict)
 			return
 		}
 
 		entityToUpdate := converter.ConvertUpdateFlatRequestToEntity(req)
-		entityToUpdate.ModeratorID = &moderatorIDFromRequest
+		entityToUpdate.FromStatuses = fromStatuses
+		entityToUpdate.ModeratorID = &principal.Subject
 		now := time.Now()
 		entityToUpdate.UpdatedAt = &now
+		entityToUpdate.ExpectedVersion = expectedVersion
 
 		updatedFlat, err := flatsRepository.UpdateFlat(r.Context(), entityToUpdate)
+		if errors.Is(err, repo_errors.ErrFlatStateConflict) {
+			status, message, errConflict := explainUpdateConflict(r.Context(), flatsRepository, req.ID, targetStatus, expectedVersion)
+			if errConflict == nil {
+				log.Error("failed to update flat",
+					slog.String("op", op),
+					slog.Int64("flat_id", req.ID),
+					slog.String("reason", message))
+				http.Error(w, message, status)
+				return
+			}
+
+			err = errConflict
+		}
+
 		if err != nil {
 			log.Error("failed to update flat",
 				slog.String("op", op),
@@ -100,9 +122,57 @@ func UpdateFlatHandler(log *slog.Logger, flatsRepository flatsRepo.FlatsReposito
 			return
 		}
 
+		metrics.ModerationDecisions.WithLabelValues(string(updatedFlat.Status)).Inc()
+
+		if updatedFlat.Status == flatsRepo.StatusApproved {
+			notifier.NotifyFlatApproved(*updatedFlat)
+		}
+
 		response := converter.ConvertFlatEntityToUpdateResponse(updatedFlat)
 
+		w.Header().Set("ETag", etag.FromVersion(updatedFlat.Version))
 		render.JSON(w, r, response)
 		log.Info("flat updated successfully", slog.Int64("flat_id", response.ID))
 	}
 }
+
+// explainUpdateConflict перечитывает квартиру после неудачного условного обновления
+// и подбирает для клиента код ответа и причину отказа
+func explainUpdateConflict(
+	ctx context.Context,
+	flatsRepository flatsRepo.FlatsRepository,
+	flatID int64,
+	targetStatus flatsRepo.FlatModerationStatus,
+	expectedVersion *int64,
+) (int, string, error) {
+	flat, err := flatsRepository.GetFlatByFlatID(ctx, flatID)
+	if err != nil {
+		var flatNotFoundErr *repo_errors.ErrFlatNotFound
+		if errors.As(err, &flatNotFoundErr) {
+			return http.StatusNotFound, flatNotFoundErr.Error(), nil
+		}
+
+		return 0, "", err
+	}
+
+	if expectedVersion != nil && flat.Version != *expectedVersion {
+		return http.StatusPreconditionFailed,
+			fmt.Sprintf("flat was modified, current version is %d", flat.Version),
+			nil
+	}
+
+	if !moderation.CanTransition(flat.Status, targetStatus) {
+		return http.StatusConflict,
+			fmt.Sprintf("flat cannot be moved from status %q to %q", flat.Status, targetStatus),
+			nil
+	}
+
+	if moderation.RequiresOwner(flat.Status) {
+		return http.StatusForbidden,
+			"failed to update flat, flat is under moderation by another moderator",
+			nil
+	}
+
+	// статус успел поменяться между обновлением и перечитыванием
+	return http.StatusConflict, "flat was modified concurrently, retry the request", nil
+}
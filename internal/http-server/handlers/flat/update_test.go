@@ -0,0 +1,91 @@
+package flat
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"realty-avito/internal/auth"
+	repo_errors "realty-avito/internal/errors"
+	"realty-avito/internal/lib/logger"
+	"realty-avito/internal/models"
+	"realty-avito/internal/repositories/flatsRepo"
+	"realty-avito/internal/repositories/flatsRepo/mocks"
+)
+
+type noopNotifier struct{}
+
+func (noopNotifier) NotifyFlatApproved(flatsRepo.FlatEntity) {}
+
+func TestUpdateFlatHandlerIfMatch(t *testing.T) {
+	tests := []struct {
+		name               string
+		ifMatch            string
+		prepareMock        func(m *mocks.FlatsRepository)
+		expectedStatusCode int
+		expectedETag       string
+	}{
+		{
+			name:    "version matches",
+			ifMatch: `"3"`,
+			prepareMock: func(m *mocks.FlatsRepository) {
+				m.On("UpdateFlat", mock.Anything, mock.MatchedBy(func(e flatsRepo.UpdateFlatEntity) bool {
+					return e.ExpectedVersion != nil && *e.ExpectedVersion == 3
+				})).Return(&flatsRepo.FlatEntity{ID: 1, Status: flatsRepo.StatusOnModeration, Version: 4}, nil).Once()
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedETag:       `"4"`,
+		},
+		{
+			name:    "stale version",
+			ifMatch: `"3"`,
+			prepareMock: func(m *mocks.FlatsRepository) {
+				m.On("UpdateFlat", mock.Anything, mock.Anything).Return(nil, repo_errors.ErrFlatStateConflict).Once()
+				m.On("GetFlatByFlatID", mock.Anything, int64(1)).
+					Return(&flatsRepo.FlatEntity{ID: 1, Status: flatsRepo.StatusCreated, Version: 5}, nil).Once()
+			},
+			expectedStatusCode: http.StatusPreconditionFailed,
+		},
+		{
+			name: "without If-Match version is not checked",
+			prepareMock: func(m *mocks.FlatsRepository) {
+				m.On("UpdateFlat", mock.Anything, mock.MatchedBy(func(e flatsRepo.UpdateFlatEntity) bool {
+					return e.ExpectedVersion == nil
+				})).Return(&flatsRepo.FlatEntity{ID: 1, Status: flatsRepo.StatusOnModeration, Version: 2}, nil).Once()
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedETag:       `"2"`,
+		},
+		{
+			name:               "weak ETag",
+			ifMatch:            `W/"3"`,
+			prepareMock:        func(m *mocks.FlatsRepository) {},
+			expectedStatusCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockFlatsRepo := new(mocks.FlatsRepository)
+			tt.prepareMock(mockFlatsRepo)
+
+			req := httptest.NewRequest(http.MethodPost, "/flat/update", strings.NewReader(`{"id": 1, "status": "on moderation"}`))
+			if tt.ifMatch != "" {
+				req.Header.Set("If-Match", tt.ifMatch)
+			}
+			req = req.WithContext(auth.WithPrincipal(req.Context(), auth.Principal{Subject: "moderator", Role: models.Moderator}))
+
+			rr := httptest.NewRecorder()
+			UpdateFlatHandler(logger.SetupLogger("local"), mockFlatsRepo, noopNotifier{}).ServeHTTP(rr, req)
+
+			require.Equal(t, tt.expectedStatusCode, rr.Code)
+			require.Equal(t, tt.expectedETag, rr.Header().Get("ETag"))
+
+			mockFlatsRepo.AssertExpectations(t)
+		})
+	}
+}
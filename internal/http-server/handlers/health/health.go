@@ -0,0 +1,94 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+
+	"realty-avito/internal/buildinfo"
+	"realty-avito/internal/http-server/handlers"
+)
+
+const (
+	statusOK          = "ok"
+	statusUnavailable = "unavailable"
+
+	checkTimeout = 2 * time.Second
+)
+
+type Pinger interface {
+	Ping(ctx context.Context) error
+}
+
+type MigrationVersionGetter interface {
+	GetAppliedVersion(ctx context.Context) (int64, error)
+}
+
+type ShutdownState interface {
+	ShuttingDown() bool
+}
+
+// LivenessHandler отвечает, пока процесс жив и обрабатывает запросы. Зависимости не проверяются,
+// иначе недоступная база приводила бы к перезапуску всех экземпляров.
+func LivenessHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		render.JSON(w, r, handlers.HealthResponse{Status: statusOK})
+	}
+}
+
+// ReadinessHandler проверяет, что экземпляр может принимать трафик: база отвечает,
+// миграции применены не ниже версии, с которой собрано приложение, и не идет остановка.
+// Более новая схема допустима: при откате приложения миграции обратно не откатываются.
+func ReadinessHandler(
+	log *slog.Logger,
+	pinger Pinger,
+	migrations MigrationVersionGetter,
+	expectedVersion int64,
+	shutdown ShutdownState,
+) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.health.readiness"
+
+		checks := make(map[string]string)
+
+		if shutdown.ShuttingDown() {
+			checks["shutdown"] = "server is shutting down"
+		} else {
+			ctx, cancel := context.WithTimeout(r.Context(), checkTimeout)
+			defer cancel()
+
+			if err := pinger.Ping(ctx); err != nil {
+				checks["postgres"] = err.Error()
+			} else if version, err := migrations.GetAppliedVersion(ctx); err != nil {
+				checks["migrations"] = err.Error()
+			} else if version < expectedVersion {
+				checks["migrations"] = fmt.Sprintf("database is at version %d, expected %d", version, expectedVersion)
+			}
+		}
+
+		if len(checks) > 0 {
+			log.Warn("not ready", slog.String("op", op), slog.Any("checks", checks))
+
+			render.Status(r, http.StatusServiceUnavailable)
+			render.JSON(w, r, handlers.HealthResponse{Status: statusUnavailable, Checks: checks})
+			return
+		}
+
+		render.JSON(w, r, handlers.HealthResponse{Status: statusOK})
+	}
+}
+
+// VersionHandler отдает коммит и время сборки, подставленные через ldflags
+func VersionHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		render.JSON(w, r, handlers.VersionResponse{
+			Commit:    buildinfo.Commit,
+			BuildTime: buildinfo.BuildTime,
+			GoVersion: buildinfo.GoVersion(),
+		})
+	}
+}
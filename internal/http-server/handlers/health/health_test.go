@@ -0,0 +1,111 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"realty-avito/internal/http-server/handlers"
+	"realty-avito/internal/lib/logger"
+	"realty-avito/internal/repositories/migrationsRepo/mocks"
+)
+
+type pingerFunc func(ctx context.Context) error
+
+func (f pingerFunc) Ping(ctx context.Context) error {
+	return f(ctx)
+}
+
+type shutdownState bool
+
+func (s shutdownState) ShuttingDown() bool {
+	return bool(s)
+}
+
+func TestReadinessHandler(t *testing.T) {
+	const expectedVersion = int64(20240901100000)
+
+	pingOK := pingerFunc(func(context.Context) error { return nil })
+
+	tests := []struct {
+		name               string
+		pinger             Pinger
+		prepareMock        func(m *mocks.MigrationsRepository)
+		shuttingDown       bool
+		expectedStatusCode int
+		expectedCheck      string
+	}{
+		{
+			name:   "ready",
+			pinger: pingOK,
+			prepareMock: func(m *mocks.MigrationsRepository) {
+				m.On("GetAppliedVersion", mock.Anything).Return(expectedVersion, nil).Once()
+			},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:   "newer schema after rollback",
+			pinger: pingOK,
+			prepareMock: func(m *mocks.MigrationsRepository) {
+				m.On("GetAppliedVersion", mock.Anything).Return(expectedVersion+1, nil).Once()
+			},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:   "migrations behind",
+			pinger: pingOK,
+			prepareMock: func(m *mocks.MigrationsRepository) {
+				m.On("GetAppliedVersion", mock.Anything).Return(int64(20240830100000), nil).Once()
+			},
+			expectedStatusCode: http.StatusServiceUnavailable,
+			expectedCheck:      "migrations",
+		},
+		{
+			name:               "postgres is down",
+			pinger:             pingerFunc(func(context.Context) error { return errors.New("connection refused") }),
+			prepareMock:        func(m *mocks.MigrationsRepository) {},
+			expectedStatusCode: http.StatusServiceUnavailable,
+			expectedCheck:      "postgres",
+		},
+		{
+			name:               "shutting down",
+			pinger:             pingOK,
+			prepareMock:        func(m *mocks.MigrationsRepository) {},
+			shuttingDown:       true,
+			expectedStatusCode: http.StatusServiceUnavailable,
+			expectedCheck:      "shutdown",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockMigrationsRepo := new(mocks.MigrationsRepository)
+			tt.prepareMock(mockMigrationsRepo)
+
+			handler := ReadinessHandler(logger.SetupLogger("local"), tt.pinger, mockMigrationsRepo, expectedVersion, shutdownState(tt.shuttingDown))
+
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+
+			require.Equal(t, tt.expectedStatusCode, rr.Code)
+
+			var response handlers.HealthResponse
+			require.NoError(t, json.NewDecoder(rr.Body).Decode(&response))
+
+			if tt.expectedCheck != "" {
+				require.Equal(t, statusUnavailable, response.Status)
+				require.Contains(t, response.Checks, tt.expectedCheck)
+			} else {
+				require.Equal(t, statusOK, response.Status)
+			}
+
+			mockMigrationsRepo.AssertExpectations(t)
+		})
+	}
+}
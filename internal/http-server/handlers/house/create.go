@@ -12,6 +12,7 @@ import (
 
 	"realty-avito/internal/converter"
 	"realty-avito/internal/http-server/handlers"
+	"realty-avito/internal/lib/etag"
 	"realty-avito/internal/models"
 	"realty-avito/internal/repositories/housesRepo"
 )
@@ -73,6 +74,7 @@ func CreateHouseHandler(log *slog.Logger, housesRepo housesRepo.HousesRepository
 
 		response := converter.ConvertEntityToCreateHouseResponse(createdHouseEntity)
 
+		w.Header().Set("ETag", etag.FromVersion(createdHouseEntity.Version))
 		render.JSON(w, r, response)
 		log.Info("house created successfully",
 			slog.String("op", op),
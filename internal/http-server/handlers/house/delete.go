@@ -0,0 +1,94 @@
+package house
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+
+	"realty-avito/internal/auth"
+	"realty-avito/internal/client/db"
+	repo_errors "realty-avito/internal/errors"
+	"realty-avito/internal/lib/logger/sl"
+	"realty-avito/internal/models"
+	"realty-avito/internal/repositories/auditRepo"
+)
+
+type HousesDeleter interface {
+	DeleteHouse(ctx context.Context, houseID int64) error
+}
+
+type AuditWriter interface {
+	AddRecord(ctx context.Context, createAuditRecordEntity auditRepo.CreateAuditRecordEntity) error
+}
+
+// DeleteHouseHandler мягко удаляет дом вместе с его квартирами и пишет запись в журнал действий
+func DeleteHouseHandler(log *slog.Logger, housesDeleter HousesDeleter, auditWriter AuditWriter, txManager db.TxManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.house.delete"
+
+		ctx := r.Context()
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(ctx)),
+		)
+
+		principal, ok := auth.PrincipalFromContext(ctx)
+		if !ok {
+			log.Error("principal not found in context")
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		var houseIDStr = chi.URLParam(r, "id")
+		houseID, err := strconv.ParseInt(houseIDStr, 10, 64)
+		if err != nil || houseID < 1 {
+			log.Error("invalid house ID", slog.String("house_id", houseIDStr))
+			http.Error(w, "Invalid house ID", http.StatusBadRequest)
+			return
+		}
+
+		err = txManager.ReadCommitted(ctx, func(ctx context.Context) error {
+			if errTx := housesDeleter.DeleteHouse(ctx, houseID); errTx != nil {
+				return errTx
+			}
+
+			return auditWriter.AddRecord(ctx, auditRepo.CreateAuditRecordEntity{
+				ActorID:    principal.Subject,
+				Action:     auditRepo.ActionDelete,
+				EntityType: auditRepo.EntityHouse,
+				EntityID:   houseID,
+			})
+		})
+		if err != nil {
+			var houseNotFoundErr *repo_errors.ErrHouseNotFound
+			if errors.As(err, &houseNotFoundErr) {
+				log.Info("house not found", slog.Int64("house_id", houseID))
+				http.Error(w, houseNotFoundErr.Error(), http.StatusNotFound)
+				return
+			}
+
+			log.Error("failed to delete house", sl.Err(err))
+
+			w.Header().Set("Retry-After", "60")
+			w.WriteHeader(http.StatusInternalServerError)
+
+			response := models.InternalServerErrorResponse{
+				Message:   err.Error(),
+				RequestID: middleware.GetReqID(ctx),
+				Code:      12345,
+			}
+			render.JSON(w, r, response)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+		log.Info("house deleted", slog.Int64("house_id", houseID), slog.String("moderator_id", principal.Subject))
+	}
+}
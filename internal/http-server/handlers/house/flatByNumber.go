@@ -0,0 +1,96 @@
+package house
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+
+	"realty-avito/internal/auth"
+	"realty-avito/internal/converter"
+	repo_errors "realty-avito/internal/errors"
+	"realty-avito/internal/lib/etag"
+	"realty-avito/internal/lib/logger/sl"
+	"realty-avito/internal/models"
+	"realty-avito/internal/repositories/flatsRepo"
+)
+
+type FlatByNumberGetter interface {
+	GetFlatByNumber(ctx context.Context, houseID int64, flatNumber int64) (*flatsRepo.FlatEntity, error)
+}
+
+// GetFlatByNumberHandler ищет квартиру по номеру в доме. Клиентам доступны только одобренные квартиры.
+func GetFlatByNumberHandler(log *slog.Logger, flatGetter FlatByNumberGetter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.house.flatByNumber"
+
+		ctx := r.Context()
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(ctx)),
+		)
+
+		principal, ok := auth.PrincipalFromContext(ctx)
+		if !ok {
+			log.Error("principal not found in context")
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		var houseIDStr = chi.URLParam(r, "id")
+		houseID, err := strconv.ParseInt(houseIDStr, 10, 64)
+		if err != nil || houseID < 1 {
+			log.Error("invalid house ID", slog.String("house_id", houseIDStr))
+			http.Error(w, "Invalid house ID", http.StatusBadRequest)
+			return
+		}
+
+		var flatNumberStr = chi.URLParam(r, "number")
+		flatNumber, err := strconv.ParseInt(flatNumberStr, 10, 64)
+		if err != nil || flatNumber < 1 {
+			log.Error("invalid flat number", slog.String("flat_number", flatNumberStr))
+			http.Error(w, "Invalid flat number", http.StatusBadRequest)
+			return
+		}
+
+		flat, err := flatGetter.GetFlatByNumber(ctx, houseID, flatNumber)
+		if err != nil {
+			var flatNotFoundErr *repo_errors.ErrFlatNumberNotFound
+			if errors.As(err, &flatNotFoundErr) {
+				log.Info("flat not found", slog.Int64("house_id", houseID), slog.Int64("flat_number", flatNumber))
+				http.Error(w, flatNotFoundErr.Error(), http.StatusNotFound)
+				return
+			}
+
+			log.Error("failed to get flat", sl.Err(err))
+
+			w.Header().Set("Retry-After", "60")
+			w.WriteHeader(http.StatusInternalServerError)
+
+			response := models.InternalServerErrorResponse{
+				Message:   err.Error(),
+				RequestID: middleware.GetReqID(ctx),
+				Code:      12345,
+			}
+			render.JSON(w, r, response)
+			return
+		}
+
+		// клиент не должен узнать о существовании неодобренной квартиры
+		if principal.Role != models.Moderator && flat.Status != flatsRepo.StatusApproved {
+			log.Info("flat is not approved", slog.Int64("flat_id", flat.ID))
+			http.Error(w, (&repo_errors.ErrFlatNumberNotFound{HouseID: houseID, FlatNumber: flatNumber}).Error(), http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("ETag", etag.FromVersion(flat.Version))
+		render.JSON(w, r, converter.ConvertEntityToFlat(*flat))
+		log.Info("request handled successfully", slog.Int64("flat_id", flat.ID))
+	}
+}
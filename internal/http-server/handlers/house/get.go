@@ -2,7 +2,11 @@ package house
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"net/url"
 	"strconv"
 
 	"github.com/go-chi/chi/v5"
@@ -10,34 +14,49 @@ import (
 	"github.com/go-chi/render"
 	"golang.org/x/exp/slog"
 
+	"realty-avito/internal/auth"
 	"realty-avito/internal/converter"
+	repo_errors "realty-avito/internal/errors"
 	"realty-avito/internal/http-server/handlers"
+	"realty-avito/internal/lib/cursor"
+	"realty-avito/internal/lib/etag"
 	"realty-avito/internal/lib/logger/sl"
 	"realty-avito/internal/models"
 	"realty-avito/internal/repositories/flatsRepo"
+	"realty-avito/internal/repositories/photosRepo"
 )
 
 type FlatsGetter interface {
-	GetFlatsByHouseID(ctx context.Context, houseID int64) ([]flatsRepo.FlatEntity, error)
-	GetApprovedFlatsByHouseID(ctx context.Context, houseID int64) ([]flatsRepo.FlatEntity, error)
+	GetFlatsByHouseID(ctx context.Context, houseID int64, page flatsRepo.HouseFlatsPage) ([]flatsRepo.FlatEntity, error)
+	GetApprovedFlatsByHouseID(ctx context.Context, houseID int64, page flatsRepo.HouseFlatsPage) ([]flatsRepo.FlatEntity, error)
 }
 
+type FlatPhotosGetter interface {
+	GetPhotosByFlatIDs(ctx context.Context, flatIDs []int64) ([]photosRepo.PhotoEntity, error)
+}
+
+const (
+	defaultFlatsLimit = 100
+	maxFlatsLimit     = 1000
+)
+
 type Request struct {
 	ID int64 `json:"id" validate:"required,min=1"`
 }
 
 type Response struct {
-	Flats []handlers.Flat `json:"flats" validate:"required,dive"`
+	Flats      []handlers.Flat `json:"flats" validate:"required,dive"`
+	NextCursor string          `json:"next_cursor,omitempty"`
 }
 
-func GetFlatsInHouseHandler(log *slog.Logger, flatsRepository flatsRepo.FlatsRepository) http.HandlerFunc {
+func GetFlatsInHouseHandler(log *slog.Logger, flatsRepository flatsRepo.FlatsRepository, photosGetter FlatPhotosGetter) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.house.get"
 
-		userType, ok := r.Context().Value("user_type").(string)
+		principal, ok := auth.PrincipalFromContext(r.Context())
 		if !ok {
 			log.Error(
-				"user_type not found in context",
+				"principal not found in context",
 				slog.String("op", op))
 			w.WriteHeader(http.StatusUnauthorized)
 			return
@@ -53,21 +72,60 @@ func GetFlatsInHouseHandler(log *slog.Logger, flatsRepository flatsRepo.FlatsRep
 			return
 		}
 
-		var flatEntities []flatsRepo.FlatEntity
-		var response Response
+		page, err := parseHouseFlatsPage(r.URL.Query())
+		if err != nil {
+			log.Error("invalid pagination parameters",
+				slog.String("op", op),
+				sl.Err(err))
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
 
-		if userType == "moderator" {
-			flatEntities, err = flatsRepository.GetFlatsByHouseID(r.Context(), houseID)
-		} else if userType == "client" {
-			flatEntities, err = flatsRepository.GetApprovedFlatsByHouseID(r.Context(), houseID)
-		} else {
+		if principal.Role != models.Moderator && principal.Role != models.Client {
 			log.Error("unauthorized access attempt",
-				slog.String("user_type", userType),
+				slog.String("user_type", string(principal.Role)),
 				slog.String("op", op))
 			w.WriteHeader(http.StatusUnauthorized)
 			return
 		}
 
+		// ETag по сводке квартир дома считается до чтения страницы, поэтому 304 не стоит запросов за квартирами и фотографиями.
+		// Если сводку получить не удалось, ETag считается по телу ответа.
+		stateETag := ""
+		state, err := flatsRepository.GetHouseFlatsState(r.Context(), houseID, principal.Role != models.Moderator)
+		var houseNotFoundErr *repo_errors.ErrHouseNotFound
+		if errors.As(err, &houseNotFoundErr) {
+			log.Info("house not found", slog.String("op", op), slog.Int64("house_id", houseID))
+			http.Error(w, houseNotFoundErr.Error(), http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			log.Warn("failed to get house flats state", slog.String("op", op), sl.Err(err))
+		} else {
+			stateETag = houseFlatsETag(*state, principal.Role, page)
+
+			if etag.NoneMatch(r.Header.Get("If-None-Match"), stateETag) {
+				w.Header().Set("ETag", stateETag)
+				w.WriteHeader(http.StatusNotModified)
+				log.Info("flats not modified", slog.String("op", op), slog.Int64("house_id", houseID))
+				return
+			}
+		}
+
+		var flatEntities []flatsRepo.FlatEntity
+		var response Response
+
+		if principal.Role == models.Moderator {
+			flatEntities, err = flatsRepository.GetFlatsByHouseID(r.Context(), houseID, page)
+		} else {
+			flatEntities, err = flatsRepository.GetApprovedFlatsByHouseID(r.Context(), houseID, page)
+		}
+
+		var photos map[int64][]handlers.Photo
+		if err == nil {
+			photos, err = getVisiblePhotos(r.Context(), photosGetter, flatEntities, principal.Role)
+		}
+
 		if err != nil {
 			log.Error(
 				"failed to get flats",
@@ -88,6 +146,9 @@ func GetFlatsInHouseHandler(log *slog.Logger, flatsRepository flatsRepo.FlatsRep
 		}
 
 		flats := converter.ConvertFlatEntitiesToFlats(flatEntities)
+		for i := range flats {
+			flats[i].Photos = photos[flats[i].ID]
+		}
 
 		if len(flats) == 0 {
 			flats = []handlers.Flat{}
@@ -97,11 +158,144 @@ func GetFlatsInHouseHandler(log *slog.Logger, flatsRepository flatsRepo.FlatsRep
 			Flats: flats,
 		}
 
-		render.JSON(w, r, response)
+		// полная страница - возможно, в доме есть еще квартиры
+		if uint64(len(flatEntities)) == page.Limit {
+			response.NextCursor = encodeHouseFlatsCursor(flatEntities[len(flatEntities)-1], page.SortBy)
+		}
+
+		body, err := json.Marshal(response)
+		if err != nil {
+			log.Error("failed to encode response", slog.String("op", op), sl.Err(err))
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		responseETag := stateETag
+		if responseETag == "" {
+			responseETag = etag.FromContent(body)
+		}
+		w.Header().Set("ETag", responseETag)
+
+		if etag.NoneMatch(r.Header.Get("If-None-Match"), responseETag) {
+			w.WriteHeader(http.StatusNotModified)
+			log.Info("flats not modified", slog.String("op", op), slog.Int64("house_id", houseID))
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(body)
 		log.Info(
 			"request handled successfully",
 			slog.String("op", op),
-			slog.String("user_type", userType),
+			slog.String("user_type", string(principal.Role)),
 		)
 	}
 }
+
+// houseFlatsETag слабый ETag страницы квартир: сводка по квартирам дома, роль (от нее зависит,
+// какие квартиры и фотографии видны) и параметры страницы
+func houseFlatsETag(state flatsRepo.HouseFlatsState, role models.UserType, page flatsRepo.HouseFlatsPage) string {
+	validator := fmt.Sprintf("%s|%d|%d|%s|%s|%t|%d",
+		role, state.HouseVersion, state.Count, state.FlatsDigest,
+		page.SortBy, page.Desc, page.Limit)
+	if page.After != nil {
+		validator += fmt.Sprintf("|%d|%d", page.After.Value, page.After.ID)
+	}
+
+	return etag.FromContent([]byte(validator))
+}
+
+// getVisiblePhotos загружает фотографии квартир одним запросом. Клиенты видят только одобренные
+// фотографии одобренных квартир, поэтому отклоненная квартира скрывает и свои фотографии.
+func getVisiblePhotos(
+	ctx context.Context,
+	photosGetter FlatPhotosGetter,
+	flats []flatsRepo.FlatEntity,
+	role models.UserType,
+) (map[int64][]handlers.Photo, error) {
+	flatIDs := make([]int64, 0, len(flats))
+	for _, flat := range flats {
+		if role == models.Moderator || flat.Status == flatsRepo.StatusApproved {
+			flatIDs = append(flatIDs, flat.ID)
+		}
+	}
+
+	if len(flatIDs) == 0 {
+		return nil, nil
+	}
+
+	photoEntities, err := photosGetter.GetPhotosByFlatIDs(ctx, flatIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	photos := make(map[int64][]handlers.Photo, len(flatIDs))
+	for _, photo := range photoEntities {
+		if role != models.Moderator && photo.Status != photosRepo.PhotoStatusApproved {
+			continue
+		}
+		photos[photo.FlatID] = append(photos[photo.FlatID], converter.ConvertPhotoEntityToPhoto(photo))
+	}
+
+	return photos, nil
+}
+
+func parseHouseFlatsPage(query url.Values) (flatsRepo.HouseFlatsPage, error) {
+	page := flatsRepo.HouseFlatsPage{
+		SortBy: flatsRepo.HouseFlatsSortByID,
+		Limit:  defaultFlatsLimit,
+	}
+
+	switch sortBy := flatsRepo.HouseFlatsSortField(query.Get("sort")); sortBy {
+	case "":
+	case flatsRepo.HouseFlatsSortByID, flatsRepo.HouseFlatsSortByPrice, flatsRepo.HouseFlatsSortByRooms:
+		page.SortBy = sortBy
+	default:
+		return page, errors.New("sort must be id, price or rooms")
+	}
+
+	switch order := query.Get("order"); order {
+	case "", "asc":
+	case "desc":
+		page.Desc = true
+	default:
+		return page, errors.New("order must be asc or desc")
+	}
+
+	if limitStr := query.Get("limit"); limitStr != "" {
+		limit, err := strconv.ParseUint(limitStr, 10, 64)
+		if err != nil || limit < 1 || limit > maxFlatsLimit {
+			return page, fmt.Errorf("limit must be between 1 and %d", maxFlatsLimit)
+		}
+		page.Limit = limit
+	}
+
+	c, err := cursor.Decode(query.Get("cursor"))
+	if err != nil {
+		return page, err
+	}
+	if c != nil {
+		page.After = &flatsRepo.HouseFlatsPosition{ID: c.ID}
+
+		if page.SortBy != flatsRepo.HouseFlatsSortByID {
+			if page.After.Value, err = strconv.ParseInt(c.Value, 10, 64); err != nil {
+				return page, cursor.ErrInvalidCursor
+			}
+		}
+	}
+
+	return page, nil
+}
+
+func encodeHouseFlatsCursor(last flatsRepo.FlatEntity, sortBy flatsRepo.HouseFlatsSortField) string {
+	c := cursor.Cursor{ID: last.ID}
+
+	switch sortBy {
+	case flatsRepo.HouseFlatsSortByPrice:
+		c.Value = strconv.FormatInt(last.Price, 10)
+	case flatsRepo.HouseFlatsSortByRooms:
+		c.Value = strconv.FormatInt(last.Rooms, 10)
+	}
+
+	return cursor.Encode(c)
+}
@@ -3,6 +3,7 @@ package house
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -12,25 +13,38 @@ import (
 	"github.com/stretchr/testify/mock"
 	"github.com/stretchr/testify/require"
 
+	"realty-avito/internal/auth"
+	repo_errors "realty-avito/internal/errors"
 	"realty-avito/internal/http-server/handlers"
+	"realty-avito/internal/lib/cursor"
 	"realty-avito/internal/lib/logger"
+	"realty-avito/internal/models"
 	"realty-avito/internal/repositories/flatsRepo"
 	"realty-avito/internal/repositories/flatsRepo/mocks"
+	"realty-avito/internal/repositories/photosRepo"
+	photoMocks "realty-avito/internal/repositories/photosRepo/mocks"
 )
 
 func TestGetFlatsInHouseHandler(t *testing.T) {
 	mockFlatsRepo := new(mocks.FlatsRepository)
+	mockFlatsRepo.On("GetHouseFlatsState", mock.Anything, int64(1), mock.Anything).Return(&flatsRepo.HouseFlatsState{HouseVersion: 1}, nil)
+	mockFlatsRepo.On("GetHouseFlatsState", mock.Anything, int64(2), mock.Anything).Return(nil, &repo_errors.ErrHouseNotFound{HouseID: 2})
+	mockPhotosRepo := new(photoMocks.PhotosRepository)
+	mockPhotosRepo.On("GetPhotosByFlatIDs", mock.Anything, mock.Anything).Return(nil, nil)
 	log := logger.SetupLogger("local")
 
 	r := chi.NewRouter()
-	handler := GetFlatsInHouseHandler(log, mockFlatsRepo) // ваш хэндлер
+	handler := GetFlatsInHouseHandler(log, mockFlatsRepo, mockPhotosRepo) // ваш хэндлер
 
 	r.Get("/house/{id}", handler)
 
+	defaultPage := flatsRepo.HouseFlatsPage{SortBy: flatsRepo.HouseFlatsSortByID, Limit: defaultFlatsLimit}
+
 	tests := []struct {
 		name               string
 		userType           string
 		houseID            string
+		query              string
 		prepareMock        func()
 		expectedStatusCode int
 		expectedResponse   interface{}
@@ -40,7 +54,7 @@ func TestGetFlatsInHouseHandler(t *testing.T) {
 			userType: "client",
 			houseID:  "1",
 			prepareMock: func() {
-				mockFlatsRepo.On("GetApprovedFlatsByHouseID", mock.Anything, int64(1)).Return([]flatsRepo.FlatEntity{
+				mockFlatsRepo.On("GetApprovedFlatsByHouseID", mock.Anything, int64(1), defaultPage).Return([]flatsRepo.FlatEntity{
 					{ID: 1, HouseID: 1, Status: "approved"},
 				}, nil)
 			},
@@ -56,7 +70,7 @@ func TestGetFlatsInHouseHandler(t *testing.T) {
 			userType: "moderator",
 			houseID:  "1",
 			prepareMock: func() {
-				mockFlatsRepo.On("GetFlatsByHouseID", mock.Anything, int64(1)).Return([]flatsRepo.FlatEntity{
+				mockFlatsRepo.On("GetFlatsByHouseID", mock.Anything, int64(1), defaultPage).Return([]flatsRepo.FlatEntity{
 					{ID: 1, HouseID: 1, Status: "created"},
 					{ID: 2, HouseID: 1, Status: "approved"},
 					{ID: 3, HouseID: 1, Status: "declined"},
@@ -71,6 +85,47 @@ func TestGetFlatsInHouseHandler(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:     "client paginates by price",
+			userType: "client",
+			houseID:  "1",
+			query:    "?sort=price&order=desc&limit=2&cursor=" + cursor.Encode(cursor.Cursor{Value: "500", ID: 9}),
+			prepareMock: func() {
+				page := flatsRepo.HouseFlatsPage{
+					SortBy: flatsRepo.HouseFlatsSortByPrice,
+					Desc:   true,
+					Limit:  2,
+					After:  &flatsRepo.HouseFlatsPosition{Value: 500, ID: 9},
+				}
+				mockFlatsRepo.On("GetApprovedFlatsByHouseID", mock.Anything, int64(1), page).Return([]flatsRepo.FlatEntity{
+					{ID: 4, HouseID: 1, Price: 400, Status: "approved"},
+					{ID: 5, HouseID: 1, Price: 300, Status: "approved"},
+				}, nil).Once()
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedResponse: Response{
+				Flats: []handlers.Flat{
+					{ID: 4, HouseID: 1, Price: 400, Status: "approved"},
+					{ID: 5, HouseID: 1, Price: 300, Status: "approved"},
+				},
+				NextCursor: cursor.Encode(cursor.Cursor{Value: "300", ID: 5}),
+			},
+		},
+		{
+			name:               "deleted house",
+			userType:           "client",
+			houseID:            "2",
+			prepareMock:        func() {},
+			expectedStatusCode: http.StatusNotFound,
+		},
+		{
+			name:               "invalid limit",
+			userType:           "client",
+			houseID:            "1",
+			query:              "?limit=0",
+			prepareMock:        func() {},
+			expectedStatusCode: http.StatusBadRequest,
+		},
 		{
 			name:               "missing user type",
 			userType:           "",
@@ -93,7 +148,7 @@ func TestGetFlatsInHouseHandler(t *testing.T) {
 		t.Run(tt.name, func(t *testing.T) {
 			tt.prepareMock()
 
-			req, err := http.NewRequest("GET", fmt.Sprintf("/house/%s", tt.houseID), nil)
+			req, err := http.NewRequest("GET", fmt.Sprintf("/house/%s%s", tt.houseID, tt.query), nil)
 			require.NoError(t, err)
 
 			rctx := chi.NewRouteContext()
@@ -102,8 +157,10 @@ func TestGetFlatsInHouseHandler(t *testing.T) {
 			ctx := context.WithValue(req.Context(), chi.RouteCtxKey, rctx)
 			req = req.WithContext(ctx)
 
-			ctx = context.WithValue(req.Context(), "user_type", tt.userType)
-			req = req.WithContext(ctx)
+			if tt.userType != "" {
+				ctx = auth.WithPrincipal(req.Context(), auth.Principal{Role: models.UserType(tt.userType)})
+				req = req.WithContext(ctx)
+			}
 
 			rr := httptest.NewRecorder()
 
@@ -126,3 +183,96 @@ func TestGetFlatsInHouseHandler(t *testing.T) {
 		})
 	}
 }
+
+func TestGetVisiblePhotos(t *testing.T) {
+	mockPhotosRepo := new(photoMocks.PhotosRepository)
+	mockPhotosRepo.On("GetPhotosByFlatIDs", mock.Anything, []int64{1}).Return([]photosRepo.PhotoEntity{
+		{ID: 10, FlatID: 1, ContentType: "image/png", Status: photosRepo.PhotoStatusApproved},
+		{ID: 12, FlatID: 1, ContentType: "image/png", Status: photosRepo.PhotoStatusPending},
+	}, nil).Once()
+	mockPhotosRepo.On("GetPhotosByFlatIDs", mock.Anything, []int64{1, 2}).Return([]photosRepo.PhotoEntity{
+		{ID: 10, FlatID: 1, ContentType: "image/png", Status: photosRepo.PhotoStatusApproved},
+		{ID: 12, FlatID: 1, ContentType: "image/png", Status: photosRepo.PhotoStatusPending},
+		{ID: 11, FlatID: 2, ContentType: "image/jpeg", Status: photosRepo.PhotoStatusApproved},
+	}, nil).Once()
+
+	flats := []flatsRepo.FlatEntity{
+		{ID: 1, Status: flatsRepo.StatusApproved},
+		{ID: 2, Status: flatsRepo.StatusDeclined},
+	}
+
+	// клиент не видит фотографии отклоненной квартиры и еще не проверенные фотографии одобренной
+	photos, err := getVisiblePhotos(context.Background(), mockPhotosRepo, flats, models.Client)
+	require.NoError(t, err)
+	require.Len(t, photos, 1)
+	require.Len(t, photos[1], 1)
+	require.Equal(t, "/flat/1/photos/10?size=thumbnail", photos[1][0].ThumbnailURL)
+
+	photos, err = getVisiblePhotos(context.Background(), mockPhotosRepo, flats, models.Moderator)
+	require.NoError(t, err)
+	require.Len(t, photos, 2)
+	require.Len(t, photos[1], 2)
+
+	mockPhotosRepo.AssertExpectations(t)
+}
+
+func TestGetFlatsInHouseHandlerNotModified(t *testing.T) {
+	mockFlatsRepo := new(mocks.FlatsRepository)
+	mockFlatsRepo.On("GetHouseFlatsState", mock.Anything, int64(1), true).
+		Return(&flatsRepo.HouseFlatsState{HouseVersion: 1, Count: 1, FlatsDigest: "a"}, nil).Twice()
+	mockFlatsRepo.On("GetHouseFlatsState", mock.Anything, int64(1), true).
+		Return(&flatsRepo.HouseFlatsState{HouseVersion: 1, Count: 1, FlatsDigest: "b"}, nil).Once()
+	mockFlatsRepo.On("GetHouseFlatsState", mock.Anything, int64(1), true).
+		Return(nil, errors.New("connection refused")).Twice()
+
+	// страница читается только при изменениях: на 304 запросов за квартирами нет
+	mockFlatsRepo.On("GetApprovedFlatsByHouseID", mock.Anything, int64(1), mock.Anything).Return([]flatsRepo.FlatEntity{
+		{ID: 1, HouseID: 1, Status: "approved", Version: 1},
+	}, nil).Once()
+	mockFlatsRepo.On("GetApprovedFlatsByHouseID", mock.Anything, int64(1), mock.Anything).Return([]flatsRepo.FlatEntity{
+		{ID: 1, HouseID: 1, Status: "approved", Price: 100, Version: 2},
+	}, nil).Times(3)
+
+	mockPhotosRepo := new(photoMocks.PhotosRepository)
+	mockPhotosRepo.On("GetPhotosByFlatIDs", mock.Anything, mock.Anything).Return(nil, nil)
+
+	r := chi.NewRouter()
+	r.Get("/house/{id}", GetFlatsInHouseHandler(logger.SetupLogger("local"), mockFlatsRepo, mockPhotosRepo))
+
+	send := func(ifNoneMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/house/1", nil)
+		req = req.WithContext(auth.WithPrincipal(req.Context(), auth.Principal{Role: models.Client}))
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+
+		rr := httptest.NewRecorder()
+		r.ServeHTTP(rr, req)
+		return rr
+	}
+
+	first := send("")
+	require.Equal(t, http.StatusOK, first.Code)
+	responseETag := first.Header().Get("ETag")
+	require.NotEmpty(t, responseETag)
+
+	// ничего не изменилось
+	notModified := send(responseETag)
+	require.Equal(t, http.StatusNotModified, notModified.Code)
+	require.Equal(t, responseETag, notModified.Header().Get("ETag"))
+	require.Empty(t, notModified.Body.String())
+
+	// квартира изменилась, клиент получает новую страницу
+	changed := send(responseETag)
+	require.Equal(t, http.StatusOK, changed.Code)
+	require.NotEqual(t, responseETag, changed.Header().Get("ETag"))
+
+	// сводка недоступна: ETag считается по телу ответа
+	fallback := send("")
+	require.Equal(t, http.StatusOK, fallback.Code)
+	fallbackETag := fallback.Header().Get("ETag")
+	require.NotEmpty(t, fallbackETag)
+	require.Equal(t, http.StatusNotModified, send(fallbackETag).Code)
+
+	mockFlatsRepo.AssertExpectations(t)
+}
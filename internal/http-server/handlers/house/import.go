@@ -0,0 +1,521 @@
+package house
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"golang.org/x/exp/slog"
+
+	"realty-avito/internal/client/db"
+	"realty-avito/internal/config"
+	"realty-avito/internal/converter"
+	repo_errors "realty-avito/internal/errors"
+	"realty-avito/internal/http-server/handlers"
+	"realty-avito/internal/lib/logger/sl"
+	"realty-avito/internal/metrics"
+	"realty-avito/internal/models"
+	"realty-avito/internal/repositories/flatsRepo"
+)
+
+const (
+	contentTypeCSV    = "text/csv"
+	contentTypeNDJSON = "application/x-ndjson"
+
+	maxNDJSONLineSize = 1 << 20
+)
+
+// errDryRun откатывает транзакцию импорта в режиме проверки
+var errDryRun = errors.New("dry run")
+
+type FlatsImporter interface {
+	ImportFlats(ctx context.Context, houseID int64, flatEntities []flatsRepo.CreateFlatEntity) ([]flatsRepo.FlatEntity, error)
+}
+
+type HouseToucher interface {
+	UpdateHouseUpdatedAt(ctx context.Context, houseID int64) error
+}
+
+// importFileError ошибка, из-за которой файл нельзя разобрать целиком
+type importFileError struct {
+	status  int
+	message string
+}
+
+func (e *importFileError) Error() string {
+	return e.message
+}
+
+// importedRow строка файла, прошедшая разбор, с ее номером для отчета об ошибках
+type importedRow struct {
+	num int
+	row handlers.ImportFlatRow
+}
+
+// ImportFlatsHandler массово создает квартиры дома из CSV (text/csv) или NDJSON (application/x-ndjson).
+// Импорт атомарный: если хотя бы в одной строке есть ошибка, не создается ни одной квартиры,
+// а в ответе 422 возвращается отчет по строкам. С ?dry_run=true файл проверяется,
+// включая занятость номеров квартир, но транзакция откатывается.
+func ImportFlatsHandler(log *slog.Logger, flatsImporter FlatsImporter, houseToucher HouseToucher, txManager db.TxManager, cfg config.ImportConfig) http.HandlerFunc {
+	rowValidator := newImportRowValidator()
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.house.import"
+
+		ctx := r.Context()
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(ctx)),
+		)
+
+		var houseIDStr = chi.URLParam(r, "id")
+		houseID, err := strconv.ParseInt(houseIDStr, 10, 64)
+		if err != nil || houseID < 1 {
+			log.Error("invalid house ID", slog.String("house_id", houseIDStr))
+			http.Error(w, "Invalid house ID", http.StatusBadRequest)
+			return
+		}
+
+		dryRun := false
+		if dryRunStr := r.URL.Query().Get("dry_run"); dryRunStr != "" {
+			dryRun, err = strconv.ParseBool(dryRunStr)
+			if err != nil {
+				log.Error("invalid dry_run", slog.String("dry_run", dryRunStr))
+				http.Error(w, "dry_run must be true or false", http.StatusBadRequest)
+				return
+			}
+		}
+
+		body := http.MaxBytesReader(w, r.Body, cfg.MaxFileSize)
+
+		var rows []importedRow
+		var rowErrors []handlers.ImportRowError
+
+		mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		switch mediaType {
+		case contentTypeCSV:
+			rows, rowErrors, err = parseCSVRows(body, cfg.MaxRows)
+		case contentTypeNDJSON:
+			rows, rowErrors, err = parseNDJSONRows(body, cfg.MaxRows)
+		default:
+			log.Error("unsupported content type", slog.String("content_type", mediaType))
+			http.Error(w, "Content-Type must be text/csv or application/x-ndjson", http.StatusUnsupportedMediaType)
+			return
+		}
+		if err != nil {
+			log.Error("failed to parse import file", sl.Err(err))
+
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				http.Error(w, fmt.Sprintf("file is larger than %d bytes", cfg.MaxFileSize), http.StatusRequestEntityTooLarge)
+				return
+			}
+
+			var fileErr *importFileError
+			if errors.As(err, &fileErr) {
+				http.Error(w, fileErr.message, fileErr.status)
+				return
+			}
+
+			http.Error(w, "failed to read import file", http.StatusBadRequest)
+			return
+		}
+
+		total := len(rows) + len(rowErrors)
+		if total == 0 {
+			log.Error("import file has no rows")
+			http.Error(w, "import file has no rows", http.StatusBadRequest)
+			return
+		}
+
+		rowErrors = append(rowErrors, validateImportRows(rowValidator, rows)...)
+
+		response := handlers.ImportFlatsResponse{
+			HouseID: houseID,
+			DryRun:  dryRun,
+			Total:   total,
+			Errors:  []handlers.ImportRowError{},
+		}
+
+		if len(rowErrors) > 0 {
+			log.Info("import file has invalid rows", slog.Int("errors", len(rowErrors)))
+
+			response.Errors = sortRowErrors(rowErrors)
+			render.Status(r, http.StatusUnprocessableEntity)
+			render.JSON(w, r, response)
+			return
+		}
+
+		flatEntities := make([]flatsRepo.CreateFlatEntity, len(rows))
+		for i, row := range rows {
+			flatEntities[i] = converter.ConvertImportFlatRowToEntity(houseID, row.row)
+		}
+
+		var createdFlats []flatsRepo.FlatEntity
+
+		err = txManager.ReadCommitted(ctx, func(ctx context.Context) error {
+			if errTx := houseToucher.UpdateHouseUpdatedAt(ctx, houseID); errTx != nil {
+				return errTx
+			}
+
+			var errTx error
+			createdFlats, errTx = flatsImporter.ImportFlats(ctx, houseID, flatEntities)
+			if errTx != nil {
+				return errTx
+			}
+
+			if dryRun {
+				return errDryRun
+			}
+
+			return nil
+		})
+		if err != nil && !errors.Is(err, errDryRun) {
+			var houseNotFoundErr *repo_errors.ErrHouseNotFound
+			if errors.As(err, &houseNotFoundErr) {
+				log.Info("house not found", slog.Int64("house_id", houseID))
+				http.Error(w, houseNotFoundErr.Error(), http.StatusNotFound)
+				return
+			}
+
+			var flatNumbersTakenErr *repo_errors.ErrFlatNumbersTaken
+			if errors.As(err, &flatNumbersTakenErr) {
+				log.Info("flat numbers are already taken", slog.Any("flat_numbers", flatNumbersTakenErr.FlatNumbers))
+
+				response.Errors = takenNumberErrors(rows, flatNumbersTakenErr.FlatNumbers)
+				render.Status(r, http.StatusUnprocessableEntity)
+				render.JSON(w, r, response)
+				return
+			}
+
+			log.Error("failed to import flats", sl.Err(err))
+
+			w.Header().Set("Retry-After", "60")
+			w.WriteHeader(http.StatusInternalServerError)
+
+			response := models.InternalServerErrorResponse{
+				Message:   err.Error(),
+				RequestID: middleware.GetReqID(ctx),
+				Code:      12345,
+			}
+			render.JSON(w, r, response)
+			return
+		}
+
+		if !dryRun {
+			response.Imported = len(createdFlats)
+			metrics.FlatsCreated.WithLabelValues(metrics.SourceImport).Add(float64(len(createdFlats)))
+		}
+
+		render.JSON(w, r, response)
+		log.Info("request handled successfully",
+			slog.Int64("house_id", houseID),
+			slog.Int("rows", total),
+			slog.Bool("dry_run", dryRun),
+		)
+	}
+}
+
+// csvColumnParsers разбирают значения колонок CSV. Пустое значение оставляет поле незаполненным.
+var csvColumnParsers = map[string]func(row *handlers.ImportFlatRow, value string) error{
+	"price": func(row *handlers.ImportFlatRow, value string) (err error) {
+		row.Price, err = strconv.ParseInt(value, 10, 64)
+		return err
+	},
+	"rooms": func(row *handlers.ImportFlatRow, value string) (err error) {
+		row.Rooms, err = strconv.ParseInt(value, 10, 64)
+		return err
+	},
+	"flat_number": func(row *handlers.ImportFlatRow, value string) (err error) {
+		row.FlatNumber, err = parseInt64Cell(value)
+		return err
+	},
+	"total_area": func(row *handlers.ImportFlatRow, value string) (err error) {
+		row.TotalArea, err = parseFloat64Cell(value)
+		return err
+	},
+	"living_area": func(row *handlers.ImportFlatRow, value string) (err error) {
+		row.LivingArea, err = parseFloat64Cell(value)
+		return err
+	},
+	"floor": func(row *handlers.ImportFlatRow, value string) (err error) {
+		row.Floor, err = parseInt64Cell(value)
+		return err
+	},
+	"total_floors": func(row *handlers.ImportFlatRow, value string) (err error) {
+		row.TotalFloors, err = parseInt64Cell(value)
+		return err
+	},
+	"layout": func(row *handlers.ImportFlatRow, value string) error {
+		layout := handlers.FlatLayout(value)
+		row.Layout = &layout
+		return nil
+	},
+	"has_balcony": func(row *handlers.ImportFlatRow, value string) error {
+		hasBalcony, err := strconv.ParseBool(value)
+		if err != nil {
+			return err
+		}
+		row.HasBalcony = &hasBalcony
+		return nil
+	},
+	"description": func(row *handlers.ImportFlatRow, value string) error {
+		row.Description = &value
+		return nil
+	},
+}
+
+// parseCSVRows разбирает CSV с заголовком, названия колонок совпадают с полями JSON
+func parseCSVRows(body io.Reader, maxRows int) ([]importedRow, []handlers.ImportRowError, error) {
+	reader := csv.NewReader(body)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, wrapCSVError(err)
+	}
+
+	columns := make([]string, len(header))
+	seen := make(map[string]bool, len(header))
+	for i, column := range header {
+		column = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(column, "\ufeff")))
+		if _, ok := csvColumnParsers[column]; !ok {
+			return nil, nil, &importFileError{status: http.StatusBadRequest, message: fmt.Sprintf("unknown column %q", column)}
+		}
+		if seen[column] {
+			return nil, nil, &importFileError{status: http.StatusBadRequest, message: fmt.Sprintf("duplicate column %q", column)}
+		}
+		seen[column] = true
+		columns[i] = column
+	}
+
+	for _, required := range []string{"price", "rooms"} {
+		if !seen[required] {
+			return nil, nil, &importFileError{status: http.StatusBadRequest, message: fmt.Sprintf("missing column %q", required)}
+		}
+	}
+
+	var rows []importedRow
+	var rowErrors []handlers.ImportRowError
+
+	for num := 1; ; num++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if num > maxRows {
+			return nil, nil, tooManyRowsError(maxRows)
+		}
+		if errors.Is(err, csv.ErrFieldCount) {
+			rowErrors = append(rowErrors, handlers.ImportRowError{
+				Row:     num,
+				Message: fmt.Sprintf("expected %d fields, got %d", len(columns), len(record)),
+			})
+			continue
+		}
+		if err != nil {
+			return nil, nil, wrapCSVError(err)
+		}
+
+		var row handlers.ImportFlatRow
+		valid := true
+
+		for i, value := range record {
+			value = strings.TrimSpace(value)
+			if value == "" {
+				continue
+			}
+
+			if err := csvColumnParsers[columns[i]](&row, value); err != nil {
+				rowErrors = append(rowErrors, handlers.ImportRowError{Row: num, Field: columns[i], Message: "invalid value"})
+				valid = false
+			}
+		}
+
+		if valid {
+			rows = append(rows, importedRow{num: num, row: row})
+		}
+	}
+
+	return rows, rowErrors, nil
+}
+
+// parseNDJSONRows разбирает NDJSON: один объект квартиры на строку, пустые строки пропускаются
+func parseNDJSONRows(body io.Reader, maxRows int) ([]importedRow, []handlers.ImportRowError, error) {
+	scanner := bufio.NewScanner(body)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxNDJSONLineSize)
+
+	var rows []importedRow
+	var rowErrors []handlers.ImportRowError
+
+	num := 0
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		num++
+		if num > maxRows {
+			return nil, nil, tooManyRowsError(maxRows)
+		}
+
+		decoder := json.NewDecoder(bytes.NewReader(line))
+		decoder.DisallowUnknownFields()
+
+		var row handlers.ImportFlatRow
+		if err := decoder.Decode(&row); err != nil {
+			rowErrors = append(rowErrors, handlers.ImportRowError{Row: num, Message: "invalid json: " + err.Error()})
+			continue
+		}
+
+		rows = append(rows, importedRow{num: num, row: row})
+	}
+
+	if err := scanner.Err(); err != nil {
+		if errors.Is(err, bufio.ErrTooLong) {
+			return nil, nil, &importFileError{
+				status:  http.StatusBadRequest,
+				message: fmt.Sprintf("line after row %d is longer than %d bytes", num, maxNDJSONLineSize),
+			}
+		}
+		return nil, nil, err
+	}
+
+	return rows, rowErrors, nil
+}
+
+// validateImportRows проверяет строки правилами validator и ищет повторяющиеся номера квартир внутри файла
+func validateImportRows(rowValidator *validator.Validate, rows []importedRow) []handlers.ImportRowError {
+	var rowErrors []handlers.ImportRowError
+
+	flatNumberRows := make(map[int64]int, len(rows))
+
+	for _, row := range rows {
+		if row.row.FlatNumber != nil {
+			if firstRow, ok := flatNumberRows[*row.row.FlatNumber]; ok {
+				rowErrors = append(rowErrors, handlers.ImportRowError{
+					Row:     row.num,
+					Field:   "flat_number",
+					Message: fmt.Sprintf("flat number is already used in row %d", firstRow),
+				})
+			} else {
+				flatNumberRows[*row.row.FlatNumber] = row.num
+			}
+		}
+
+		err := rowValidator.Struct(row.row)
+		if err == nil {
+			continue
+		}
+
+		var validationErrs validator.ValidationErrors
+		if !errors.As(err, &validationErrs) {
+			rowErrors = append(rowErrors, handlers.ImportRowError{Row: row.num, Message: err.Error()})
+			continue
+		}
+
+		for _, fieldErr := range validationErrs {
+			rowErrors = append(rowErrors, handlers.ImportRowError{
+				Row:     row.num,
+				Field:   fieldErr.Field(),
+				Message: fmt.Sprintf("failed on the '%s' rule", fieldErr.Tag()),
+			})
+		}
+	}
+
+	return rowErrors
+}
+
+// takenNumberErrors сопоставляет номера, уже занятые в доме, со строками файла
+func takenNumberErrors(rows []importedRow, takenNumbers []int64) []handlers.ImportRowError {
+	taken := make(map[int64]bool, len(takenNumbers))
+	for _, number := range takenNumbers {
+		taken[number] = true
+	}
+
+	rowErrors := []handlers.ImportRowError{}
+	for _, row := range rows {
+		if row.row.FlatNumber != nil && taken[*row.row.FlatNumber] {
+			rowErrors = append(rowErrors, handlers.ImportRowError{
+				Row:     row.num,
+				Field:   "flat_number",
+				Message: "flat number is already taken in the house",
+			})
+		}
+	}
+
+	return rowErrors
+}
+
+// sortRowErrors упорядочивает ошибки по номеру строки, сохраняя порядок ошибок внутри строки
+func sortRowErrors(rowErrors []handlers.ImportRowError) []handlers.ImportRowError {
+	sort.SliceStable(rowErrors, func(i, j int) bool {
+		return rowErrors[i].Row < rowErrors[j].Row
+	})
+
+	return rowErrors
+}
+
+// newImportRowValidator возвращает validator, который называет поля в ошибках так же, как в файле
+func newImportRowValidator() *validator.Validate {
+	rowValidator := validator.New()
+	rowValidator.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		return name
+	})
+
+	return rowValidator
+}
+
+func parseInt64Cell(value string) (*int64, error) {
+	number, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return nil, err
+	}
+	return &number, nil
+}
+
+func parseFloat64Cell(value string) (*float64, error) {
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return nil, err
+	}
+	return &number, nil
+}
+
+func tooManyRowsError(maxRows int) error {
+	return &importFileError{
+		status:  http.StatusRequestEntityTooLarge,
+		message: fmt.Sprintf("import file has more than %d rows", maxRows),
+	}
+}
+
+// wrapCSVError превращает синтаксическую ошибку CSV в ошибку файла с номером строки,
+// ошибки чтения тела запроса возвращаются как есть
+func wrapCSVError(err error) error {
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		return &importFileError{status: http.StatusBadRequest, message: parseErr.Error()}
+	}
+	return err
+}
@@ -0,0 +1,175 @@
+package house
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"realty-avito/internal/client/db"
+	"realty-avito/internal/config"
+	repo_errors "realty-avito/internal/errors"
+	"realty-avito/internal/http-server/handlers"
+	"realty-avito/internal/lib/logger"
+	"realty-avito/internal/repositories/flatsRepo"
+	"realty-avito/internal/repositories/flatsRepo/mocks"
+	houseMocks "realty-avito/internal/repositories/housesRepo/mocks"
+)
+
+// noTxManager выполняет обработчик без транзакции
+type noTxManager struct{}
+
+func (noTxManager) ReadCommitted(ctx context.Context, f db.Handler) error {
+	return f(ctx)
+}
+
+func TestImportFlatsHandler(t *testing.T) {
+	cfg := config.ImportConfig{MaxRows: 3, MaxFileSize: 1 << 20}
+
+	tests := []struct {
+		name               string
+		query              string
+		contentType        string
+		body               string
+		prepareMock        func(flats *mocks.FlatsRepository, houses *houseMocks.HousesRepository)
+		expectedStatusCode int
+		expectedImported   int
+		expectedErrors     []handlers.ImportRowError
+	}{
+		{
+			name:        "csv imported",
+			contentType: "text/csv; charset=utf-8",
+			body:        "price,rooms,flat_number,layout\n5000000,2,12,isolated\n3000000,1,,studio\n",
+			prepareMock: func(flats *mocks.FlatsRepository, houses *houseMocks.HousesRepository) {
+				houses.On("UpdateHouseUpdatedAt", mock.Anything, int64(1)).Return(nil).Once()
+				flats.On("ImportFlats", mock.Anything, int64(1), mock.MatchedBy(func(entities []flatsRepo.CreateFlatEntity) bool {
+					return len(entities) == 2 && *entities[0].FlatNumber == 12 && entities[1].FlatNumber == nil &&
+						*entities[1].Layout == flatsRepo.LayoutStudio
+				})).Return([]flatsRepo.FlatEntity{{ID: 1}, {ID: 2}}, nil).Once()
+			},
+			expectedStatusCode: http.StatusOK,
+			expectedImported:   2,
+		},
+		{
+			name:               "invalid csv rows are reported and nothing is written",
+			contentType:        "text/csv",
+			body:               "price,rooms,flat_number\n5000000,0,12\nabc,1,13\n100,1,12\n",
+			prepareMock:        func(flats *mocks.FlatsRepository, houses *houseMocks.HousesRepository) {},
+			expectedStatusCode: http.StatusUnprocessableEntity,
+			expectedErrors: []handlers.ImportRowError{
+				{Row: 1, Field: "rooms", Message: "failed on the 'required' rule"},
+				{Row: 2, Field: "price", Message: "invalid value"},
+				{Row: 3, Field: "flat_number", Message: "flat number is already used in row 1"},
+			},
+		},
+		{
+			name:               "too many rows",
+			contentType:        "text/csv",
+			body:               "price,rooms\n100,1\n100,1\n100,1\n100,1\n",
+			prepareMock:        func(flats *mocks.FlatsRepository, houses *houseMocks.HousesRepository) {},
+			expectedStatusCode: http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:        "validation errors by row",
+			contentType: "application/x-ndjson",
+			body: `{"price": 5000000, "rooms": 0, "flat_number": 12}
+{"price": 100, "rooms": 1, "flat_number": 13, "floor": 10, "total_floors": 5}
+
+{"price": 100, "rooms": 1, "color": "red"}
+`,
+			prepareMock:        func(flats *mocks.FlatsRepository, houses *houseMocks.HousesRepository) {},
+			expectedStatusCode: http.StatusUnprocessableEntity,
+			expectedErrors: []handlers.ImportRowError{
+				{Row: 1, Field: "rooms", Message: "failed on the 'required' rule"},
+				{Row: 2, Field: "floor", Message: "failed on the 'ltefield' rule"},
+				{Row: 3, Message: `invalid json: json: unknown field "color"`},
+			},
+		},
+		{
+			name:        "dry run rolls back",
+			query:       "?dry_run=true",
+			contentType: "application/x-ndjson",
+			body:        `{"price": 5000000, "rooms": 2}`,
+			prepareMock: func(flats *mocks.FlatsRepository, houses *houseMocks.HousesRepository) {
+				houses.On("UpdateHouseUpdatedAt", mock.Anything, int64(1)).Return(nil).Once()
+				flats.On("ImportFlats", mock.Anything, int64(1), mock.Anything).Return([]flatsRepo.FlatEntity{{ID: 1}}, nil).Once()
+			},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name:        "flat numbers taken in house",
+			contentType: "text/csv",
+			body:        "price,rooms,flat_number\n100,1,1\n100,1,2\n",
+			prepareMock: func(flats *mocks.FlatsRepository, houses *houseMocks.HousesRepository) {
+				houses.On("UpdateHouseUpdatedAt", mock.Anything, int64(1)).Return(nil).Once()
+				flats.On("ImportFlats", mock.Anything, int64(1), mock.Anything).
+					Return(nil, &repo_errors.ErrFlatNumbersTaken{HouseID: 1, FlatNumbers: []int64{2}}).Once()
+			},
+			expectedStatusCode: http.StatusUnprocessableEntity,
+			expectedErrors: []handlers.ImportRowError{
+				{Row: 2, Field: "flat_number", Message: "flat number is already taken in the house"},
+			},
+		},
+		{
+			name:        "house not found",
+			contentType: "text/csv",
+			body:        "price,rooms\n100,1\n",
+			prepareMock: func(flats *mocks.FlatsRepository, houses *houseMocks.HousesRepository) {
+				houses.On("UpdateHouseUpdatedAt", mock.Anything, int64(1)).Return(&repo_errors.ErrHouseNotFound{HouseID: 1}).Once()
+			},
+			expectedStatusCode: http.StatusNotFound,
+		},
+		{
+			name:               "unknown csv column",
+			contentType:        "text/csv",
+			body:               "price,rooms,color\n100,1,red\n",
+			prepareMock:        func(flats *mocks.FlatsRepository, houses *houseMocks.HousesRepository) {},
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		{
+			name:               "unsupported content type",
+			contentType:        "application/json",
+			body:               `[{"price": 100, "rooms": 1}]`,
+			prepareMock:        func(flats *mocks.FlatsRepository, houses *houseMocks.HousesRepository) {},
+			expectedStatusCode: http.StatusUnsupportedMediaType,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockFlatsRepo := new(mocks.FlatsRepository)
+			mockHousesRepo := new(houseMocks.HousesRepository)
+			tt.prepareMock(mockFlatsRepo, mockHousesRepo)
+
+			r := chi.NewRouter()
+			r.Post("/house/{id}/flats/import", ImportFlatsHandler(logger.SetupLogger("local"), mockFlatsRepo, mockHousesRepo, noTxManager{}, cfg))
+
+			req := httptest.NewRequest(http.MethodPost, "/house/1/flats/import"+tt.query, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", tt.contentType)
+
+			rr := httptest.NewRecorder()
+			r.ServeHTTP(rr, req)
+
+			require.Equal(t, tt.expectedStatusCode, rr.Code)
+
+			if tt.expectedStatusCode == http.StatusOK || tt.expectedStatusCode == http.StatusUnprocessableEntity {
+				var response handlers.ImportFlatsResponse
+				require.NoError(t, json.Unmarshal(rr.Body.Bytes(), &response))
+				require.Equal(t, tt.expectedImported, response.Imported)
+
+				if tt.expectedErrors != nil {
+					require.Equal(t, tt.expectedErrors, response.Errors)
+				}
+			}
+
+			mockFlatsRepo.AssertExpectations(t)
+			mockHousesRepo.AssertExpectations(t)
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package house
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+
+	"realty-avito/internal/converter"
+	repo_errors "realty-avito/internal/errors"
+	"realty-avito/internal/lib/etag"
+	"realty-avito/internal/lib/logger/sl"
+	"realty-avito/internal/models"
+	"realty-avito/internal/repositories/housesRepo"
+)
+
+type HouseGetter interface {
+	GetHouseByID(ctx context.Context, houseID int64) (*housesRepo.HouseEntity, error)
+}
+
+func GetHouseHandler(log *slog.Logger, houseGetter HouseGetter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.house.info"
+
+		ctx := r.Context()
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(ctx)),
+		)
+
+		var houseIDStr = chi.URLParam(r, "id")
+		houseID, err := strconv.ParseInt(houseIDStr, 10, 64)
+		if err != nil || houseID < 1 {
+			log.Error("invalid house ID", slog.String("house_id", houseIDStr))
+			http.Error(w, "Invalid house ID", http.StatusBadRequest)
+			return
+		}
+
+		house, err := houseGetter.GetHouseByID(ctx, houseID)
+		if err != nil {
+			var houseNotFoundErr *repo_errors.ErrHouseNotFound
+			if errors.As(err, &houseNotFoundErr) {
+				log.Info("house not found", slog.Int64("house_id", houseID))
+				http.Error(w, houseNotFoundErr.Error(), http.StatusNotFound)
+				return
+			}
+
+			log.Error("failed to get house", sl.Err(err))
+
+			w.Header().Set("Retry-After", "60")
+			w.WriteHeader(http.StatusInternalServerError)
+
+			response := models.InternalServerErrorResponse{
+				Message:   err.Error(),
+				RequestID: middleware.GetReqID(ctx),
+				Code:      12345,
+			}
+			render.JSON(w, r, response)
+			return
+		}
+
+		houseETag := etag.FromVersion(house.Version)
+		w.Header().Set("ETag", houseETag)
+
+		if etag.NoneMatch(r.Header.Get("If-None-Match"), houseETag) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+
+		render.JSON(w, r, converter.ConvertHouseEntityToHouse(*house))
+		log.Info("request handled successfully", slog.Int64("house_id", houseID))
+	}
+}
@@ -0,0 +1,141 @@
+package house
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/url"
+	"strconv"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+
+	"realty-avito/internal/converter"
+	"realty-avito/internal/http-server/handlers"
+	"realty-avito/internal/lib/cursor"
+	"realty-avito/internal/lib/logger/sl"
+	"realty-avito/internal/models"
+	"realty-avito/internal/repositories/housesRepo"
+)
+
+const (
+	defaultHousesLimit = 20
+	maxHousesLimit     = 100
+)
+
+type HousesLister interface {
+	ListHouses(ctx context.Context, filter housesRepo.ListHousesFilter) ([]housesRepo.HouseEntity, error)
+}
+
+type ListResponse struct {
+	Houses     []handlers.House `json:"houses"`
+	NextCursor string           `json:"next_cursor,omitempty"`
+}
+
+func ListHousesHandler(log *slog.Logger, housesLister HousesLister) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.house.list"
+
+		ctx := r.Context()
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(ctx)),
+		)
+
+		filter, err := parseListHousesFilter(r.URL.Query())
+		if err != nil {
+			log.Error("invalid list parameters", sl.Err(err))
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		houseEntities, err := housesLister.ListHouses(ctx, filter)
+		if err != nil {
+			log.Error("failed to list houses", sl.Err(err))
+
+			w.Header().Set("Retry-After", "60")
+			w.WriteHeader(http.StatusInternalServerError)
+
+			response := models.InternalServerErrorResponse{
+				Message:   err.Error(),
+				RequestID: middleware.GetReqID(ctx),
+				Code:      12345,
+			}
+			render.JSON(w, r, response)
+			return
+		}
+
+		response := ListResponse{
+			Houses: converter.ConvertHouseEntitiesToHouses(houseEntities),
+		}
+
+		// полная страница - возможно, есть еще дома
+		if uint64(len(houseEntities)) == filter.Limit {
+			response.NextCursor = cursor.Encode(cursor.Cursor{ID: houseEntities[len(houseEntities)-1].ID})
+		}
+
+		render.JSON(w, r, response)
+		log.Info("request handled successfully", slog.Int("count", len(houseEntities)))
+	}
+}
+
+func parseListHousesFilter(query url.Values) (housesRepo.ListHousesFilter, error) {
+	filter := housesRepo.ListHousesFilter{
+		Limit: defaultHousesLimit,
+	}
+
+	if developer := query.Get("developer"); developer != "" {
+		filter.Developer = &developer
+	}
+
+	if address := query.Get("address"); address != "" {
+		filter.Address = &address
+	}
+
+	var err error
+
+	if filter.MinYear, err = parseOptionalYear(query, "min_year"); err != nil {
+		return filter, err
+	}
+	if filter.MaxYear, err = parseOptionalYear(query, "max_year"); err != nil {
+		return filter, err
+	}
+
+	if filter.MinYear != nil && filter.MaxYear != nil && *filter.MinYear > *filter.MaxYear {
+		return filter, errors.New("min_year must not be greater than max_year")
+	}
+
+	if limitStr := query.Get("limit"); limitStr != "" {
+		limit, err := strconv.ParseUint(limitStr, 10, 64)
+		if err != nil || limit < 1 || limit > maxHousesLimit {
+			return filter, errors.New("limit must be between 1 and 100")
+		}
+		filter.Limit = limit
+	}
+
+	c, err := cursor.Decode(query.Get("cursor"))
+	if err != nil {
+		return filter, err
+	}
+	if c != nil {
+		filter.AfterID = c.ID
+	}
+
+	return filter, nil
+}
+
+func parseOptionalYear(query url.Values, name string) (*int, error) {
+	raw := query.Get(name)
+	if raw == "" {
+		return nil, nil
+	}
+
+	year, err := strconv.Atoi(raw)
+	if err != nil || year < 1 {
+		return nil, errors.New("invalid " + name)
+	}
+
+	return &year, nil
+}
@@ -0,0 +1,95 @@
+package house
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"golang.org/x/exp/slog"
+
+	"realty-avito/internal/converter"
+	repo_errors "realty-avito/internal/errors"
+	"realty-avito/internal/http-server/handlers"
+	"realty-avito/internal/lib/logger/sl"
+	"realty-avito/internal/models"
+	"realty-avito/internal/repositories/subscriptionsRepo"
+)
+
+type SubscriptionsWriter interface {
+	CreateSubscription(ctx context.Context, createSubscriptionEntity subscriptionsRepo.CreateSubscriptionEntity) (*subscriptionsRepo.SubscriptionEntity, error)
+}
+
+func SubscribeHandler(log *slog.Logger, subscriptionsWriter SubscriptionsWriter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.house.subscribe"
+
+		ctx := r.Context()
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(ctx)),
+		)
+
+		var houseIDStr = chi.URLParam(r, "id")
+		houseID, err := strconv.ParseInt(houseIDStr, 10, 64)
+		if err != nil || houseID < 1 {
+			log.Error("invalid house ID", slog.String("house_id", houseIDStr))
+			http.Error(w, "Invalid house ID", http.StatusBadRequest)
+			return
+		}
+
+		var req handlers.SubscribeRequest
+
+		err = render.DecodeJSON(r.Body, &req)
+		if errors.Is(err, io.EOF) {
+			log.Error("request body is empty")
+			http.Error(w, "request body is empty", http.StatusBadRequest)
+			return
+		}
+
+		if err != nil {
+			log.Error("failed to decode request body", sl.Err(err))
+			http.Error(w, "failed to decode request body", http.StatusBadRequest)
+			return
+		}
+
+		if err := validator.New().Struct(req); err != nil {
+			log.Error("failed to validate request body", sl.Err(err))
+			http.Error(w, "failed to validate request body", http.StatusBadRequest)
+			return
+		}
+
+		subscription, err := subscriptionsWriter.CreateSubscription(ctx, converter.ConvertSubscribeRequestToEntity(houseID, req))
+		if err != nil {
+			log.Error("failed to create subscription", sl.Err(err))
+
+			var houseNotFoundErr *repo_errors.ErrHouseNotFound
+			if errors.As(err, &houseNotFoundErr) {
+				errorDescription := fmt.Sprintf("Cannot subscribe: %s", houseNotFoundErr.Error())
+				http.Error(w, errorDescription, http.StatusNotFound)
+				return
+			}
+
+			w.Header().Set("Retry-After", "60")
+			w.WriteHeader(http.StatusInternalServerError)
+
+			response := models.InternalServerErrorResponse{
+				Message:   err.Error(),
+				RequestID: middleware.GetReqID(ctx),
+				Code:      12345,
+			}
+			render.JSON(w, r, response)
+			return
+		}
+
+		render.JSON(w, r, converter.ConvertSubscriptionEntityToResponse(subscription))
+		log.Info("subscription created", slog.Int64("house_id", houseID))
+	}
+}
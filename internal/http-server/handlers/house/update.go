@@ -0,0 +1,120 @@
+package house
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"golang.org/x/exp/slog"
+
+	"realty-avito/internal/converter"
+	repo_errors "realty-avito/internal/errors"
+	"realty-avito/internal/http-server/handlers"
+	"realty-avito/internal/lib/etag"
+	"realty-avito/internal/lib/logger/sl"
+	"realty-avito/internal/models"
+	"realty-avito/internal/repositories/housesRepo"
+)
+
+type HouseUpdater interface {
+	UpdateHouse(ctx context.Context, updateHouseEntity housesRepo.UpdateHouseEntity) (*housesRepo.HouseEntity, error)
+}
+
+// UpdateHouseHandler частично обновляет дом: меняются только переданные поля.
+// С заголовком If-Match дом обновляется, только если не менялся с момента чтения, иначе возвращается 412.
+func UpdateHouseHandler(log *slog.Logger, houseUpdater HouseUpdater) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.house.update"
+
+		ctx := r.Context()
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(ctx)),
+		)
+
+		var houseIDStr = chi.URLParam(r, "id")
+		houseID, err := strconv.ParseInt(houseIDStr, 10, 64)
+		if err != nil || houseID < 1 {
+			log.Error("invalid house ID", slog.String("house_id", houseIDStr))
+			http.Error(w, "Invalid house ID", http.StatusBadRequest)
+			return
+		}
+
+		var req handlers.UpdateHouseRequest
+
+		err = render.DecodeJSON(r.Body, &req)
+		if errors.Is(err, io.EOF) {
+			log.Error("request body is empty")
+			http.Error(w, "request body is empty", http.StatusBadRequest)
+			return
+		}
+
+		if err != nil {
+			log.Error("failed to decode request body", sl.Err(err))
+			http.Error(w, "failed to decode request body", http.StatusBadRequest)
+			return
+		}
+
+		if err := validator.New().Struct(req); err != nil {
+			log.Error("failed to validate request body", sl.Err(err))
+			http.Error(w, "failed to validate request body", http.StatusBadRequest)
+			return
+		}
+
+		if req.Address == nil && req.Year == nil && req.Developer == nil {
+			log.Error("nothing to update")
+			http.Error(w, "at least one of address, year or developer is required", http.StatusBadRequest)
+			return
+		}
+
+		expectedVersion, err := etag.ParseIfMatch(r.Header.Get("If-Match"))
+		if err != nil {
+			log.Error("invalid If-Match", sl.Err(err))
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		entityToUpdate := converter.ConvertUpdateHouseRequestToEntity(houseID, req)
+		entityToUpdate.ExpectedVersion = expectedVersion
+
+		house, err := houseUpdater.UpdateHouse(ctx, entityToUpdate)
+		if err != nil {
+			if errors.Is(err, repo_errors.ErrVersionMismatch) {
+				log.Info("house version mismatch", slog.Int64("house_id", houseID))
+				http.Error(w, "house was modified, fetch it again and retry", http.StatusPreconditionFailed)
+				return
+			}
+
+			var houseNotFoundErr *repo_errors.ErrHouseNotFound
+			if errors.As(err, &houseNotFoundErr) {
+				log.Info("house not found", slog.Int64("house_id", houseID))
+				http.Error(w, houseNotFoundErr.Error(), http.StatusNotFound)
+				return
+			}
+
+			log.Error("failed to update house", sl.Err(err))
+
+			w.Header().Set("Retry-After", "60")
+			w.WriteHeader(http.StatusInternalServerError)
+
+			response := models.InternalServerErrorResponse{
+				Message:   err.Error(),
+				RequestID: middleware.GetReqID(ctx),
+				Code:      12345,
+			}
+			render.JSON(w, r, response)
+			return
+		}
+
+		w.Header().Set("ETag", etag.FromVersion(house.Version))
+		render.JSON(w, r, converter.ConvertHouseEntityToHouse(*house))
+		log.Info("house updated", slog.Int64("house_id", houseID))
+	}
+}
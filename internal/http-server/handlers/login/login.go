@@ -2,7 +2,6 @@ package login
 
 import (
 	"errors"
-	"fmt"
 	"io"
 	"net/http"
 
@@ -10,9 +9,11 @@ import (
 	"github.com/go-chi/render"
 	"github.com/go-playground/validator/v10"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
 	"golang.org/x/exp/slog"
 
+	"realty-avito/internal/auth"
 	"realty-avito/internal/converter"
 	"realty-avito/internal/http-server/handlers"
 	"realty-avito/internal/lib/logger/sl"
@@ -20,14 +21,12 @@ import (
 	"realty-avito/internal/repositories/usersRepo"
 )
 
-var jwtKey = []byte("jwt_most_secret_key")
-
-type Claims struct {
-	UserType string `json:"user_type"`
-	jwt.RegisteredClaims
-}
-
-func LoginHandler(log *slog.Logger, usersRepository usersRepo.UserRepository) http.HandlerFunc {
+func LoginHandler(
+	log *slog.Logger,
+	usersRepository usersRepo.UserRepository,
+	tokens auth.Issuer,
+	refreshTokens auth.RefreshTokenWriter,
+) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		const op = "handlers.LoginHandler"
 
@@ -121,7 +120,13 @@ func LoginHandler(log *slog.Logger, usersRepository usersRepo.UserRepository) ht
 			return
 		}
 
-		token, err := generateJWT(user.UserType, user.ID)
+		claims := auth.Claims{
+			UserType:         user.UserType,
+			AuthMethod:       auth.AuthMethodPassword,
+			RegisteredClaims: jwt.RegisteredClaims{Subject: user.UUID},
+		}
+
+		token, refreshToken, err := auth.IssueTokenPair(ctx, tokens, refreshTokens, claims, uuid.New().String())
 		if err != nil {
 			log.Error("failed to create token", slog.String("op", op), slog.StringValue(err.Error()))
 
@@ -139,7 +144,8 @@ func LoginHandler(log *slog.Logger, usersRepository usersRepo.UserRepository) ht
 		}
 
 		loginResponse := handlers.LoginResponse{
-			Token: token,
+			Token:        token,
+			RefreshToken: refreshToken,
 		}
 
 		if err := validator.New().Struct(loginResponse); err != nil {
@@ -151,13 +157,3 @@ func LoginHandler(log *slog.Logger, usersRepository usersRepo.UserRepository) ht
 		render.JSON(w, r, loginResponse)
 	}
 }
-
-func generateJWT(userType string, userID int64) (string, error) {
-	claims := &Claims{
-		UserType:         userType,
-		RegisteredClaims: jwt.RegisteredClaims{ID: fmt.Sprintf("%d", userID)},
-	}
-
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(jwtKey)
-}
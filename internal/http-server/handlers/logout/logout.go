@@ -0,0 +1,91 @@
+package logout
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+
+	"realty-avito/internal/auth"
+	"realty-avito/internal/http-server/handlers"
+	"realty-avito/internal/lib/logger/sl"
+	"realty-avito/internal/models"
+	"realty-avito/internal/repositories/tokensRepo"
+)
+
+type TokensRevoker interface {
+	GetRefreshTokenByHash(ctx context.Context, tokenHash string) (*tokensRepo.RefreshTokenEntity, error)
+	RevokeRefreshTokenFamily(ctx context.Context, familyID string) error
+	RevokeAccessToken(ctx context.Context, jti string, ttl time.Duration) error
+}
+
+// LogoutHandler отзывает текущий access-токен и, если он передан, refresh-токен вместе со всей его сессией
+func LogoutHandler(log *slog.Logger, tokens TokensRevoker) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.LogoutHandler"
+
+		ctx := r.Context()
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(ctx)),
+		)
+
+		principal, ok := auth.PrincipalFromContext(ctx)
+		if !ok {
+			log.Error("principal not found in context")
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		var req handlers.LogoutRequest
+
+		err := render.DecodeJSON(r.Body, &req)
+		if err != nil && !errors.Is(err, io.EOF) {
+			log.Error("failed to decode request body", sl.Err(err))
+			http.Error(w, "failed to decode request body", http.StatusBadRequest)
+			return
+		}
+
+		err = revoke(ctx, tokens, principal, req.RefreshToken)
+		if err != nil {
+			log.Error("failed to logout", sl.Err(err))
+
+			w.Header().Set("Retry-After", "60")
+			w.WriteHeader(http.StatusInternalServerError)
+
+			render.JSON(w, r, models.InternalServerErrorResponse{
+				Message:   "failed to logout",
+				RequestID: middleware.GetReqID(ctx),
+				Code:      12345,
+			})
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+		log.Info("user logged out", slog.String("jti", principal.TokenID))
+	}
+}
+
+func revoke(ctx context.Context, tokens TokensRevoker, principal auth.Principal, refreshToken string) error {
+	if refreshToken != "" {
+		stored, err := tokens.GetRefreshTokenByHash(ctx, auth.HashRefreshToken(refreshToken))
+		if err != nil && !errors.Is(err, tokensRepo.ErrRefreshTokenNotFound) {
+			return err
+		}
+
+		// чужой refresh-токен отозвать нельзя
+		if stored != nil && stored.Subject == principal.Subject {
+			if err := tokens.RevokeRefreshTokenFamily(ctx, stored.FamilyID); err != nil {
+				return err
+			}
+		}
+	}
+
+	return tokens.RevokeAccessToken(ctx, principal.TokenID, time.Until(principal.ExpiresAt))
+}
@@ -3,17 +3,35 @@ package handlers
 import "time"
 
 type CreateFlatRequest struct {
-	HouseID int64 `json:"house_id" validate:"required,min=1"`
-	Price   int64 `json:"price" validate:"required,min=0"`
-	Rooms   int64 `json:"rooms" validate:"required,min=1"`
+	HouseID    int64  `json:"house_id" validate:"required,min=1"`
+	Price      int64  `json:"price" validate:"required,min=0"`
+	Rooms      int64  `json:"rooms" validate:"required,min=1"`
+	FlatNumber *int64 `json:"flat_number,omitempty" validate:"omitempty,min=1"`
+	FlatAttributes
 }
 
+// FlatAttributes необязательные характеристики квартиры
+type FlatAttributes struct {
+	TotalArea   *float64    `json:"total_area,omitempty" validate:"omitempty,gt=0,lte=10000"`
+	LivingArea  *float64    `json:"living_area,omitempty" validate:"omitempty,gt=0,ltefield=TotalArea"`
+	Floor       *int64      `json:"floor,omitempty" validate:"omitempty,min=1,ltefield=TotalFloors"`
+	TotalFloors *int64      `json:"total_floors,omitempty" validate:"omitempty,min=1,max=200"`
+	Layout      *FlatLayout `json:"layout,omitempty" validate:"omitempty,oneof=studio isolated adjoining open"`
+	HasBalcony  *bool       `json:"has_balcony,omitempty"`
+	Description *string     `json:"description,omitempty" validate:"omitempty,max=2000"`
+}
+
+type FlatLayout string
+
 type CreateFlatResponse struct {
-	ID      int64                `json:"id" validate:"required,min=1"`
-	HouseID int64                `json:"house_id" validate:"required,min=1"`
-	Price   int64                `json:"price" validate:"required,min=0"`
-	Rooms   int64                `json:"rooms" validate:"required,min=1"`
-	Status  FlatModerationStatus `json:"status" validate:"required,oneof='created' 'approved' 'declined' 'on moderation'"`
+	ID         int64                `json:"id" validate:"required,min=1"`
+	HouseID    int64                `json:"house_id" validate:"required,min=1"`
+	Price      int64                `json:"price" validate:"required,min=0"`
+	Rooms      int64                `json:"rooms" validate:"required,min=1"`
+	FlatNumber *int64               `json:"flat_number,omitempty"`
+	Status     FlatModerationStatus `json:"status" validate:"required,oneof='created' 'approved' 'declined' 'on moderation'"`
+	FlatAttributes
+	Version int64 `json:"version"`
 }
 
 type UpdateFlatRequest struct {
@@ -22,11 +40,60 @@ type UpdateFlatRequest struct {
 }
 
 type UpdateFlatResponse struct {
-	ID      int64                `json:"id"`
-	HouseID int64                `json:"house_id"`
-	Price   int64                `json:"price"`
-	Rooms   int64                `json:"rooms"`
-	Status  FlatModerationStatus `json:"status"`
+	ID         int64                `json:"id"`
+	HouseID    int64                `json:"house_id"`
+	Price      int64                `json:"price"`
+	Rooms      int64                `json:"rooms"`
+	FlatNumber *int64               `json:"flat_number,omitempty"`
+	Status     FlatModerationStatus `json:"status"`
+	FlatAttributes
+	Version int64 `json:"version"`
+}
+
+type ChangePriceRequest struct {
+	Price int64 `json:"price" validate:"required,min=0"`
+}
+
+// FlatHistoryRecord состояние квартиры после изменения цены или статуса.
+// ChangedBy - uuid пользователя, сделавшего изменение, отдается только модераторам.
+type FlatHistoryRecord struct {
+	ChangeType string               `json:"change_type"`
+	Price      int64                `json:"price"`
+	Status     FlatModerationStatus `json:"status"`
+	ChangedBy  *string              `json:"changed_by,omitempty"`
+	ChangedAt  time.Time            `json:"changed_at"`
+}
+
+type FlatHistoryResponse struct {
+	FlatID  int64               `json:"flat_id"`
+	History []FlatHistoryRecord `json:"history"`
+}
+
+// ImportFlatRow строка файла импорта квартир, дом задается в пути запроса
+type ImportFlatRow struct {
+	Price      int64  `json:"price" validate:"required,min=0"`
+	Rooms      int64  `json:"rooms" validate:"required,min=1"`
+	FlatNumber *int64 `json:"flat_number,omitempty" validate:"omitempty,min=1"`
+	FlatAttributes
+}
+
+// ImportRowError ошибка в строке файла импорта. Row - номер строки с данными, начиная с 1
+type ImportRowError struct {
+	Row     int    `json:"row"`
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+type ImportFlatsResponse struct {
+	HouseID  int64            `json:"house_id"`
+	DryRun   bool             `json:"dry_run"`
+	Total    int              `json:"total"`
+	Imported int              `json:"imported"`
+	Errors   []ImportRowError `json:"errors"`
+}
+
+type ClaimRequest struct {
+	Count uint64 `json:"count" validate:"required,min=1,max=50"`
 }
 
 type FlatModerationStatus string
@@ -39,11 +106,50 @@ const (
 )
 
 type Flat struct {
-	ID      int64                `json:"id" validate:"required,min=1"`
-	HouseID int64                `json:"house_id" validate:"required,min=1"`
-	Price   int64                `json:"price" validate:"required,min=0"`
-	Rooms   int64                `json:"rooms" validate:"required,min=1"`
-	Status  FlatModerationStatus `json:"status" validate:"required,oneof='created' 'approved' 'declined' 'on moderation'"`
+	ID         int64                `json:"id" validate:"required,min=1"`
+	HouseID    int64                `json:"house_id" validate:"required,min=1"`
+	Price      int64                `json:"price" validate:"required,min=0"`
+	Rooms      int64                `json:"rooms" validate:"required,min=1"`
+	FlatNumber *int64               `json:"flat_number,omitempty"`
+	Status     FlatModerationStatus `json:"status" validate:"required,oneof='created' 'approved' 'declined' 'on moderation'"`
+	FlatAttributes
+	Version int64   `json:"version"`
+	Photos  []Photo `json:"photos,omitempty"`
+}
+
+// ExportFlat строка выгрузки квартир: квартира вместе с данными дома
+type ExportFlat struct {
+	ID         int64                `json:"id"`
+	HouseID    int64                `json:"house_id"`
+	FlatNumber *int64               `json:"flat_number,omitempty"`
+	Price      int64                `json:"price"`
+	Rooms      int64                `json:"rooms"`
+	Status     FlatModerationStatus `json:"status"`
+	FlatAttributes
+	CreatedAt      time.Time `json:"created_at"`
+	HouseAddress   string    `json:"house_address"`
+	HouseYear      int       `json:"house_year"`
+	HouseDeveloper *string   `json:"house_developer,omitempty"`
+}
+
+// Photo фотография квартиры. URL ведут на GET /flat/{id}/photos/{photoID}
+type Photo struct {
+	ID           int64  `json:"id"`
+	URL          string `json:"url"`
+	ThumbnailURL string `json:"thumbnail_url"`
+	ContentType  string `json:"content_type"`
+	Size         int64  `json:"size"`
+	Width        int    `json:"width"`
+	Height       int    `json:"height"`
+	Status       string `json:"status"`
+}
+
+type UploadPhotosResponse struct {
+	Photos []Photo `json:"photos"`
+}
+
+type UpdatePhotoStatusRequest struct {
+	Status string `json:"status" validate:"required,oneof=approved declined"`
 }
 
 type House struct {
@@ -52,7 +158,14 @@ type House struct {
 	Year      int        `json:"year"`
 	Developer *string    `json:"developer"`
 	CreatedAt time.Time  `json:"created_at"`
-	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"` // дата добавления последней квартиры
+	Version   int64      `json:"version"`
+}
+
+type UpdateHouseRequest struct {
+	Address   *string `json:"address,omitempty" validate:"omitempty,min=1"`
+	Year      *int    `json:"year,omitempty" validate:"omitempty,min=1"`
+	Developer *string `json:"developer,omitempty"`
 }
 
 type CreateHouseRequest struct {
@@ -67,6 +180,17 @@ type CreateHouseResponse struct {
 	Year      int     `json:"year"`
 	Developer *string `json:"developer,omitempty"`
 	CreatedAt string  `json:"created_at"`
+	Version   int64   `json:"version"`
+}
+
+type SubscribeRequest struct {
+	Email string `json:"email" validate:"required,email"`
+}
+
+type SubscribeResponse struct {
+	ID      int64  `json:"id"`
+	HouseID int64  `json:"house_id"`
+	Email   string `json:"email"`
 }
 
 type User struct {
@@ -93,5 +217,26 @@ type RegisterResponse struct {
 }
 
 type LoginResponse struct {
-	Token string `json:"token"`
+	Token        string `json:"token"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+}
+
+type RefreshRequest struct {
+	RefreshToken string `json:"refresh_token" validate:"required"`
+}
+
+type LogoutRequest struct {
+	RefreshToken string `json:"refresh_token,omitempty"`
+}
+
+// HealthResponse результат проверок состояния; в Checks попадают только непройденные проверки
+type HealthResponse struct {
+	Status string            `json:"status"`
+	Checks map[string]string `json:"checks,omitempty"`
+}
+
+type VersionResponse struct {
+	Commit    string `json:"commit"`
+	BuildTime string `json:"build_time"`
+	GoVersion string `json:"go_version"`
 }
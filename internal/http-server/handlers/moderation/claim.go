@@ -0,0 +1,98 @@
+package moderation
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"golang.org/x/exp/slog"
+
+	"realty-avito/internal/auth"
+	"realty-avito/internal/converter"
+	"realty-avito/internal/http-server/handlers"
+	"realty-avito/internal/lib/logger/sl"
+	"realty-avito/internal/models"
+	"realty-avito/internal/repositories/flatsRepo"
+)
+
+type FlatsClaimer interface {
+	ClaimFlats(ctx context.Context, moderatorID string, count uint64) ([]flatsRepo.FlatEntity, error)
+}
+
+type ClaimResponse struct {
+	Flats []handlers.Flat `json:"flats"`
+}
+
+func ClaimHandler(log *slog.Logger, flatsClaimer FlatsClaimer) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.moderation.claim"
+
+		ctx := r.Context()
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(ctx)),
+		)
+
+		principal, ok := auth.PrincipalFromContext(ctx)
+		if !ok {
+			log.Error("Error: no principal in context")
+			w.WriteHeader(http.StatusInternalServerError)
+
+			response := models.InternalServerErrorResponse{
+				Message:   "Error: no principal in context",
+				RequestID: middleware.GetReqID(ctx),
+				Code:      12345,
+			}
+
+			render.JSON(w, r, response)
+			return
+		}
+
+		var req handlers.ClaimRequest
+
+		err := render.DecodeJSON(r.Body, &req)
+		if errors.Is(err, io.EOF) {
+			log.Error("request body is empty")
+			http.Error(w, "request body is empty", http.StatusBadRequest)
+			return
+		}
+
+		if err != nil {
+			log.Error("failed to decode request body", sl.Err(err))
+			http.Error(w, "failed to decode request body", http.StatusBadRequest)
+			return
+		}
+
+		if err := validator.New().Struct(req); err != nil {
+			log.Error("failed to validate request body", sl.Err(err))
+			http.Error(w, "failed to validate request body", http.StatusBadRequest)
+			return
+		}
+
+		flatEntities, err := flatsClaimer.ClaimFlats(ctx, principal.Subject, req.Count)
+		if err != nil {
+			log.Error("failed to claim flats", sl.Err(err))
+
+			w.Header().Set("Retry-After", "60")
+			w.WriteHeader(http.StatusInternalServerError)
+
+			response := models.InternalServerErrorResponse{
+				Message:   err.Error(),
+				RequestID: middleware.GetReqID(ctx),
+				Code:      12345,
+			}
+			render.JSON(w, r, response)
+			return
+		}
+
+		render.JSON(w, r, ClaimResponse{
+			Flats: converter.ConvertFlatEntitiesToFlats(flatEntities),
+		})
+		log.Info("flats claimed", slog.Int("count", len(flatEntities)))
+	}
+}
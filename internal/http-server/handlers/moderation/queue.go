@@ -0,0 +1,110 @@
+package moderation
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"golang.org/x/exp/slog"
+
+	"realty-avito/internal/converter"
+	"realty-avito/internal/http-server/handlers"
+	"realty-avito/internal/lib/cursor"
+	"realty-avito/internal/lib/logger/sl"
+	"realty-avito/internal/models"
+	"realty-avito/internal/repositories/flatsRepo"
+)
+
+const (
+	defaultQueueLimit = 20
+	maxQueueLimit     = 100
+)
+
+type QueueGetter interface {
+	GetModerationQueue(ctx context.Context, limit uint64, after *flatsRepo.QueuePosition) ([]flatsRepo.FlatEntity, error)
+}
+
+type QueueResponse struct {
+	Flats      []handlers.Flat `json:"flats"`
+	NextCursor string          `json:"next_cursor,omitempty"`
+}
+
+func GetQueueHandler(log *slog.Logger, queueGetter QueueGetter) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.moderation.queue"
+
+		ctx := r.Context()
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(ctx)),
+		)
+
+		limit := uint64(defaultQueueLimit)
+		if limitStr := r.URL.Query().Get("limit"); limitStr != "" {
+			parsed, err := strconv.ParseUint(limitStr, 10, 64)
+			if err != nil || parsed < 1 || parsed > maxQueueLimit {
+				log.Error("invalid limit", slog.String("limit", limitStr))
+				http.Error(w, "limit must be between 1 and 100", http.StatusBadRequest)
+				return
+			}
+			limit = parsed
+		}
+
+		after, err := decodeQueuePosition(r.URL.Query().Get("cursor"))
+		if err != nil {
+			log.Error("invalid cursor", sl.Err(err))
+			http.Error(w, "invalid cursor", http.StatusBadRequest)
+			return
+		}
+
+		flatEntities, err := queueGetter.GetModerationQueue(ctx, limit, after)
+		if err != nil {
+			log.Error("failed to get moderation queue", sl.Err(err))
+
+			w.Header().Set("Retry-After", "60")
+			w.WriteHeader(http.StatusInternalServerError)
+
+			response := models.InternalServerErrorResponse{
+				Message:   err.Error(),
+				RequestID: middleware.GetReqID(ctx),
+				Code:      12345,
+			}
+			render.JSON(w, r, response)
+			return
+		}
+
+		response := QueueResponse{
+			Flats: converter.ConvertFlatEntitiesToFlats(flatEntities),
+		}
+
+		// полная страница - возможно, в очереди есть еще квартиры
+		if uint64(len(flatEntities)) == limit {
+			last := flatEntities[len(flatEntities)-1]
+			response.NextCursor = cursor.Encode(cursor.Cursor{
+				Value: last.CreatedAt.Format(time.RFC3339Nano),
+				ID:    last.ID,
+			})
+		}
+
+		render.JSON(w, r, response)
+		log.Info("request handled successfully", slog.Int("count", len(flatEntities)))
+	}
+}
+
+func decodeQueuePosition(raw string) (*flatsRepo.QueuePosition, error) {
+	c, err := cursor.Decode(raw)
+	if err != nil || c == nil {
+		return nil, err
+	}
+
+	createdAt, err := time.Parse(time.RFC3339Nano, c.Value)
+	if err != nil {
+		return nil, cursor.ErrInvalidCursor
+	}
+
+	return &flatsRepo.QueuePosition{CreatedAt: createdAt, ID: c.ID}, nil
+}
@@ -0,0 +1,135 @@
+package refresh
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"github.com/go-chi/render"
+	"github.com/go-playground/validator/v10"
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/exp/slog"
+
+	"realty-avito/internal/auth"
+	"realty-avito/internal/client/db"
+	"realty-avito/internal/http-server/handlers"
+	"realty-avito/internal/lib/logger/sl"
+	"realty-avito/internal/models"
+	"realty-avito/internal/repositories/tokensRepo"
+)
+
+var (
+	errRefreshTokenExpired = errors.New("refresh token expired")
+	errRefreshTokenReused  = errors.New("refresh token reused")
+)
+
+type RefreshTokensStore interface {
+	auth.RefreshTokenWriter
+	GetRefreshTokenByHash(ctx context.Context, tokenHash string) (*tokensRepo.RefreshTokenEntity, error)
+	RevokeRefreshToken(ctx context.Context, tokenID int64) (bool, error)
+	RevokeRefreshTokenFamily(ctx context.Context, familyID string) error
+}
+
+// RefreshHandler меняет refresh-токен на новую пару токенов. Старый refresh-токен при этом отзывается.
+// Повторное предъявление уже отозванного токена считается кражей: отзывается вся сессия.
+func RefreshHandler(log *slog.Logger, refreshTokens RefreshTokensStore, tokens auth.Issuer, txManager db.TxManager) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		const op = "handlers.RefreshHandler"
+
+		ctx := r.Context()
+
+		log := log.With(
+			slog.String("op", op),
+			slog.String("request_id", middleware.GetReqID(ctx)),
+		)
+
+		var req handlers.RefreshRequest
+
+		err := render.DecodeJSON(r.Body, &req)
+		if errors.Is(err, io.EOF) {
+			log.Error("request body is empty")
+			http.Error(w, "request body is empty", http.StatusBadRequest)
+			return
+		}
+
+		if err != nil {
+			log.Error("failed to decode request body", sl.Err(err))
+			http.Error(w, "failed to decode request body", http.StatusBadRequest)
+			return
+		}
+
+		if err := validator.New().Struct(req); err != nil {
+			log.Error("failed to validate request body", sl.Err(err))
+			http.Error(w, "failed to validate request body", http.StatusBadRequest)
+			return
+		}
+
+		var response handlers.LoginResponse
+		var reused bool
+
+		err = txManager.ReadCommitted(ctx, func(ctx context.Context) error {
+			stored, errTx := refreshTokens.GetRefreshTokenByHash(ctx, auth.HashRefreshToken(req.RefreshToken))
+			if errTx != nil {
+				return errTx
+			}
+
+			if stored.RevokedAt != nil {
+				// транзакция должна закоммитить отзыв сессии, поэтому ошибку возвращаем уже после нее
+				reused = true
+				return refreshTokens.RevokeRefreshTokenFamily(ctx, stored.FamilyID)
+			}
+
+			if stored.Expired {
+				return errRefreshTokenExpired
+			}
+
+			revoked, errTx := refreshTokens.RevokeRefreshToken(ctx, stored.ID)
+			if errTx != nil {
+				return errTx
+			}
+
+			if !revoked {
+				return errRefreshTokenReused
+			}
+
+			claims := auth.Claims{
+				UserType:         stored.UserType,
+				AuthMethod:       auth.AuthMethodRefresh,
+				RegisteredClaims: jwt.RegisteredClaims{Subject: stored.Subject},
+			}
+
+			response.Token, response.RefreshToken, errTx = auth.IssueTokenPair(ctx, tokens, refreshTokens, claims, stored.FamilyID)
+			return errTx
+		})
+
+		if err == nil && reused {
+			err = errRefreshTokenReused
+		}
+
+		if err != nil {
+			if errors.Is(err, tokensRepo.ErrRefreshTokenNotFound) ||
+				errors.Is(err, errRefreshTokenExpired) ||
+				errors.Is(err, errRefreshTokenReused) {
+				log.Warn("refresh token rejected", sl.Err(err))
+				http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+				return
+			}
+
+			log.Error("failed to refresh token", sl.Err(err))
+
+			w.Header().Set("Retry-After", "60")
+			w.WriteHeader(http.StatusInternalServerError)
+
+			render.JSON(w, r, models.InternalServerErrorResponse{
+				Message:   "failed to refresh token",
+				RequestID: middleware.GetReqID(ctx),
+				Code:      12345,
+			})
+			return
+		}
+
+		render.JSON(w, r, response)
+	}
+}
@@ -0,0 +1,116 @@
+package refresh
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"realty-avito/internal/auth"
+	"realty-avito/internal/client/db"
+	"realty-avito/internal/config"
+	"realty-avito/internal/http-server/handlers"
+	"realty-avito/internal/lib/logger"
+	"realty-avito/internal/repositories/tokensRepo"
+	"realty-avito/internal/repositories/tokensRepo/mocks"
+)
+
+type noTxManager struct{}
+
+func (noTxManager) ReadCommitted(ctx context.Context, f db.Handler) error {
+	return f(ctx)
+}
+
+func TestRefreshHandler(t *testing.T) {
+	tokens, err := auth.NewTokenManager(config.JWTConfig{
+		Algorithm:       auth.AlgorithmHS256,
+		Secret:          "test-secret",
+		AccessTokenTTL:  time.Minute,
+		RefreshTokenTTL: time.Hour,
+	})
+	require.NoError(t, err)
+
+	const refreshToken = "refresh-token"
+	tokenHash := auth.HashRefreshToken(refreshToken)
+	revokedAt := time.Now()
+
+	tests := []struct {
+		name               string
+		prepareMock        func(m *mocks.TokensRepository)
+		expectedStatusCode int
+	}{
+		{
+			name: "valid token is rotated",
+			prepareMock: func(m *mocks.TokensRepository) {
+				m.On("GetRefreshTokenByHash", mock.Anything, tokenHash).Return(&tokensRepo.RefreshTokenEntity{
+					ID: 1, FamilyID: "family", Subject: "user", UserType: "client",
+				}, nil).Once()
+				m.On("RevokeRefreshToken", mock.Anything, int64(1)).Return(true, nil).Once()
+				m.On("CreateRefreshToken", mock.Anything, mock.MatchedBy(func(e tokensRepo.CreateRefreshTokenEntity) bool {
+					return e.FamilyID == "family" && e.Subject == "user" && e.TokenHash != tokenHash
+				})).Return(nil).Once()
+			},
+			expectedStatusCode: http.StatusOK,
+		},
+		{
+			name: "reused token revokes the whole family",
+			prepareMock: func(m *mocks.TokensRepository) {
+				m.On("GetRefreshTokenByHash", mock.Anything, tokenHash).Return(&tokensRepo.RefreshTokenEntity{
+					ID: 1, FamilyID: "family", Subject: "user", UserType: "client", RevokedAt: &revokedAt,
+				}, nil).Once()
+				m.On("RevokeRefreshTokenFamily", mock.Anything, "family").Return(nil).Once()
+			},
+			expectedStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name: "expired token",
+			prepareMock: func(m *mocks.TokensRepository) {
+				m.On("GetRefreshTokenByHash", mock.Anything, tokenHash).Return(&tokensRepo.RefreshTokenEntity{
+					ID: 1, FamilyID: "family", Subject: "user", UserType: "client", Expired: true,
+				}, nil).Once()
+			},
+			expectedStatusCode: http.StatusUnauthorized,
+		},
+		{
+			name: "unknown token",
+			prepareMock: func(m *mocks.TokensRepository) {
+				m.On("GetRefreshTokenByHash", mock.Anything, tokenHash).Return(nil, tokensRepo.ErrRefreshTokenNotFound).Once()
+			},
+			expectedStatusCode: http.StatusUnauthorized,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockTokensRepo := new(mocks.TokensRepository)
+			tt.prepareMock(mockTokensRepo)
+
+			handler := RefreshHandler(logger.SetupLogger("local"), mockTokensRepo, tokens, noTxManager{})
+
+			req := httptest.NewRequest(http.MethodPost, "/token/refresh", strings.NewReader(`{"refresh_token":"`+refreshToken+`"}`))
+			rr := httptest.NewRecorder()
+
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tt.expectedStatusCode, rr.Code)
+			mockTokensRepo.AssertExpectations(t)
+
+			if rr.Code == http.StatusOK {
+				var resp handlers.LoginResponse
+				require.NoError(t, json.NewDecoder(rr.Body).Decode(&resp))
+
+				claims, err := tokens.Parse(resp.Token)
+				require.NoError(t, err)
+				require.Equal(t, "user", claims.Subject)
+				require.NotEmpty(t, resp.RefreshToken)
+				require.NotEqual(t, refreshToken, resp.RefreshToken)
+			}
+		})
+	}
+}
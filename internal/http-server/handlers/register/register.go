@@ -4,6 +4,7 @@ import (
 	"errors"
 	"io"
 	"net/http"
+	"strings"
 
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/render"
@@ -53,6 +54,12 @@ func RegisterHandler(log *slog.Logger, usersRepository usersRepo.UserRepository)
 			return
 		}
 
+		if strings.HasSuffix(strings.ToLower(req.Email), "@"+usersRepo.DummyEmailDomain) {
+			log.Info("registration with reserved email domain rejected")
+			http.Error(w, "email domain is reserved", http.StatusBadRequest)
+			return
+		}
+
 		userEntity, err := converter.ConvertRegisterRequestToUserEntity(req)
 		if err != nil {
 			log.Error("failed to register user", slog.String("op", op), slog.StringValue(err.Error()))
@@ -2,86 +2,73 @@ package middleware
 
 import (
 	"context"
-	"fmt"
 	"net/http"
 	"strings"
-	"time"
 
-	"github.com/golang-jwt/jwt/v5"
+	"realty-avito/internal/auth"
+	"realty-avito/internal/models"
 )
 
-var jwtKey = []byte("jwt_most_secret_key")
-
-type DummyClaims struct {
-	UserType string `json:"user_type"`
-	jwt.RegisteredClaims
+type TokenParser interface {
+	Parse(tokenString string) (*auth.Claims, error)
 }
 
-func GenerateDummyJWT(userType string) (string, error) {
-	claims := &DummyClaims{
-		UserType:         userType,
-		RegisteredClaims: jwt.RegisteredClaims{ID: generateUUID()},
-	}
-
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(jwtKey)
+type AccessTokenDenylist interface {
+	IsAccessTokenRevoked(ctx context.Context, jti string) (bool, error)
 }
 
-func JWTMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		tokenString := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
-
-		if tokenString == "" {
-			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
-			return
-		}
-
-		claims := &DummyClaims{}
-		token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-			return jwtKey, nil
+// Authenticate проверяет bearer-токен и кладет в контекст auth.Principal.
+// Если токена нет, он невалиден или отозван, отвечает 401.
+func Authenticate(tokens TokenParser, denylist AccessTokenDenylist) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			tokenString := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+
+			if tokenString == "" {
+				http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+				return
+			}
+
+			claims, err := tokens.Parse(tokenString)
+			if err != nil || claims.Subject == "" {
+				http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+				return
+			}
+
+			revoked, err := denylist.IsAccessTokenRevoked(r.Context(), claims.ID)
+			if err != nil {
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				return
+			}
+
+			if revoked {
+				http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+				return
+			}
+
+			ctx := auth.WithPrincipal(r.Context(), auth.NewPrincipal(claims))
+			next.ServeHTTP(w, r.WithContext(ctx))
 		})
-
-		if err != nil || !token.Valid {
-			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
-			return
-		}
-
-		ctx := context.WithValue(r.Context(), "user_type", claims.UserType)
-		next.ServeHTTP(w, r.WithContext(ctx))
-	})
+	}
 }
 
-func JWTModeratorOnlyMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		tokenString := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
-
-		if tokenString == "" {
-			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
-			return
-		}
-
-		claims := &DummyClaims{}
-		token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
-			return jwtKey, nil
+// RequireRole пропускает только пользователей с одной из ролей.
+// Ставится после Authenticate: без аутентификации отвечает 401, с чужой ролью - 403.
+func RequireRole(roles ...models.UserType) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			principal, ok := auth.PrincipalFromContext(r.Context())
+			if !ok {
+				http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
+				return
+			}
+
+			if !principal.HasRole(roles...) {
+				http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+				return
+			}
+
+			next.ServeHTTP(w, r)
 		})
-
-		if err != nil || !token.Valid {
-			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
-			return
-		}
-
-		if claims.UserType != "moderator" {
-			http.Error(w, http.StatusText(http.StatusUnauthorized), http.StatusUnauthorized)
-			return
-		}
-
-		ctx := context.WithValue(r.Context(), "user_type", claims.UserType)
-		ctx = context.WithValue(ctx, "moderator_id", tokenString)
-
-		next.ServeHTTP(w, r.WithContext(ctx))
-	})
-}
-
-func generateUUID() string {
-	return fmt.Sprintf("%d", time.Now().UnixNano())
+	}
 }
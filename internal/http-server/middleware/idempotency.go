@@ -0,0 +1,188 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5/middleware"
+	"golang.org/x/exp/slog"
+
+	"realty-avito/internal/auth"
+	"realty-avito/internal/config"
+	"realty-avito/internal/lib/logger/sl"
+	"realty-avito/internal/repositories/idempotencyRepo"
+)
+
+const (
+	IdempotencyKeyHeader     = "Idempotency-Key"
+	IdempotentReplayedHeader = "Idempotent-Replayed"
+	maxIdempotencyKeyLength  = 255
+	idempotencyRecordTimeout = 5 * time.Second
+)
+
+type IdempotencyStore interface {
+	ReserveKey(ctx context.Context, reserveKeyEntity idempotencyRepo.ReserveKeyEntity) (*idempotencyRepo.KeyEntity, bool, error)
+	SaveResponse(ctx context.Context, saveResponseEntity idempotencyRepo.SaveResponseEntity) error
+	ReleaseKey(ctx context.Context, subject string, key string, leaseID string) error
+}
+
+// Idempotency делает маршрут идемпотентным для запросов с заголовком Idempotency-Key.
+// Первый запрос выполняется, его ответ сохраняется на cfg.TTL и отдается на повторы с тем же ключом
+// и тем же телом. Повтор с другим телом получает 422, повтор во время выполнения первого запроса - 409.
+// Ответы 5xx не сохраняются: ключ освобождается, и запрос можно повторить.
+// Если процесс упал посреди запроса, ключ освобождается сам через cfg.LockTimeout.
+// Запрос, чью аренду перехватил повтор, уже не может ни сохранить ответ, ни освободить ключ.
+// Ключи у каждого пользователя свои, поэтому middleware ставится после Authenticate.
+func Idempotency(log *slog.Logger, store IdempotencyStore, cfg config.IdempotencyConfig) func(next http.Handler) http.Handler {
+	log = log.With(slog.String("component", "middleware/idempotency"))
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get(IdempotencyKeyHeader)
+			if key == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			if len(key) > maxIdempotencyKeyLength {
+				http.Error(w, "Idempotency-Key is too long", http.StatusBadRequest)
+				return
+			}
+
+			log := log.With(
+				slog.String("request_id", middleware.GetReqID(r.Context())),
+				slog.String("idempotency_key", key),
+			)
+
+			body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, cfg.MaxBodySize))
+			if err != nil {
+				var maxBytesErr *http.MaxBytesError
+				if errors.As(err, &maxBytesErr) {
+					http.Error(w, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+					return
+				}
+
+				http.Error(w, "failed to read request body", http.StatusBadRequest)
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			var subject string
+			if principal, ok := auth.PrincipalFromContext(r.Context()); ok {
+				subject = principal.Subject
+			}
+
+			fingerprint := requestFingerprint(r, body)
+
+			saved, reserved, err := store.ReserveKey(r.Context(), idempotencyRepo.ReserveKeyEntity{
+				Subject:     subject,
+				Key:         key,
+				Fingerprint: fingerprint,
+				TTL:         cfg.TTL,
+				LockTimeout: cfg.LockTimeout,
+			})
+			if err != nil {
+				log.Error("failed to reserve idempotency key", sl.Err(err))
+				http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+				return
+			}
+
+			if !reserved {
+				replayResponse(w, saved, fingerprint)
+				return
+			}
+
+			leaseID := saved.LeaseID
+
+			// запись ключа не должна зависеть от клиента: он мог отключиться по таймауту и прийти повтором
+			recordCtx := func() (context.Context, context.CancelFunc) {
+				return context.WithTimeout(context.Background(), idempotencyRecordTimeout)
+			}
+
+			completed := false
+			defer func() {
+				if completed {
+					return
+				}
+
+				// обработчик упал с паникой: освобождаем ключ и отдаем панику дальше в Recoverer
+				ctx, cancel := recordCtx()
+				defer cancel()
+
+				if err := store.ReleaseKey(ctx, subject, key, leaseID); err != nil {
+					log.Error("failed to release idempotency key", sl.Err(err))
+				}
+			}()
+
+			var response bytes.Buffer
+
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			ww.Tee(&response)
+
+			next.ServeHTTP(ww, r)
+
+			completed = true
+
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+
+			ctx, cancel := recordCtx()
+			defer cancel()
+
+			if status >= http.StatusInternalServerError {
+				if err := store.ReleaseKey(ctx, subject, key, leaseID); err != nil {
+					log.Error("failed to release idempotency key", sl.Err(err))
+				}
+				return
+			}
+
+			err = store.SaveResponse(ctx, idempotencyRepo.SaveResponseEntity{
+				Subject:      subject,
+				Key:          key,
+				LeaseID:      leaseID,
+				StatusCode:   status,
+				ContentType:  ww.Header().Get("Content-Type"),
+				ResponseBody: response.Bytes(),
+			})
+			if err != nil {
+				log.Error("failed to save idempotent response", sl.Err(err))
+			}
+		})
+	}
+}
+
+func replayResponse(w http.ResponseWriter, saved *idempotencyRepo.KeyEntity, fingerprint string) {
+	if saved.Fingerprint != fingerprint {
+		http.Error(w, "Idempotency-Key is already used with a different request", http.StatusUnprocessableEntity)
+		return
+	}
+
+	if saved.StatusCode == nil {
+		http.Error(w, "request with this Idempotency-Key is still in progress", http.StatusConflict)
+		return
+	}
+
+	if saved.ContentType != nil && *saved.ContentType != "" {
+		w.Header().Set("Content-Type", *saved.ContentType)
+	}
+	w.Header().Set(IdempotentReplayedHeader, "true")
+	w.WriteHeader(*saved.StatusCode)
+	_, _ = w.Write(saved.ResponseBody)
+}
+
+// requestFingerprint отпечаток запроса: метод, путь и тело
+func requestFingerprint(r *http.Request, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(r.Method + " " + r.URL.Path + "\n"))
+	hash.Write(body)
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
@@ -0,0 +1,264 @@
+package middleware_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"realty-avito/internal/auth"
+	"realty-avito/internal/config"
+	"realty-avito/internal/http-server/middleware"
+	"realty-avito/internal/lib/logger"
+	"realty-avito/internal/models"
+	"realty-avito/internal/repositories/idempotencyRepo"
+)
+
+// memoryIdempotencyStore хранит ключи в памяти, истечение ключей не проверяется,
+// истечение аренды задается через expireLease
+type memoryIdempotencyStore struct {
+	mu      sync.Mutex
+	keys    map[string]idempotencyRepo.KeyEntity
+	expired map[string]bool
+	leases  int
+}
+
+func newMemoryIdempotencyStore() *memoryIdempotencyStore {
+	return &memoryIdempotencyStore{keys: map[string]idempotencyRepo.KeyEntity{}, expired: map[string]bool{}}
+}
+
+func (s *memoryIdempotencyStore) ReserveKey(_ context.Context, e idempotencyRepo.ReserveKeyEntity) (*idempotencyRepo.KeyEntity, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := e.Subject + "/" + e.Key
+	if saved, ok := s.keys[id]; ok && !(saved.StatusCode == nil && s.expired[id]) {
+		return &saved, false, nil
+	}
+
+	s.leases++
+	reserved := idempotencyRepo.KeyEntity{Subject: e.Subject, Key: e.Key, Fingerprint: e.Fingerprint, LeaseID: strconv.Itoa(s.leases)}
+	s.keys[id] = reserved
+	delete(s.expired, id)
+
+	return &reserved, true, nil
+}
+
+func (s *memoryIdempotencyStore) SaveResponse(_ context.Context, e idempotencyRepo.SaveResponseEntity) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	saved, ok := s.keys[e.Subject+"/"+e.Key]
+	if !ok || saved.LeaseID != e.LeaseID {
+		return nil
+	}
+
+	saved.StatusCode = &e.StatusCode
+	saved.ContentType = &e.ContentType
+	saved.ResponseBody = e.ResponseBody
+	s.keys[e.Subject+"/"+e.Key] = saved
+
+	return nil
+}
+
+func (s *memoryIdempotencyStore) ReleaseKey(_ context.Context, subject string, key string, leaseID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if saved, ok := s.keys[subject+"/"+key]; ok && saved.LeaseID == leaseID && saved.StatusCode == nil {
+		delete(s.keys, subject+"/"+key)
+	}
+	return nil
+}
+
+func (s *memoryIdempotencyStore) expireLease(subject string, key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.expired[subject+"/"+key] = true
+}
+
+func TestIdempotency(t *testing.T) {
+	store := newMemoryIdempotencyStore()
+
+	calls := 0
+	failNext := false
+
+	handler := middleware.Idempotency(logger.SetupLogger("local"), store, config.IdempotencyConfig{TTL: time.Hour, MaxBodySize: 1024})(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			calls++
+
+			if failNext {
+				failNext = false
+				w.WriteHeader(http.StatusInternalServerError)
+				return
+			}
+
+			body, _ := io.ReadAll(r.Body)
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(`{"call":` + strconv.Itoa(calls) + `,"echo":` + string(body) + `}`))
+		}),
+	)
+
+	send := func(subject, key, body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/flat/create", strings.NewReader(body))
+		if key != "" {
+			req.Header.Set(middleware.IdempotencyKeyHeader, key)
+		}
+		req = req.WithContext(auth.WithPrincipal(req.Context(), auth.Principal{Subject: subject, Role: models.Client}))
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	first := send("user-1", "key-1", `{"price":1}`)
+	require.Equal(t, http.StatusCreated, first.Code)
+	require.Equal(t, `{"call":1,"echo":{"price":1}}`, first.Body.String())
+
+	// повтор получает сохраненный ответ, обработчик не вызывается
+	replay := send("user-1", "key-1", `{"price":1}`)
+	require.Equal(t, http.StatusCreated, replay.Code)
+	require.Equal(t, first.Body.String(), replay.Body.String())
+	require.Equal(t, "application/json", replay.Header().Get("Content-Type"))
+	require.Equal(t, "true", replay.Header().Get(middleware.IdempotentReplayedHeader))
+	require.Equal(t, 1, calls)
+
+	// тот же ключ с другим телом
+	mismatch := send("user-1", "key-1", `{"price":2}`)
+	require.Equal(t, http.StatusUnprocessableEntity, mismatch.Code)
+	require.Equal(t, 1, calls)
+
+	// ключи разных пользователей не пересекаются
+	other := send("user-2", "key-1", `{"price":2}`)
+	require.Equal(t, http.StatusCreated, other.Code)
+	require.Equal(t, 2, calls)
+
+	// ответ 5xx не сохраняется, повтор выполняет запрос заново
+	failNext = true
+	failed := send("user-1", "key-2", `{"price":3}`)
+	require.Equal(t, http.StatusInternalServerError, failed.Code)
+
+	retried := send("user-1", "key-2", `{"price":3}`)
+	require.Equal(t, http.StatusCreated, retried.Code)
+	require.Equal(t, 4, calls)
+
+	// запросы без ключа не кешируются
+	send("user-1", "", `{"price":4}`)
+	send("user-1", "", `{"price":4}`)
+	require.Equal(t, 6, calls)
+}
+
+func TestIdempotencyInProgress(t *testing.T) {
+	var handler http.Handler
+
+	send := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/house/create", strings.NewReader(`{"year":2000}`))
+		req.Header.Set(middleware.IdempotencyKeyHeader, "key-1")
+		req = req.WithContext(auth.WithPrincipal(req.Context(), auth.Principal{Subject: "moderator", Role: models.Moderator}))
+
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	var concurrentCode int
+
+	handler = middleware.Idempotency(logger.SetupLogger("local"), newMemoryIdempotencyStore(), config.IdempotencyConfig{TTL: time.Hour, MaxBodySize: 1024})(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// повтор приходит, пока первый запрос еще выполняется
+			concurrentCode = send().Code
+			w.WriteHeader(http.StatusOK)
+		}),
+	)
+
+	require.Equal(t, http.StatusOK, send().Code)
+	require.Equal(t, http.StatusConflict, concurrentCode)
+}
+
+func TestIdempotencyLeaseTakenOver(t *testing.T) {
+	tests := []struct {
+		name        string
+		firstStatus int
+	}{
+		{name: "first request saves response", firstStatus: http.StatusCreated},
+		{name: "first request releases key", firstStatus: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := newMemoryIdempotencyStore()
+
+			var (
+				handler http.Handler
+				calls   atomic.Int32
+				retry   *httptest.ResponseRecorder
+				retryWg sync.WaitGroup
+				entered = make(chan struct{})
+				proceed = make(chan struct{})
+			)
+
+			send := func() *httptest.ResponseRecorder {
+				req := httptest.NewRequest(http.MethodPost, "/flat/create", strings.NewReader(`{"price":1}`))
+				req.Header.Set(middleware.IdempotencyKeyHeader, "key-1")
+				req = req.WithContext(auth.WithPrincipal(req.Context(), auth.Principal{Subject: "user-1", Role: models.Client}))
+
+				rr := httptest.NewRecorder()
+				handler.ServeHTTP(rr, req)
+				return rr
+			}
+
+			handler = middleware.Idempotency(logger.SetupLogger("local"), store, config.IdempotencyConfig{TTL: time.Hour, LockTimeout: time.Minute, MaxBodySize: 1024})(
+				http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					call := calls.Add(1)
+
+					if call == 1 {
+						// первый запрос завис дольше аренды, ключ перехватывает повтор
+						store.expireLease("user-1", "key-1")
+
+						retryWg.Add(1)
+						go func() {
+							defer retryWg.Done()
+							retry = send()
+						}()
+						<-entered
+
+						w.WriteHeader(tt.firstStatus)
+						_, _ = w.Write([]byte(`{"call":1}`))
+						return
+					}
+
+					close(entered)
+					<-proceed
+
+					w.WriteHeader(http.StatusCreated)
+					_, _ = w.Write([]byte(`{"call":2}`))
+				}),
+			)
+
+			first := send()
+			require.Equal(t, tt.firstStatus, first.Code)
+
+			// первый запрос не тронул чужую аренду: повтор все еще выполняется
+			require.Equal(t, http.StatusConflict, send().Code)
+
+			close(proceed)
+			retryWg.Wait()
+			require.Equal(t, http.StatusCreated, retry.Code)
+
+			replay := send()
+			require.Equal(t, http.StatusCreated, replay.Code)
+			require.Equal(t, `{"call":2}`, replay.Body.String())
+			require.Equal(t, int32(2), calls.Load())
+		})
+	}
+}
@@ -2,12 +2,21 @@ package logger
 
 import (
 	"net/http"
+	"strconv"
 	"time"
 
+	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
+	"go.opentelemetry.io/otel/trace"
 	"golang.org/x/exp/slog"
+
+	"realty-avito/internal/lib/logger/sl"
+	"realty-avito/internal/metrics"
 )
 
+// New логирует каждый запрос и записывает его длительность в метрики.
+// Логгер запроса кладется в контекст, через него пишутся, например, SQL-запросы.
+// Шаблон маршрута chi известен только после роутинга, поэтому метрики пишутся после обработки запроса.
 func New(log *slog.Logger) func(next http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		log := log.With(
@@ -24,18 +33,37 @@ func New(log *slog.Logger) func(next http.Handler) http.Handler {
 				slog.String("user_agent", r.UserAgent()),
 				slog.String("request_id", middleware.GetReqID(r.Context())),
 			)
+			if spanCtx := trace.SpanContextFromContext(r.Context()); spanCtx.HasTraceID() {
+				entry = entry.With(slog.String("trace_id", spanCtx.TraceID().String()))
+			}
 			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
 
 			t1 := time.Now()
 			defer func() {
+				duration := time.Since(t1)
+
+				status := ww.Status()
+				if status == 0 {
+					status = http.StatusOK
+				}
+
+				route := metrics.UnmatchedRoute
+				if routeCtx := chi.RouteContext(r.Context()); routeCtx != nil && routeCtx.RoutePattern() != "" {
+					route = routeCtx.RoutePattern()
+				}
+
+				metrics.HTTPRequestDuration.
+					WithLabelValues(r.Method, route, strconv.Itoa(status)).
+					Observe(duration.Seconds())
+
 				entry.Info("request completed",
 					slog.Int("status", ww.Status()),
 					slog.Int("bytes", ww.BytesWritten()),
-					slog.String("duration", time.Since(t1).String()),
+					slog.String("duration", duration.String()),
 				)
 			}()
 
-			next.ServeHTTP(ww, r)
+			next.ServeHTTP(ww, r.WithContext(sl.WithLogger(r.Context(), entry)))
 		}
 
 		return http.HandlerFunc(fn)
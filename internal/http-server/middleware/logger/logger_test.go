@@ -0,0 +1,30 @@
+package logger_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/stretchr/testify/require"
+
+	mwLogger "realty-avito/internal/http-server/middleware/logger"
+	"realty-avito/internal/lib/logger"
+	"realty-avito/internal/metrics"
+)
+
+func TestRequestMetricsUseRoutePattern(t *testing.T) {
+	router := chi.NewRouter()
+	router.Use(mwLogger.New(logger.SetupLogger("local")))
+	router.Get("/house/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotModified)
+	})
+
+	for _, path := range []string{"/house/1", "/house/2", "/unknown/path"} {
+		router.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	// разные id попадают в один ряд, неизвестные пути - в общий
+	require.True(t, metrics.HTTPRequestDuration.DeleteLabelValues(http.MethodGet, "/house/{id}", "304"))
+	require.True(t, metrics.HTTPRequestDuration.DeleteLabelValues(http.MethodGet, metrics.UnmatchedRoute, "404"))
+}
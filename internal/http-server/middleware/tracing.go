@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"realty-avito/internal/tracing"
+)
+
+var httpTracer = tracing.Tracer("http-server")
+
+// Tracing открывает серверный спан на каждый запрос, продолжая трассу из входящего traceparent.
+// Имя спана уточняется шаблоном маршрута chi после роутинга, чтобы не зависеть от id в пути.
+// Ставится после middleware.RequestID: request_id записывается в спан и связывает его с логами.
+func Tracing() func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+
+			ctx, span := httpTracer.Start(ctx, "HTTP "+r.Method,
+				trace.WithSpanKind(trace.SpanKindServer),
+				trace.WithAttributes(
+					semconv.HTTPMethod(r.Method),
+					attribute.String("http.target", r.URL.Path),
+					attribute.String("request_id", middleware.GetReqID(r.Context())),
+				),
+			)
+
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+
+			defer func() {
+				status := ww.Status()
+				if status == 0 {
+					status = http.StatusOK
+				}
+
+				if routeCtx := chi.RouteContext(ctx); routeCtx != nil && routeCtx.RoutePattern() != "" {
+					span.SetName(r.Method + " " + routeCtx.RoutePattern())
+					span.SetAttributes(semconv.HTTPRoute(routeCtx.RoutePattern()))
+				}
+
+				span.SetAttributes(semconv.HTTPStatusCode(status))
+				if status >= http.StatusInternalServerError {
+					span.SetStatus(codes.Error, http.StatusText(status))
+				}
+
+				span.End()
+			}()
+
+			next.ServeHTTP(ww, r.WithContext(ctx))
+		})
+	}
+}
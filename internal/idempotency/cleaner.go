@@ -0,0 +1,72 @@
+package idempotency
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"golang.org/x/exp/slog"
+
+	"realty-avito/internal/config"
+	"realty-avito/internal/lib/logger/sl"
+)
+
+type ExpiredKeysDeleter interface {
+	DeleteExpiredKeys(ctx context.Context) (int64, error)
+}
+
+// Cleaner периодически удаляет истекшие ключи идемпотентности
+type Cleaner struct {
+	log      *slog.Logger
+	keys     ExpiredKeysDeleter
+	interval time.Duration
+
+	wg sync.WaitGroup
+}
+
+func NewCleaner(log *slog.Logger, keys ExpiredKeysDeleter, cfg config.IdempotencyConfig) *Cleaner {
+	return &Cleaner{
+		log:      log.With(slog.String("component", "idempotency/cleaner")),
+		keys:     keys,
+		interval: cfg.CleanupInterval,
+	}
+}
+
+// Start запускает фоновую очистку, которая работает до отмены ctx
+func (c *Cleaner) Start(ctx context.Context) {
+	c.wg.Add(1)
+	go func() {
+		defer c.wg.Done()
+		c.run(ctx)
+	}()
+}
+
+// Wait дожидается остановки фоновой очистки
+func (c *Cleaner) Wait() {
+	c.wg.Wait()
+}
+
+func (c *Cleaner) run(ctx context.Context) {
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		deleted, err := c.keys.DeleteExpiredKeys(ctx)
+		if err != nil {
+			if ctx.Err() == nil {
+				c.log.Error("failed to delete expired idempotency keys", sl.Err(err))
+			}
+			continue
+		}
+
+		if deleted > 0 {
+			c.log.Info("expired idempotency keys deleted", slog.Int64("count", deleted))
+		}
+	}
+}
@@ -0,0 +1,40 @@
+package cursor
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Cursor позиция для keyset-пагинации: значение ключа сортировки и id последней отданной записи.
+// Клиенту курсор отдается непрозрачной base64-строкой.
+type Cursor struct {
+	Value string `json:"v,omitempty"`
+	ID    int64  `json:"id"`
+}
+
+func Encode(c Cursor) string {
+	raw, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// Decode разбирает курсор, пришедший от клиента. Пустая строка означает первую страницу и возвращает nil.
+func Decode(s string) (*Cursor, error) {
+	if s == "" {
+		return nil, nil
+	}
+
+	raw, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	var c Cursor
+	if err := json.Unmarshal(raw, &c); err != nil || c.ID < 1 {
+		return nil, ErrInvalidCursor
+	}
+
+	return &c, nil
+}
@@ -0,0 +1,64 @@
+package etag
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+var ErrInvalidIfMatch = errors.New("invalid If-Match, expected a single ETag from a previous response or *")
+
+// FromVersion сильный ETag ресурса с версией
+func FromVersion(version int64) string {
+	return `"` + strconv.FormatInt(version, 10) + `"`
+}
+
+// FromContent слабый ETag по телу ответа, для выборок без собственной версии
+func FromContent(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `W/"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// ParseIfMatch достает из If-Match версию, которую видел клиент.
+// nil без ошибки означает, что заголовка нет или в нем *, и версию проверять не нужно.
+func ParseIfMatch(header string) (*int64, error) {
+	header = strings.TrimSpace(header)
+	if header == "" || header == "*" {
+		return nil, nil
+	}
+
+	// слабые ETag и списки в If-Match для версий не имеют смысла
+	if len(header) < 2 || header[0] != '"' || header[len(header)-1] != '"' {
+		return nil, ErrInvalidIfMatch
+	}
+
+	version, err := strconv.ParseInt(header[1:len(header)-1], 10, 64)
+	if err != nil || version < 1 {
+		return nil, ErrInvalidIfMatch
+	}
+
+	return &version, nil
+}
+
+// NoneMatch проверяет, есть ли etag в If-None-Match, то есть актуальна ли копия клиента.
+// Сравнение слабое: префикс W/ не учитывается.
+func NoneMatch(header string, etag string) bool {
+	header = strings.TrimSpace(header)
+	if header == "" {
+		return false
+	}
+	if header == "*" {
+		return true
+	}
+
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(header, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(candidate), "W/") == etag {
+			return true
+		}
+	}
+
+	return false
+}
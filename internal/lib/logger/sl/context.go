@@ -0,0 +1,23 @@
+package sl
+
+import (
+	"context"
+
+	"golang.org/x/exp/slog"
+)
+
+type ctxKey struct{}
+
+// WithLogger кладет в контекст логгер запроса, чтобы нижние слои писали в лог с request_id и trace_id
+func WithLogger(ctx context.Context, log *slog.Logger) context.Context {
+	return context.WithValue(ctx, ctxKey{}, log)
+}
+
+// FromContext возвращает логгер запроса из контекста или fallback, если запрос идет не из HTTP-обработчика
+func FromContext(ctx context.Context, fallback *slog.Logger) *slog.Logger {
+	if log, ok := ctx.Value(ctxKey{}).(*slog.Logger); ok {
+		return log
+	}
+
+	return fallback
+}
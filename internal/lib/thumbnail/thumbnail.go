@@ -0,0 +1,43 @@
+package thumbnail
+
+import (
+	"image"
+	"image/color"
+)
+
+// Resize уменьшает изображение так, чтобы большая сторона не превышала maxSize, методом ближайшего соседа.
+// Изображения, которые уже меньше maxSize, возвращаются как есть.
+func Resize(src image.Image, maxSize int) image.Image {
+	bounds := src.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+
+	if maxSize <= 0 || (width <= maxSize && height <= maxSize) {
+		return src
+	}
+
+	dstWidth, dstHeight := maxSize, maxSize
+	if width > height {
+		dstHeight = max(1, height*maxSize/width)
+	} else {
+		dstWidth = max(1, width*maxSize/height)
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dstWidth, dstHeight))
+
+	for y := 0; y < dstHeight; y++ {
+		srcY := bounds.Min.Y + y*height/dstHeight
+		for x := 0; x < dstWidth; x++ {
+			srcX := bounds.Min.X + x*width/dstWidth
+			dst.Set(x, y, color.RGBAModel.Convert(src.At(srcX, srcY)))
+		}
+	}
+
+	return dst
+}
+
+func max(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
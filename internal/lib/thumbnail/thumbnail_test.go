@@ -0,0 +1,29 @@
+package thumbnail
+
+import (
+	"image"
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestResize(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 400, 100))
+	for x := 200; x < 400; x++ {
+		for y := 0; y < 100; y++ {
+			src.Set(x, y, color.RGBA{R: 255, A: 255})
+		}
+	}
+
+	dst := Resize(src, 100)
+	require.Equal(t, image.Rect(0, 0, 100, 25), dst.Bounds())
+	require.Equal(t, color.RGBA{A: 0}, dst.At(10, 10))
+	require.Equal(t, color.RGBA{R: 255, A: 255}, dst.At(90, 10))
+
+	small := image.NewRGBA(image.Rect(0, 0, 50, 80))
+	require.Same(t, small, Resize(small, 100))
+
+	tall := Resize(image.NewRGBA(image.Rect(0, 0, 10, 1000)), 100)
+	require.Equal(t, image.Rect(0, 0, 1, 100), tall.Bounds())
+}
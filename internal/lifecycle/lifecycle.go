@@ -0,0 +1,164 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"golang.org/x/exp/slog"
+
+	"realty-avito/internal/lib/logger/sl"
+)
+
+// Worker фоновый процесс, который работает до отмены ctx, переданного в Start
+type Worker interface {
+	Start(ctx context.Context)
+	Wait()
+}
+
+type closer struct {
+	name  string
+	close func(ctx context.Context) error
+}
+
+// Lifecycle останавливает приложение по SIGINT/SIGTERM: сначала экземпляр перестает быть готовым
+// и ждет shutdownDelay, чтобы балансировщик успел убрать его из ротации, затем HTTP-сервер перестает
+// принимать соединения и дожидается текущих запросов, после чего останавливаются воркеры и закрываются ресурсы.
+// Воркеры и ресурсы останавливаются в порядке, обратном регистрации, как defer:
+// то, что зарегистрировано первым (пул Postgres), закрывается последним.
+type Lifecycle struct {
+	log             *slog.Logger
+	shutdownDelay   time.Duration
+	shutdownTimeout time.Duration
+	closers         []closer
+
+	shuttingDown atomic.Bool
+}
+
+func New(log *slog.Logger, shutdownDelay time.Duration, shutdownTimeout time.Duration) *Lifecycle {
+	return &Lifecycle{
+		log:             log.With(slog.String("component", "lifecycle")),
+		shutdownDelay:   shutdownDelay,
+		shutdownTimeout: shutdownTimeout,
+	}
+}
+
+// ShuttingDown сообщает, что началась остановка и новый трафик принимать не нужно
+func (l *Lifecycle) ShuttingDown() bool {
+	return l.shuttingDown.Load()
+}
+
+// AddCloser регистрирует ресурс, который закрывается при остановке
+func (l *Lifecycle) AddCloser(name string, c io.Closer) {
+	l.AddFunc(name, func(context.Context) error {
+		return c.Close()
+	})
+}
+
+// AddFunc регистрирует функцию остановки, которая должна уложиться в срок из ctx
+func (l *Lifecycle) AddFunc(name string, fn func(ctx context.Context) error) {
+	l.closers = append(l.closers, closer{name: name, close: fn})
+}
+
+// StartWorker запускает воркер с собственным контекстом. При остановке контекст отменяется,
+// и Lifecycle ждет завершения воркера, но не дольше общего срока остановки.
+func (l *Lifecycle) StartWorker(ctx context.Context, name string, worker Worker) {
+	workerCtx, cancel := context.WithCancel(ctx)
+	worker.Start(workerCtx)
+
+	l.closers = append(l.closers, closer{
+		name: name,
+		close: func(ctx context.Context) error {
+			cancel()
+
+			stopped := make(chan struct{})
+			go func() {
+				worker.Wait()
+				close(stopped)
+			}()
+
+			select {
+			case <-stopped:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		},
+	})
+}
+
+// Run запускает сервер и блокируется до сигнала остановки, отмены ctx или ошибки сервера,
+// после чего останавливает приложение. Возвращает ошибку сервера, если он упал сам.
+func (l *Lifecycle) Run(ctx context.Context, srv *http.Server) error {
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- srv.ListenAndServe()
+	}()
+
+	var err error
+
+	select {
+	case <-ctx.Done():
+		l.log.Info("shutdown signal received")
+	case err = <-serveErr:
+		if errors.Is(err, http.ErrServerClosed) {
+			err = nil
+		}
+		if err != nil {
+			l.log.Error("server stopped unexpectedly", sl.Err(err))
+		}
+	}
+
+	// повторный сигнал завершает процесс сразу, не дожидаясь остановки
+	stop()
+
+	l.Shutdown(srv)
+
+	return err
+}
+
+// Shutdown останавливает сервер, затем воркеры и ресурсы. Все шаги после shutdownDelay укладываются
+// в shutdownTimeout, но ресурсы закрываются, даже если срок вышел, чтобы не оставить открытыми соединения с базой.
+func (l *Lifecycle) Shutdown(srv *http.Server) {
+	start := time.Now()
+
+	l.shuttingDown.Store(true)
+
+	if l.shutdownDelay > 0 {
+		l.log.Info("waiting for load balancer to stop routing traffic", slog.Duration("delay", l.shutdownDelay))
+		time.Sleep(l.shutdownDelay)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), l.shutdownTimeout)
+	defer cancel()
+
+	if err := srv.Shutdown(ctx); err != nil {
+		l.log.Error("failed to drain http server, closing remaining connections", sl.Err(err))
+
+		if err := srv.Close(); err != nil {
+			l.log.Error("failed to close http server", sl.Err(err))
+		}
+	}
+
+	for i := len(l.closers) - 1; i >= 0; i-- {
+		c := l.closers[i]
+
+		if err := c.close(ctx); err != nil {
+			l.log.Error("failed to stop", slog.String("name", c.name), sl.Err(err))
+			continue
+		}
+
+		l.log.Debug("stopped", slog.String("name", c.name))
+	}
+
+	l.log.Info("shutdown completed", slog.Duration("duration", time.Since(start)))
+}
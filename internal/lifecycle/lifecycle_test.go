@@ -0,0 +1,111 @@
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"realty-avito/internal/lib/logger"
+)
+
+type recorder struct {
+	mu    sync.Mutex
+	order []string
+}
+
+func (r *recorder) add(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.order = append(r.order, name)
+}
+
+type testWorker struct {
+	name     string
+	recorder *recorder
+	wg       sync.WaitGroup
+}
+
+func (w *testWorker) Start(ctx context.Context) {
+	w.wg.Add(1)
+	go func() {
+		defer w.wg.Done()
+		<-ctx.Done()
+		w.recorder.add(w.name)
+	}()
+}
+
+func (w *testWorker) Wait() {
+	w.wg.Wait()
+}
+
+type testCloser struct {
+	name     string
+	recorder *recorder
+	err      error
+}
+
+func (c *testCloser) Close() error {
+	c.recorder.add(c.name)
+	return c.err
+}
+
+func TestLifecycleRun(t *testing.T) {
+	rec := &recorder{}
+	app := New(logger.SetupLogger("local"), 0, time.Second)
+
+	app.AddCloser("postgres", &testCloser{name: "postgres", recorder: rec})
+	app.StartWorker(context.Background(), "notifier", &testWorker{name: "notifier", recorder: rec})
+	app.AddCloser("publisher", &testCloser{name: "publisher", recorder: rec, err: errors.New("already closed")})
+	app.StartWorker(context.Background(), "relay", &testWorker{name: "relay", recorder: rec})
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	addr := listener.Addr().String()
+	require.NoError(t, listener.Close())
+
+	requestStarted := make(chan struct{})
+	srv := &http.Server{
+		Addr: addr,
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			close(requestStarted)
+			// запрос еще выполняется, когда приходит сигнал остановки
+			time.Sleep(100 * time.Millisecond)
+			rec.add("request")
+			w.WriteHeader(http.StatusCreated)
+		}),
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	runErr := make(chan error, 1)
+	go func() {
+		runErr <- app.Run(ctx, srv)
+	}()
+
+	responseCode := make(chan int, 1)
+	go func() {
+		// сервер запускается асинхронно, ждем, пока он начнет принимать соединения
+		for i := 0; i < 100; i++ {
+			resp, err := http.Post("http://"+addr, "text/plain", nil)
+			if err == nil {
+				_ = resp.Body.Close()
+				responseCode <- resp.StatusCode
+				return
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		responseCode <- 0
+	}()
+
+	<-requestStarted
+	cancel()
+
+	require.NoError(t, <-runErr)
+	require.Equal(t, http.StatusCreated, <-responseCode)
+	require.Equal(t, []string{"request", "relay", "publisher", "notifier", "postgres"}, rec.order)
+}
@@ -0,0 +1,67 @@
+package metrics
+
+import (
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+const namespace = "realty"
+
+// UnmatchedRoute метка маршрута для запросов, не попавших ни в один маршрут,
+// чтобы произвольные пути не раздували число временных рядов
+const UnmatchedRoute = "unmatched"
+
+var (
+	HTTPRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "HTTP request duration by chi route pattern and response status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	DBQueryDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "db",
+		Name:      "query_duration_seconds",
+		Help:      "SQL query duration by db.Query name.",
+		Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5},
+	}, []string{"query"})
+
+	DBQueryErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "db",
+		Name:      "query_errors_total",
+		Help:      "SQL queries that failed, by db.Query name. pgx.ErrNoRows is not counted.",
+	}, []string{"query"})
+
+	FlatsCreated = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "flats_created_total",
+		Help:      "Flats created, by source: api or import.",
+	}, []string{"source"})
+
+	ModerationDecisions = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "moderation_decisions_total",
+		Help:      "Flat status changes made by moderators, by resulting status.",
+	}, []string{"status"})
+)
+
+const (
+	SourceAPI    = "api"
+	SourceImport = "import"
+)
+
+// ObserveQuery записывает длительность запроса с момента start и ошибку, если она есть
+func ObserveQuery(name string, start time.Time, err error) {
+	DBQueryDuration.WithLabelValues(name).Observe(time.Since(start).Seconds())
+
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		DBQueryErrors.WithLabelValues(name).Inc()
+	}
+}
@@ -0,0 +1,21 @@
+package metrics
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v4"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestObserveQuery(t *testing.T) {
+	const name = "flatsRepository.TestObserveQuery"
+
+	ObserveQuery(name, time.Now(), nil)
+	ObserveQuery(name, time.Now(), pgx.ErrNoRows)
+	ObserveQuery(name, time.Now(), errors.New("connection reset"))
+
+	require.Equal(t, 1.0, testutil.ToFloat64(DBQueryErrors.WithLabelValues(name)))
+}
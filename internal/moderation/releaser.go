@@ -0,0 +1,75 @@
+package moderation
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"golang.org/x/exp/slog"
+
+	"realty-avito/internal/config"
+	"realty-avito/internal/lib/logger/sl"
+	"realty-avito/internal/repositories/flatsRepo"
+)
+
+type StaleClaimsReleaser interface {
+	ReleaseStaleClaims(ctx context.Context, claimTimeout time.Duration) ([]flatsRepo.FlatEntity, error)
+}
+
+// Releaser периодически возвращает в очередь квартиры, которые модератор взял и не закончил проверять
+type Releaser struct {
+	log          *slog.Logger
+	flats        StaleClaimsReleaser
+	claimTimeout time.Duration
+	interval     time.Duration
+
+	wg sync.WaitGroup
+}
+
+func NewReleaser(log *slog.Logger, flats StaleClaimsReleaser, cfg config.ModerationConfig) *Releaser {
+	return &Releaser{
+		log:          log.With(slog.String("component", "moderation/releaser")),
+		flats:        flats,
+		claimTimeout: cfg.ClaimTimeout,
+		interval:     cfg.ReleaseInterval,
+	}
+}
+
+// Start запускает фоновую проверку, которая работает до отмены ctx
+func (r *Releaser) Start(ctx context.Context) {
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		r.run(ctx)
+	}()
+}
+
+// Wait дожидается остановки фоновой проверки
+func (r *Releaser) Wait() {
+	r.wg.Wait()
+}
+
+func (r *Releaser) run(ctx context.Context) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		released, err := r.flats.ReleaseStaleClaims(ctx, r.claimTimeout)
+		if err != nil {
+			if ctx.Err() == nil {
+				r.log.Error("failed to release stale claims", sl.Err(err))
+			}
+			continue
+		}
+
+		for _, flat := range released {
+			r.log.Info("stale claim released", slog.Int64("flat_id", flat.ID))
+		}
+	}
+}
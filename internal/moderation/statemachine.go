@@ -0,0 +1,56 @@
+package moderation
+
+import (
+	"realty-avito/internal/repositories/flatsRepo"
+)
+
+// transitions допустимые переходы статусов модерации квартиры:
+// created -> on moderation -> approved / declined
+var transitions = map[flatsRepo.FlatModerationStatus][]flatsRepo.FlatModerationStatus{
+	flatsRepo.StatusCreated:      {flatsRepo.StatusOnModeration},
+	flatsRepo.StatusOnModeration: {flatsRepo.StatusApproved, flatsRepo.StatusDeclined},
+}
+
+// CanTransition проверяет, можно ли перевести квартиру из статуса from в статус to
+func CanTransition(from, to flatsRepo.FlatModerationStatus) bool {
+	for _, next := range transitions[from] {
+		if next == to {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Sources возвращает статусы, из которых допустим переход в статус to.
+// Пустой результат означает, что в статус to перейти нельзя вообще.
+func Sources(to flatsRepo.FlatModerationStatus) []flatsRepo.FlatModerationStatus {
+	var sources []flatsRepo.FlatModerationStatus
+
+	for from := range transitions {
+		if CanTransition(from, to) {
+			sources = append(sources, from)
+		}
+	}
+
+	return sources
+}
+
+// RequiresOwner сообщает, что переход из статуса from может выполнить только модератор,
+// который взял квартиру на модерацию
+func RequiresOwner(from flatsRepo.FlatModerationStatus) bool {
+	return from == flatsRepo.StatusOnModeration
+}
+
+// priceChangeSources статусы, из которых можно изменить цену квартиры. Изменение цены возвращает
+// квартиру в статус created; квартиру, которую сейчас проверяет модератор, менять нельзя.
+var priceChangeSources = []flatsRepo.FlatModerationStatus{
+	flatsRepo.StatusCreated,
+	flatsRepo.StatusApproved,
+	flatsRepo.StatusDeclined,
+}
+
+// PriceChangeSources возвращает статусы, из которых допустимо изменение цены
+func PriceChangeSources() []flatsRepo.FlatModerationStatus {
+	return append([]flatsRepo.FlatModerationStatus(nil), priceChangeSources...)
+}
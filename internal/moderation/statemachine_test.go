@@ -0,0 +1,38 @@
+package moderation
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"realty-avito/internal/repositories/flatsRepo"
+)
+
+func TestCanTransition(t *testing.T) {
+	tests := []struct {
+		from     flatsRepo.FlatModerationStatus
+		to       flatsRepo.FlatModerationStatus
+		expected bool
+	}{
+		{flatsRepo.StatusCreated, flatsRepo.StatusOnModeration, true},
+		{flatsRepo.StatusOnModeration, flatsRepo.StatusApproved, true},
+		{flatsRepo.StatusOnModeration, flatsRepo.StatusDeclined, true},
+		{flatsRepo.StatusCreated, flatsRepo.StatusApproved, false},
+		{flatsRepo.StatusApproved, flatsRepo.StatusCreated, false},
+		{flatsRepo.StatusApproved, flatsRepo.StatusOnModeration, false},
+		{flatsRepo.StatusDeclined, flatsRepo.StatusApproved, false},
+		{flatsRepo.StatusOnModeration, flatsRepo.StatusOnModeration, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(string(tt.from)+" -> "+string(tt.to), func(t *testing.T) {
+			require.Equal(t, tt.expected, CanTransition(tt.from, tt.to))
+		})
+	}
+}
+
+func TestSources(t *testing.T) {
+	require.Equal(t, []flatsRepo.FlatModerationStatus{flatsRepo.StatusOnModeration}, Sources(flatsRepo.StatusApproved))
+	require.Equal(t, []flatsRepo.FlatModerationStatus{flatsRepo.StatusCreated}, Sources(flatsRepo.StatusOnModeration))
+	require.Empty(t, Sources(flatsRepo.StatusCreated))
+}
@@ -0,0 +1,155 @@
+package notifier
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"golang.org/x/exp/slog"
+
+	"realty-avito/internal/config"
+	"realty-avito/internal/lib/logger/sl"
+	"realty-avito/internal/repositories/flatsRepo"
+	"realty-avito/internal/repositories/subscriptionsRepo"
+	"realty-avito/internal/sender"
+)
+
+type SubscriptionsGetter interface {
+	GetSubscriptionsByHouseID(ctx context.Context, houseID int64) ([]subscriptionsRepo.SubscriptionEntity, error)
+}
+
+// Notifier в фоне рассылает подписчикам дома уведомления о новых квартирах.
+// HTTP-обработчик только кладет квартиру в очередь и не ждет отправки писем.
+type Notifier struct {
+	log           *slog.Logger
+	sender        sender.Sender
+	subscriptions SubscriptionsGetter
+
+	queue        chan flatsRepo.FlatEntity
+	workers      int
+	maxRetries   int
+	retryBackoff time.Duration
+
+	wg sync.WaitGroup
+}
+
+func New(log *slog.Logger, sender sender.Sender, subscriptions SubscriptionsGetter, cfg config.NotifierConfig) *Notifier {
+	return &Notifier{
+		log:           log.With(slog.String("component", "notifier")),
+		sender:        sender,
+		subscriptions: subscriptions,
+		queue:         make(chan flatsRepo.FlatEntity, cfg.QueueSize),
+		workers:       cfg.Workers,
+		maxRetries:    cfg.MaxRetries,
+		retryBackoff:  cfg.RetryBackoff,
+	}
+}
+
+// Start запускает воркеры, которые работают до отмены ctx
+func (n *Notifier) Start(ctx context.Context) {
+	for i := 0; i < n.workers; i++ {
+		n.wg.Add(1)
+		go func() {
+			defer n.wg.Done()
+			n.run(ctx)
+		}()
+	}
+}
+
+// Wait дожидается завершения всех воркеров
+func (n *Notifier) Wait() {
+	n.wg.Wait()
+}
+
+// NotifyFlatApproved ставит уведомление в очередь. Если очередь переполнена, уведомление отбрасывается,
+// чтобы не блокировать обработку запроса.
+func (n *Notifier) NotifyFlatApproved(flat flatsRepo.FlatEntity) {
+	select {
+	case n.queue <- flat:
+	default:
+		n.log.Warn("notification queue is full, notification dropped",
+			slog.Int64("flat_id", flat.ID),
+			slog.Int64("house_id", flat.HouseID),
+		)
+	}
+}
+
+func (n *Notifier) run(ctx context.Context) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case flat := <-n.queue:
+			n.notify(ctx, flat)
+		}
+	}
+}
+
+func (n *Notifier) notify(ctx context.Context, flat flatsRepo.FlatEntity) {
+	log := n.log.With(
+		slog.Int64("flat_id", flat.ID),
+		slog.Int64("house_id", flat.HouseID),
+	)
+
+	var subscriptions []subscriptionsRepo.SubscriptionEntity
+
+	err := n.withRetries(ctx, func() error {
+		var err error
+		subscriptions, err = n.subscriptions.GetSubscriptionsByHouseID(ctx, flat.HouseID)
+		return err
+	})
+	if err != nil {
+		log.Error("failed to get subscriptions", sl.Err(err))
+		return
+	}
+
+	message := fmt.Sprintf("В доме %d появилась новая квартира %d: комнат %d, цена %d",
+		flat.HouseID, flat.ID, flat.Rooms, flat.Price)
+
+	for _, subscription := range subscriptions {
+		email := subscription.Email
+
+		err := n.withRetries(ctx, func() error {
+			return n.sender.SendEmail(ctx, email, message)
+		})
+		if err != nil {
+			log.Error("failed to send notification", slog.String("email", email), sl.Err(err))
+			continue
+		}
+
+		log.Debug("notification sent", slog.String("email", email))
+	}
+}
+
+// withRetries повторяет fn с экспоненциальной задержкой, пока не исчерпаны попытки или не отменен ctx
+func (n *Notifier) withRetries(ctx context.Context, fn func() error) error {
+	backoff := n.retryBackoff
+
+	var err error
+	for attempt := 0; attempt <= n.maxRetries; attempt++ {
+		if err = fn(); err == nil {
+			return nil
+		}
+
+		if attempt == n.maxRetries {
+			break
+		}
+
+		n.log.Warn("attempt failed, retrying",
+			slog.Int("attempt", attempt+1),
+			slog.String("backoff", backoff.String()),
+			sl.Err(err),
+		)
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+
+		backoff *= 2
+	}
+
+	return err
+}
@@ -0,0 +1,76 @@
+package notifier
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"realty-avito/internal/config"
+	"realty-avito/internal/lib/logger"
+	"realty-avito/internal/repositories/flatsRepo"
+	"realty-avito/internal/repositories/subscriptionsRepo"
+	"realty-avito/internal/repositories/subscriptionsRepo/mocks"
+)
+
+type flakySender struct {
+	mu       sync.Mutex
+	failures int
+	sent     []string
+	done     chan struct{}
+}
+
+func (s *flakySender) SendEmail(_ context.Context, email string, _ string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.failures > 0 {
+		s.failures--
+		return errors.New("smtp is unavailable")
+	}
+
+	s.sent = append(s.sent, email)
+	if len(s.sent) == 2 {
+		close(s.done)
+	}
+
+	return nil
+}
+
+func TestNotifierRetriesFailedSends(t *testing.T) {
+	mockSubscriptionsRepo := new(mocks.SubscriptionsRepository)
+	mockSubscriptionsRepo.On("GetSubscriptionsByHouseID", mock.Anything, int64(1)).Return([]subscriptionsRepo.SubscriptionEntity{
+		{ID: 1, HouseID: 1, Email: "first@example.com"},
+		{ID: 2, HouseID: 1, Email: "second@example.com"},
+	}, nil).Once()
+
+	sender := &flakySender{failures: 2, done: make(chan struct{})}
+
+	n := New(logger.SetupLogger("local"), sender, mockSubscriptionsRepo, config.NotifierConfig{
+		Workers:      1,
+		QueueSize:    1,
+		MaxRetries:   3,
+		RetryBackoff: time.Millisecond,
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	n.Start(ctx)
+
+	n.NotifyFlatApproved(flatsRepo.FlatEntity{ID: 10, HouseID: 1, Price: 100, Rooms: 2})
+
+	select {
+	case <-sender.done:
+	case <-time.After(time.Second):
+		t.Fatal("notifications were not delivered")
+	}
+
+	cancel()
+	n.Wait()
+
+	require.Equal(t, []string{"first@example.com", "second@example.com"}, sender.sent)
+	mockSubscriptionsRepo.AssertExpectations(t)
+}
@@ -0,0 +1,148 @@
+package outbox
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"realty-avito/internal/config"
+)
+
+const (
+	PublisherChannel = "channel"
+	PublisherFile    = "file"
+	PublisherWebhook = "webhook"
+)
+
+var ErrChannelFull = errors.New("outbox channel is full")
+
+// Event событие из outbox, которое отдается во внешний мир
+type Event struct {
+	ID            int64           `json:"id"`
+	AggregateType string          `json:"aggregate_type"`
+	AggregateID   int64           `json:"aggregate_id"`
+	EventType     string          `json:"event_type"`
+	Payload       json.RawMessage `json:"payload"`
+	CreatedAt     time.Time       `json:"created_at"`
+}
+
+// EventPublisher доставляет событие потребителям. Событие считается доставленным, только если вернулся nil.
+type EventPublisher interface {
+	Publish(ctx context.Context, event Event) error
+}
+
+// NewPublisher создает публикатор по настройкам из конфига
+func NewPublisher(cfg config.OutboxConfig) (EventPublisher, error) {
+	switch cfg.Publisher {
+	case PublisherChannel:
+		return NewChannelPublisher(cfg.ChannelSize), nil
+	case PublisherFile:
+		return NewFilePublisher(cfg.FilePath)
+	case PublisherWebhook:
+		return NewWebhookPublisher(cfg.WebhookURL, cfg.WebhookTimeout), nil
+	default:
+		return nil, fmt.Errorf("unknown outbox publisher: %q", cfg.Publisher)
+	}
+}
+
+// ChannelPublisher отдает события внутри процесса через канал
+type ChannelPublisher struct {
+	events chan Event
+}
+
+func NewChannelPublisher(size int) *ChannelPublisher {
+	return &ChannelPublisher{events: make(chan Event, size)}
+}
+
+func (p *ChannelPublisher) Publish(ctx context.Context, event Event) error {
+	select {
+	case p.events <- event:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	default:
+		return ErrChannelFull
+	}
+}
+
+func (p *ChannelPublisher) Events() <-chan Event {
+	return p.events
+}
+
+// FilePublisher дописывает события в файл в формате NDJSON
+type FilePublisher struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+func NewFilePublisher(filePath string) (*FilePublisher, error) {
+	file, err := os.OpenFile(filePath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open outbox file %s: %w", filePath, err)
+	}
+
+	return &FilePublisher{file: file}, nil
+}
+
+func (p *FilePublisher) Publish(_ context.Context, event Event) error {
+	line, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	_, err = p.file.Write(append(line, '\n'))
+	return err
+}
+
+func (p *FilePublisher) Close() error {
+	return p.file.Close()
+}
+
+// WebhookPublisher отправляет каждое событие POST-запросом на указанный URL
+type WebhookPublisher struct {
+	url    string
+	client *http.Client
+}
+
+func NewWebhookPublisher(url string, timeout time.Duration) *WebhookPublisher {
+	return &WebhookPublisher{
+		url:    url,
+		client: &http.Client{Timeout: timeout},
+	}
+}
+
+func (p *WebhookPublisher) Publish(ctx context.Context, event Event) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, p.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Idempotency-Key", fmt.Sprintf("outbox-%d", event.ID))
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,143 @@
+package outbox
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"golang.org/x/exp/slog"
+
+	"realty-avito/internal/client/db"
+	"realty-avito/internal/config"
+	"realty-avito/internal/lib/logger/sl"
+	"realty-avito/internal/repositories/outboxRepo"
+)
+
+// Relay периодически забирает недоставленные события из outbox и публикует их.
+// События доставляются строго в порядке записи: если событие не удалось опубликовать,
+// следующие за ним ждут, пока оно не будет доставлено. Порядок id совпадает с порядком коммитов,
+// потому что outboxRepo.AddEvent сериализует запись событий.
+type Relay struct {
+	log       *slog.Logger
+	txManager db.TxManager
+	repo      outboxRepo.OutboxRepository
+	publisher EventPublisher
+
+	pollInterval time.Duration
+	batchSize    int
+	retryBackoff time.Duration
+	maxBackoff   time.Duration
+
+	wg sync.WaitGroup
+}
+
+func NewRelay(log *slog.Logger, txManager db.TxManager, repo outboxRepo.OutboxRepository, publisher EventPublisher, cfg config.OutboxConfig) *Relay {
+	return &Relay{
+		log:          log.With(slog.String("component", "outbox/relay")),
+		txManager:    txManager,
+		repo:         repo,
+		publisher:    publisher,
+		pollInterval: cfg.PollInterval,
+		batchSize:    cfg.BatchSize,
+		retryBackoff: cfg.RetryBackoff,
+		maxBackoff:   cfg.MaxBackoff,
+	}
+}
+
+// Start запускает релей, который работает до отмены ctx
+func (r *Relay) Start(ctx context.Context) {
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+		r.run(ctx)
+	}()
+}
+
+// Wait дожидается остановки релея
+func (r *Relay) Wait() {
+	r.wg.Wait()
+}
+
+func (r *Relay) run(ctx context.Context) {
+	ticker := time.NewTicker(r.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		if err := r.processBatch(ctx); err != nil && ctx.Err() == nil {
+			r.log.Error("failed to process outbox batch", sl.Err(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (r *Relay) processBatch(ctx context.Context) error {
+	return r.txManager.ReadCommitted(ctx, func(ctx context.Context) error {
+		locked, err := r.repo.TryLockRelay(ctx)
+		if err != nil {
+			return err
+		}
+
+		// события уже разбирает другой экземпляр приложения
+		if !locked {
+			return nil
+		}
+
+		events, err := r.repo.GetPendingEvents(ctx, r.batchSize)
+		if err != nil {
+			return err
+		}
+
+		for _, entity := range events {
+			// голова очереди ждет повторной попытки - дальше не идем, чтобы не нарушить порядок
+			if !entity.Ready {
+				return nil
+			}
+
+			err := r.publisher.Publish(ctx, Event{
+				ID:            entity.ID,
+				AggregateType: entity.AggregateType,
+				AggregateID:   entity.AggregateID,
+				EventType:     entity.EventType,
+				Payload:       entity.Payload,
+				CreatedAt:     entity.CreatedAt,
+			})
+			if err != nil {
+				retryAfter := r.backoff(entity.Attempts)
+
+				r.log.Warn("failed to publish outbox event",
+					slog.Int64("event_id", entity.ID),
+					slog.Int("attempt", entity.Attempts+1),
+					slog.String("retry_after", retryAfter.String()),
+					sl.Err(err),
+				)
+
+				return r.repo.MarkFailed(ctx, entity.ID, retryAfter, err.Error())
+			}
+
+			if err := r.repo.MarkDelivered(ctx, entity.ID); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// backoff экспоненциальная задержка перед следующей попыткой, ограниченная maxBackoff
+func (r *Relay) backoff(attempts int) time.Duration {
+	backoff := r.retryBackoff
+	for i := 0; i < attempts && backoff < r.maxBackoff; i++ {
+		backoff *= 2
+	}
+
+	if backoff > r.maxBackoff {
+		return r.maxBackoff
+	}
+
+	return backoff
+}
@@ -0,0 +1,69 @@
+package outbox
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"realty-avito/internal/client/db"
+	"realty-avito/internal/config"
+	"realty-avito/internal/lib/logger"
+	"realty-avito/internal/repositories/outboxRepo"
+	"realty-avito/internal/repositories/outboxRepo/mocks"
+)
+
+type noTxManager struct{}
+
+func (noTxManager) ReadCommitted(ctx context.Context, f db.Handler) error {
+	return f(ctx)
+}
+
+type recordingPublisher struct {
+	failOn    int64
+	published []int64
+}
+
+func (p *recordingPublisher) Publish(_ context.Context, event Event) error {
+	if event.ID == p.failOn {
+		return errors.New("consumer is down")
+	}
+
+	p.published = append(p.published, event.ID)
+	return nil
+}
+
+func TestRelayStopsBatchOnFailedEvent(t *testing.T) {
+	mockOutboxRepo := new(mocks.OutboxRepository)
+	mockOutboxRepo.On("TryLockRelay", mock.Anything).Return(true, nil).Once()
+	mockOutboxRepo.On("GetPendingEvents", mock.Anything, 10).Return([]outboxRepo.EventEntity{
+		{ID: 1, EventType: "flat.created", Payload: []byte(`{}`), Ready: true},
+		{ID: 2, EventType: "flat.created", Payload: []byte(`{}`), Ready: true, Attempts: 2},
+		{ID: 3, EventType: "flat.status_changed", Payload: []byte(`{}`), Ready: true},
+	}, nil).Once()
+	mockOutboxRepo.On("MarkDelivered", mock.Anything, int64(1)).Return(nil).Once()
+	mockOutboxRepo.On("MarkFailed", mock.Anything, int64(2), 4*time.Second, "consumer is down").Return(nil).Once()
+
+	publisher := &recordingPublisher{failOn: 2}
+
+	relay := NewRelay(logger.SetupLogger("local"), noTxManager{}, mockOutboxRepo, publisher, config.OutboxConfig{
+		BatchSize:    10,
+		RetryBackoff: time.Second,
+		MaxBackoff:   time.Minute,
+	})
+
+	require.NoError(t, relay.processBatch(context.Background()))
+	require.Equal(t, []int64{1}, publisher.published)
+	mockOutboxRepo.AssertExpectations(t)
+}
+
+func TestRelayBackoffIsCapped(t *testing.T) {
+	relay := &Relay{retryBackoff: time.Second, maxBackoff: 10 * time.Second}
+
+	require.Equal(t, time.Second, relay.backoff(0))
+	require.Equal(t, 8*time.Second, relay.backoff(3))
+	require.Equal(t, 10*time.Second, relay.backoff(20))
+}
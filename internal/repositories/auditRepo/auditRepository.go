@@ -0,0 +1,59 @@
+package auditRepo
+
+import (
+	"context"
+
+	"github.com/Masterminds/squirrel"
+
+	"realty-avito/internal/client/db"
+)
+
+const (
+	tableName = "audit_log"
+
+	actorIDColumn    = "actor_id"
+	actionColumn     = "action"
+	entityTypeColumn = "entity_type"
+	entityIDColumn   = "entity_id"
+)
+
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=AuditRepository
+type AuditRepository interface {
+	AddRecord(ctx context.Context, createAuditRecordEntity CreateAuditRecordEntity) error
+}
+
+type auditRepository struct {
+	db db.Client
+}
+
+func NewAuditRepository(db db.Client) AuditRepository {
+	return &auditRepository{db: db}
+}
+
+// AddRecord пишет запись в журнал действий. Чтобы запись была атомарной с самим действием,
+// метод нужно вызывать внутри транзакции db.TxManager.
+func (r *auditRepository) AddRecord(ctx context.Context, createAuditRecordEntity CreateAuditRecordEntity) error {
+	insertBuilder := squirrel.
+		Insert(tableName).
+		PlaceholderFormat(squirrel.Dollar).
+		Columns(actorIDColumn, actionColumn, entityTypeColumn, entityIDColumn).
+		Values(
+			createAuditRecordEntity.ActorID,
+			createAuditRecordEntity.Action,
+			createAuditRecordEntity.EntityType,
+			createAuditRecordEntity.EntityID,
+		)
+
+	query, args, err := insertBuilder.ToSql()
+	if err != nil {
+		return err
+	}
+
+	q := db.Query{
+		Name:     "auditRepository.AddRecord",
+		QueryRaw: query,
+	}
+
+	_, err = r.db.DB().ExecContext(ctx, q, args...)
+	return err
+}
@@ -0,0 +1,44 @@
+// Code generated by mockery v2.28.2. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+	auditRepo "realty-avito/internal/repositories/auditRepo"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// AuditRepository is an autogenerated mock type for the AuditRepository type
+type AuditRepository struct {
+	mock.Mock
+}
+
+// AddRecord provides a mock function with given fields: ctx, createAuditRecordEntity
+func (_m *AuditRepository) AddRecord(ctx context.Context, createAuditRecordEntity auditRepo.CreateAuditRecordEntity) error {
+	ret := _m.Called(ctx, createAuditRecordEntity)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, auditRepo.CreateAuditRecordEntity) error); ok {
+		r0 = rf(ctx, createAuditRecordEntity)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+type mockConstructorTestingTNewAuditRepository interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewAuditRepository creates a new instance of AuditRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewAuditRepository(t mockConstructorTestingTNewAuditRepository) *AuditRepository {
+	mock := &AuditRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,16 @@
+package auditRepo
+
+const (
+	ActionDelete  = "delete"
+	ActionRestore = "restore"
+
+	EntityFlat  = "flat"
+	EntityHouse = "house"
+)
+
+type CreateAuditRecordEntity struct {
+	ActorID    string
+	Action     string
+	EntityType string
+	EntityID   int64
+}
@@ -2,11 +2,18 @@ package flatsRepo
 
 import (
 	"context"
-	"realty-avito/internal/errors"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
 
 	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v4"
 
 	"realty-avito/internal/client/db"
+	repo_errors "realty-avito/internal/errors"
+	"realty-avito/internal/repositories/outboxRepo"
 )
 
 const (
@@ -20,30 +27,84 @@ const (
 	moderatorIDColumn = "moderator_id"
 	createdAtColumn   = "created_at"
 	updatedAtColumn   = "updated_at"
+	claimedAtColumn   = "claimed_at"
+	deletedAtColumn   = "deleted_at"
+	flatNumberColumn  = "flat_number"
+	totalAreaColumn   = "total_area"
+	livingAreaColumn  = "living_area"
+	floorColumn       = "floor"
+	totalFloorsColumn = "total_floors"
+	layoutColumn      = "layout"
+	hasBalconyColumn  = "has_balcony"
+	descriptionColumn = "description"
+	versionColumn     = "version"
+
+	eventAggregateFlat     = "flat"
+	EventFlatCreated       = "flat.created"
+	EventFlatStatusChanged = "flat.status_changed"
+	EventFlatDeleted       = "flat.deleted"
+	EventFlatRestored      = "flat.restored"
+	EventFlatPriceChanged  = "flat.price_changed"
+
+	historyTableName = "flat_price_history"
+	photosTableName  = "flat_photos"
+	importTableName  = "flats_import"
 )
 
+// flatColumns колонки квартиры в том порядке, в котором их читает scanFlat
+var flatColumns = []string{
+	idColumn, houseIDColumn, priceColumn, roomsColumn, statusColumn, moderatorIDColumn, createdAtColumn, flatNumberColumn,
+	totalAreaColumn, livingAreaColumn, floorColumn, totalFloorsColumn, layoutColumn, hasBalconyColumn, descriptionColumn,
+	versionColumn,
+}
+
+var returningFlatColumns = "RETURNING " + strings.Join(flatColumns, ", ")
+
 //go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=FlatsRepository
 type FlatsRepository interface {
-	GetFlatsByHouseID(ctx context.Context, houseID int64) ([]FlatEntity, error)
+	GetFlatsByHouseID(ctx context.Context, houseID int64, page HouseFlatsPage) ([]FlatEntity, error)
 	GetFlatByFlatID(ctx context.Context, flatID int64) (*FlatEntity, error)
-	GetApprovedFlatsByHouseID(ctx context.Context, houseID int64) ([]FlatEntity, error)
+	GetApprovedFlatsByHouseID(ctx context.Context, houseID int64, page HouseFlatsPage) ([]FlatEntity, error)
+	GetHouseFlatsState(ctx context.Context, houseID int64, approvedOnly bool) (*HouseFlatsState, error)
 	CreateFlat(ctx context.Context, flatModel CreateFlatEntity) (*FlatEntity, error)
 	UpdateFlat(ctx context.Context, updateFlatModel UpdateFlatEntity) (*FlatEntity, error)
+	GetModerationQueue(ctx context.Context, limit uint64, after *QueuePosition) ([]FlatEntity, error)
+	ClaimFlats(ctx context.Context, moderatorID string, count uint64) ([]FlatEntity, error)
+	ReleaseStaleClaims(ctx context.Context, claimTimeout time.Duration) ([]FlatEntity, error)
+	SearchFlats(ctx context.Context, filter SearchFlatsFilter) ([]FlatEntity, error)
+	DeleteFlat(ctx context.Context, flatID int64) (*FlatEntity, error)
+	RestoreFlat(ctx context.Context, flatID int64) (*FlatEntity, error)
+	GetFlatByNumber(ctx context.Context, houseID int64, flatNumber int64) (*FlatEntity, error)
+	ChangeFlatPrice(ctx context.Context, changeFlatPriceEntity ChangeFlatPriceEntity) (*FlatEntity, error)
+	GetFlatHistory(ctx context.Context, flatID int64) ([]FlatHistoryEntity, error)
+	ImportFlats(ctx context.Context, houseID int64, flatEntities []CreateFlatEntity) ([]FlatEntity, error)
+	ExportFlats(ctx context.Context, filter SearchFlatsFilter, fn func(flat *ExportFlatEntity) error) error
+}
+
+// EventWriter пишет события об изменении квартир в outbox
+type EventWriter interface {
+	AddEvent(ctx context.Context, createEventEntity outboxRepo.CreateEventEntity) error
 }
 
 type flatsRepository struct {
-	db db.Client
+	db        db.Client
+	txManager db.TxManager
+	events    EventWriter
 }
 
-func NewFlatsRepository(db db.Client) FlatsRepository {
-	return &flatsRepository{db: db}
+func NewFlatsRepository(db db.Client, txManager db.TxManager, events EventWriter) FlatsRepository {
+	return &flatsRepository{db: db, txManager: txManager, events: events}
 }
 
-func (r *flatsRepository) GetFlatsByHouseID(ctx context.Context, houseID int64) ([]FlatEntity, error) {
+func (r *flatsRepository) GetFlatsByHouseID(ctx context.Context, houseID int64, page HouseFlatsPage) ([]FlatEntity, error) {
+	return r.getHouseFlatsPage(ctx, "flatsRepository.GetFlatsByHouseID", squirrel.Eq{houseIDColumn: houseID}, page)
+}
+
+func (r *flatsRepository) GetFlatByFlatID(ctx context.Context, flatID int64) (*FlatEntity, error) {
 	selectBuilder := squirrel.
-		Select(idColumn, houseIDColumn, priceColumn, roomsColumn, statusColumn).
+		Select(flatColumns...).
 		From(tableName).
-		Where(squirrel.Eq{houseIDColumn: houseID}).
+		Where(squirrel.Eq{idColumn: flatID, deletedAtColumn: nil}).
 		PlaceholderFormat(squirrel.Dollar)
 
 	query, args, err := selectBuilder.ToSql()
@@ -52,54 +113,177 @@ func (r *flatsRepository) GetFlatsByHouseID(ctx context.Context, houseID int64)
 	}
 
 	q := db.Query{
-		Name:     "flatsRepository.GetFlatsByHouseID",
+		Name:     "flatsRepository.GetFlatByFlatID",
 		QueryRaw: query,
 	}
 
-	rows, err := r.db.DB().QueryContext(ctx, q, args...)
+	var flat FlatEntity
+
+	err = scanFlat(r.db.DB().QueryRowContext(ctx, q, args...), &flat)
 	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, &repo_errors.ErrFlatNotFound{FlatID: flatID}
+		}
+
 		return nil, err
 	}
-	defer rows.Close()
 
-	var flats []FlatEntity
+	return &flat, nil
+}
 
-	for rows.Next() {
-		var flat FlatEntity
+func (r *flatsRepository) GetApprovedFlatsByHouseID(ctx context.Context, houseID int64, page HouseFlatsPage) ([]FlatEntity, error) {
+	where := squirrel.Eq{
+		houseIDColumn: houseID,
+		statusColumn:  StatusApproved,
+	}
 
-		err := rows.Scan(&flat.ID, &flat.HouseID, &flat.Price, &flat.Rooms, &flat.Status)
-		if err != nil {
-			return nil, err
+	return r.getHouseFlatsPage(ctx, "flatsRepository.GetApprovedFlatsByHouseID", where, page)
+}
+
+// GetHouseFlatsState считает сводку по квартирам неудаленного дома, видимым с approvedOnly или без.
+// Любое изменение квартиры увеличивает ее версию, поэтому меняется хеш пар (id, version) видимых квартир,
+// в том числе когда одна квартира выпадает из выборки, а другая в нее попадает. Фотографии при изменении
+// обновляют свою квартиру.
+func (r *flatsRepository) GetHouseFlatsState(ctx context.Context, houseID int64, approvedOnly bool) (*HouseFlatsState, error) {
+	joinCondition := "f.house_id = h.id AND f.deleted_at IS NULL"
+	var joinArgs []interface{}
+	if approvedOnly {
+		joinCondition += " AND f.status = ?"
+		joinArgs = append(joinArgs, StatusApproved)
+	}
+
+	selectBuilder := squirrel.
+		Select(
+			"h.version",
+			"COUNT(f.id)",
+			"COALESCE(md5(string_agg(f.id::text || ':' || f.version::text, ',' ORDER BY f.id)), '')",
+		).
+		From("houses h").
+		LeftJoin("flats f ON "+joinCondition, joinArgs...).
+		Where(squirrel.Eq{"h.id": houseID, "h.deleted_at": nil}).
+		GroupBy("h.version").
+		PlaceholderFormat(squirrel.Dollar)
+
+	query, args, err := selectBuilder.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	q := db.Query{
+		Name:     "flatsRepository.GetHouseFlatsState",
+		QueryRaw: query,
+	}
+
+	var state HouseFlatsState
+
+	err = r.db.DB().
+		QueryRowContext(ctx, q, args...).
+		Scan(&state.HouseVersion, &state.Count, &state.FlatsDigest)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, &repo_errors.ErrHouseNotFound{HouseID: houseID}
 		}
 
-		flats = append(flats, flat)
+		return nil, err
 	}
 
-	return flats, nil
+	return &state, nil
 }
 
-func (r *flatsRepository) GetFlatByFlatID(ctx context.Context, flatID int64) (*FlatEntity, error) {
+// getHouseFlatsPage отдает одну страницу квартир дома, пагинация по ключу (sort column, id)
+func (r *flatsRepository) getHouseFlatsPage(ctx context.Context, name string, where squirrel.Eq, page HouseFlatsPage) ([]FlatEntity, error) {
+	direction := "ASC"
+	comparison := ">"
+	if page.Desc {
+		direction = "DESC"
+		comparison = "<"
+	}
+
 	selectBuilder := squirrel.
-		Select(idColumn, houseIDColumn, priceColumn, roomsColumn, statusColumn, moderatorIDColumn).
+		Select(flatColumns...).
 		From(tableName).
-		Where(squirrel.Eq{idColumn: flatID}).
+		Where(where).
+		Where(squirrel.Eq{deletedAtColumn: nil}).
+		Limit(page.Limit).
 		PlaceholderFormat(squirrel.Dollar)
 
+	switch page.SortBy {
+	case HouseFlatsSortByPrice, HouseFlatsSortByRooms:
+		sortColumn := string(page.SortBy)
+		selectBuilder = selectBuilder.OrderBy(sortColumn+" "+direction, idColumn+" "+direction)
+
+		if page.After != nil {
+			selectBuilder = selectBuilder.Where(
+				squirrel.Expr("("+sortColumn+", id) "+comparison+" (?, ?)", page.After.Value, page.After.ID))
+		}
+	default:
+		selectBuilder = selectBuilder.OrderBy(idColumn + " " + direction)
+
+		if page.After != nil {
+			selectBuilder = selectBuilder.Where(squirrel.Expr("id "+comparison+" ?", page.After.ID))
+		}
+	}
+
 	query, args, err := selectBuilder.ToSql()
 	if err != nil {
 		return nil, err
 	}
 
 	q := db.Query{
-		Name:     "flatsRepository.GetFlatByFlatID",
+		Name:     name,
+		QueryRaw: query,
+	}
+
+	return r.queryFlats(ctx, q, args...)
+}
+
+func (r *flatsRepository) CreateFlat(ctx context.Context, flatEntity CreateFlatEntity) (*FlatEntity, error) {
+	insertBuilder := squirrel.
+		Insert(tableName).
+		PlaceholderFormat(squirrel.Dollar).
+		Columns(
+			houseIDColumn, priceColumn, roomsColumn, statusColumn, flatNumberColumn,
+			totalAreaColumn, livingAreaColumn, floorColumn, totalFloorsColumn, layoutColumn, hasBalconyColumn, descriptionColumn,
+		).
+		Values(
+			flatEntity.HouseID, flatEntity.Price, flatEntity.Rooms, StatusCreated, flatEntity.FlatNumber,
+			flatEntity.TotalArea, flatEntity.LivingArea, flatEntity.Floor, flatEntity.TotalFloors,
+			flatEntity.Layout, flatEntity.HasBalcony, flatEntity.Description,
+		).
+		Suffix(returningFlatColumns)
+
+	query, args, err := insertBuilder.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	q := db.Query{
+		Name:     "flatsRepository.CreateFlat",
 		QueryRaw: query,
 	}
 
 	var flat FlatEntity
 
-	err = r.db.DB().
-		QueryRowContext(ctx, q, args...).
-		Scan(&flat.ID, &flat.HouseID, &flat.Price, &flat.Rooms, &flat.Status, &flat.ModeratorID)
+	err = r.txManager.ReadCommitted(ctx, func(ctx context.Context) error {
+		errTx := scanFlat(r.db.DB().QueryRowContext(ctx, q, args...), &flat)
+		if errTx != nil {
+			if repo_errors.IsForeignKeyViolation(errTx) {
+				return &repo_errors.ErrHouseNotFound{HouseID: flatEntity.HouseID}
+			}
+
+			if repo_errors.IsUniqueViolation(errTx) && flatEntity.FlatNumber != nil {
+				return &repo_errors.ErrFlatNumberTaken{HouseID: flatEntity.HouseID, FlatNumber: *flatEntity.FlatNumber}
+			}
+
+			return errTx
+		}
+
+		if errTx = r.addHistoryRecord(ctx, &flat, ChangeCreated, nil); errTx != nil {
+			return errTx
+		}
+
+		return r.addFlatEvent(ctx, EventFlatCreated, &flat)
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -107,114 +291,785 @@ func (r *flatsRepository) GetFlatByFlatID(ctx context.Context, flatID int64) (*F
 	return &flat, nil
 }
 
-func (r *flatsRepository) GetApprovedFlatsByHouseID(ctx context.Context, houseID int64) ([]FlatEntity, error) {
+// UpdateFlat атомарно меняет статус квартиры, только если она находится в одном из статусов FromStatuses,
+// а квартиру на модерации может обновить только модератор, который ее взял.
+// Если задана ExpectedVersion, квартира должна быть еще и в этой версии.
+// Если условие не выполнено, возвращает repo_errors.ErrFlatStateConflict.
+func (r *flatsRepository) UpdateFlat(ctx context.Context, updateFlatEntity UpdateFlatEntity) (*FlatEntity, error) {
+	updateBuilder := squirrel.
+		Update(tableName).
+		Set(statusColumn, updateFlatEntity.Status).
+		Set(moderatorIDColumn, updateFlatEntity.ModeratorID).
+		Set(updatedAtColumn, updateFlatEntity.UpdatedAt).
+		Where(squirrel.Eq{
+			idColumn:        updateFlatEntity.ID,
+			statusColumn:    updateFlatEntity.FromStatuses,
+			deletedAtColumn: nil,
+		}).
+		Where(squirrel.Or{
+			squirrel.NotEq{statusColumn: StatusOnModeration},
+			squirrel.Eq{moderatorIDColumn: updateFlatEntity.ModeratorID},
+		}).
+		Suffix(returningFlatColumns).
+		PlaceholderFormat(squirrel.Dollar)
+
+	if updateFlatEntity.ExpectedVersion != nil {
+		updateBuilder = updateBuilder.Where(squirrel.Eq{versionColumn: *updateFlatEntity.ExpectedVersion})
+	}
+
+	// время взятия на модерацию нужно, чтобы вернуть в очередь забытые квартиры
+	if updateFlatEntity.Status == StatusOnModeration {
+		updateBuilder = updateBuilder.Set(claimedAtColumn, squirrel.Expr("CURRENT_TIMESTAMP"))
+	}
+
+	query, args, err := updateBuilder.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	q := db.Query{
+		Name:     "flatsRepository.UpdateFlat",
+		QueryRaw: query,
+	}
+
+	var flat FlatEntity
+
+	err = r.txManager.ReadCommitted(ctx, func(ctx context.Context) error {
+		errTx := scanFlat(r.db.DB().QueryRowContext(ctx, q, args...), &flat)
+		if errTx != nil {
+			if errors.Is(errTx, pgx.ErrNoRows) {
+				return repo_errors.ErrFlatStateConflict
+			}
+
+			return errTx
+		}
+
+		if errTx = r.addHistoryRecord(ctx, &flat, ChangeStatus, updateFlatEntity.ModeratorID); errTx != nil {
+			return errTx
+		}
+
+		if errTx = r.resolvePendingPhotos(ctx, &flat); errTx != nil {
+			return errTx
+		}
+
+		return r.addFlatEvent(ctx, EventFlatStatusChanged, &flat)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &flat, nil
+}
+
+// GetModerationQueue возвращает квартиры, ожидающие модерации, начиная с самых старых
+func (r *flatsRepository) GetModerationQueue(ctx context.Context, limit uint64, after *QueuePosition) ([]FlatEntity, error) {
 	selectBuilder := squirrel.
-		Select(idColumn, houseIDColumn, priceColumn, roomsColumn, statusColumn).
+		Select(flatColumns...).
 		From(tableName).
-		Where(
-			squirrel.Eq{
-				houseIDColumn: houseID,
-				statusColumn:  StatusApproved,
-			}).
+		Where(squirrel.Eq{statusColumn: StatusCreated, deletedAtColumn: nil}).
+		OrderBy(createdAtColumn, idColumn).
+		Limit(limit).
 		PlaceholderFormat(squirrel.Dollar)
 
+	if after != nil {
+		selectBuilder = selectBuilder.Where(squirrel.Expr("(created_at, id) > (?, ?)", after.CreatedAt, after.ID))
+	}
+
 	query, args, err := selectBuilder.ToSql()
 	if err != nil {
 		return nil, err
 	}
 
 	q := db.Query{
-		Name:     "flatsRepository.GetApprovedFlatsByHouseID",
+		Name:     "flatsRepository.GetModerationQueue",
 		QueryRaw: query,
 	}
 
-	rows, err := r.db.DB().QueryContext(ctx, q, args...)
+	return r.queryFlats(ctx, q, args...)
+}
+
+// ClaimFlats атомарно берет на модерацию до count самых старых квартир из очереди.
+// Квартиры, которые в этот момент берет другой модератор, пропускаются.
+func (r *flatsRepository) ClaimFlats(ctx context.Context, moderatorID string, count uint64) ([]FlatEntity, error) {
+	q := db.Query{
+		Name: "flatsRepository.ClaimFlats",
+		QueryRaw: `UPDATE flats
+			SET status = $1, moderator_id = $2, claimed_at = CURRENT_TIMESTAMP, updated_at = CURRENT_TIMESTAMP
+			WHERE id IN (
+				SELECT id FROM flats
+				WHERE status = $3 AND deleted_at IS NULL
+				ORDER BY created_at, id
+				LIMIT $4
+				FOR UPDATE SKIP LOCKED
+			)
+			` + returningFlatColumns,
+	}
+
+	return r.changeStatusesWithEvents(ctx, q, StatusOnModeration, moderatorID, StatusCreated, count)
+}
+
+// ReleaseStaleClaims возвращает в очередь квартиры, которые находятся на модерации дольше claimTimeout
+func (r *flatsRepository) ReleaseStaleClaims(ctx context.Context, claimTimeout time.Duration) ([]FlatEntity, error) {
+	q := db.Query{
+		Name: "flatsRepository.ReleaseStaleClaims",
+		QueryRaw: `UPDATE flats
+			SET status = $1, moderator_id = NULL, claimed_at = NULL, updated_at = CURRENT_TIMESTAMP
+			WHERE status = $2 AND deleted_at IS NULL AND claimed_at < CURRENT_TIMESTAMP - $3 * INTERVAL '1 millisecond'
+			` + returningFlatColumns,
+	}
+
+	return r.changeStatusesWithEvents(ctx, q, StatusCreated, StatusOnModeration, claimTimeout.Milliseconds())
+}
+
+// SearchFlats ищет квартиры по фильтру с сортировкой по цене или дате создания.
+// Дом джойнится только при фильтрах по застройщику или году постройки.
+func (r *flatsRepository) SearchFlats(ctx context.Context, filter SearchFlatsFilter) ([]FlatEntity, error) {
+	joinHouses := filter.Developer != nil || filter.MinYear != nil || filter.MaxYear != nil
+
+	selectBuilder := searchFlatsBuilder(filter, prefixColumns("f", flatColumns), joinHouses).Limit(filter.Limit)
+
+	query, args, err := selectBuilder.ToSql()
 	if err != nil {
 		return nil, err
 	}
+
+	q := db.Query{
+		Name:     "flatsRepository.SearchFlats",
+		QueryRaw: query,
+	}
+
+	return r.queryFlats(ctx, q, args...)
+}
+
+// ExportFlats отдает в fn по одной все квартиры, подходящие под фильтр, вместе с данными дома.
+// Limit фильтра не используется. Строки читаются из pgx.Rows по мере обработки,
+// поэтому выгрузка любого размера не держит результат в памяти.
+func (r *flatsRepository) ExportFlats(ctx context.Context, filter SearchFlatsFilter, fn func(flat *ExportFlatEntity) error) error {
+	columns := append(prefixColumns("f", flatColumns), "h.address", "h.year", "h.developer")
+
+	query, args, err := searchFlatsBuilder(filter, columns, true).ToSql()
+	if err != nil {
+		return err
+	}
+
+	q := db.Query{
+		Name:     "flatsRepository.ExportFlats",
+		QueryRaw: query,
+	}
+
+	rows, err := r.db.DB().QueryContext(ctx, q, args...)
+	if err != nil {
+		return err
+	}
 	defer rows.Close()
 
-	var flats []FlatEntity
 	for rows.Next() {
-		var flat FlatEntity
-		if err := rows.Scan(&flat.ID, &flat.HouseID, &flat.Price, &flat.Rooms, &flat.Status); err != nil {
-			return nil, err
+		var flat ExportFlatEntity
+
+		err := rows.Scan(append(flatScanTargets(&flat.FlatEntity), &flat.HouseAddress, &flat.HouseYear, &flat.HouseDeveloper)...)
+		if err != nil {
+			return err
 		}
-		flats = append(flats, flat)
+
+		if err := fn(&flat); err != nil {
+			return err
+		}
+	}
+
+	return rows.Err()
+}
+
+// searchFlatsBuilder строит выборку квартир по фильтру без LIMIT. Фильтры по застройщику
+// и году постройки применяются, только если дом джойнится.
+func searchFlatsBuilder(filter SearchFlatsFilter, columns []string, joinHouses bool) squirrel.SelectBuilder {
+	sortColumn := "f." + createdAtColumn
+	if filter.SortBy == SortByPrice {
+		sortColumn = "f." + priceColumn
+	}
+
+	direction := "ASC"
+	if filter.Desc {
+		direction = "DESC"
+	}
+
+	selectBuilder := squirrel.
+		Select(columns...).
+		From(tableName+" f").
+		Where(squirrel.Eq{"f.deleted_at": nil}).
+		OrderBy(sortColumn+" "+direction, "f.id "+direction).
+		PlaceholderFormat(squirrel.Dollar)
+
+	if joinHouses {
+		selectBuilder = selectBuilder.Join("houses h ON h.id = f.house_id")
+
+		if filter.Developer != nil {
+			selectBuilder = selectBuilder.Where(squirrel.Eq{"h.developer": *filter.Developer})
+		}
+		if filter.MinYear != nil {
+			selectBuilder = selectBuilder.Where(squirrel.GtOrEq{"h.year": *filter.MinYear})
+		}
+		if filter.MaxYear != nil {
+			selectBuilder = selectBuilder.Where(squirrel.LtOrEq{"h.year": *filter.MaxYear})
+		}
+	}
+
+	if filter.MinPrice != nil {
+		selectBuilder = selectBuilder.Where(squirrel.GtOrEq{"f.price": *filter.MinPrice})
+	}
+	if filter.MaxPrice != nil {
+		selectBuilder = selectBuilder.Where(squirrel.LtOrEq{"f.price": *filter.MaxPrice})
+	}
+	if len(filter.Rooms) > 0 {
+		selectBuilder = selectBuilder.Where(squirrel.Eq{"f.rooms": filter.Rooms})
+	}
+	if filter.HouseID != nil {
+		selectBuilder = selectBuilder.Where(squirrel.Eq{"f.house_id": *filter.HouseID})
+	}
+	if len(filter.Statuses) > 0 {
+		selectBuilder = selectBuilder.Where(squirrel.Eq{"f.status": filter.Statuses})
+	}
+
+	if filter.After != nil {
+		comparison := ">"
+		if filter.Desc {
+			comparison = "<"
+		}
+
+		var afterValue interface{} = filter.After.CreatedAt
+		if filter.SortBy == SortByPrice {
+			afterValue = filter.After.Price
+		}
+
+		selectBuilder = selectBuilder.Where(
+			squirrel.Expr("("+sortColumn+", f.id) "+comparison+" (?, ?)", afterValue, filter.After.ID))
+	}
+
+	return selectBuilder
+}
+
+// DeleteFlat мягко удаляет квартиру: она перестает отдаваться во всех чтениях, но остается в базе
+func (r *flatsRepository) DeleteFlat(ctx context.Context, flatID int64) (*FlatEntity, error) {
+	q := db.Query{
+		Name: "flatsRepository.DeleteFlat",
+		QueryRaw: `UPDATE flats
+			SET deleted_at = CURRENT_TIMESTAMP
+			WHERE id = $1 AND deleted_at IS NULL
+			` + returningFlatColumns,
+	}
+
+	return r.changeFlatWithEvent(ctx, q, EventFlatDeleted, flatID)
+}
+
+// RestoreFlat возвращает мягко удаленную квартиру. Квартиру удаленного дома восстановить нельзя,
+// сначала нужно восстановить дом.
+func (r *flatsRepository) RestoreFlat(ctx context.Context, flatID int64) (*FlatEntity, error) {
+	q := db.Query{
+		Name: "flatsRepository.RestoreFlat",
+		QueryRaw: `UPDATE flats
+			SET deleted_at = NULL
+			WHERE id = $1 AND deleted_at IS NOT NULL
+			  AND EXISTS (SELECT 1 FROM houses WHERE houses.id = flats.house_id AND houses.deleted_at IS NULL)
+			` + returningFlatColumns,
+	}
+
+	flat, err := r.changeFlatWithEvent(ctx, q, EventFlatRestored, flatID)
+	if repo_errors.IsUniqueViolation(err) {
+		// пока квартира была удалена, ее номер в доме занял кто-то другой
+		return nil, fmt.Errorf("%w: flat number is taken by another flat", repo_errors.ErrFlatStateConflict)
+	}
+
+	return flat, err
+}
+
+// changeFlatWithEvent меняет одну квартиру по id и в той же транзакции пишет событие в outbox
+func (r *flatsRepository) changeFlatWithEvent(ctx context.Context, q db.Query, eventType string, flatID int64) (*FlatEntity, error) {
+	var flat *FlatEntity
+
+	err := r.txManager.ReadCommitted(ctx, func(ctx context.Context) error {
+		flats, errTx := r.queryFlats(ctx, q, flatID)
+		if errTx != nil {
+			return errTx
+		}
+
+		if len(flats) == 0 {
+			return &repo_errors.ErrFlatNotFound{FlatID: flatID}
+		}
+
+		flat = &flats[0]
+
+		return r.addFlatEvent(ctx, eventType, flat)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return flat, nil
+}
+
+// changeStatusesWithEvents выполняет массовое изменение статусов и в той же транзакции пишет события в outbox
+func (r *flatsRepository) changeStatusesWithEvents(ctx context.Context, q db.Query, args ...interface{}) ([]FlatEntity, error) {
+	var flats []FlatEntity
+
+	err := r.txManager.ReadCommitted(ctx, func(ctx context.Context) error {
+		var errTx error
+
+		flats, errTx = r.queryFlats(ctx, q, args...)
+		if errTx != nil {
+			return errTx
+		}
+
+		for i := range flats {
+			// при взятии на модерацию изменение сделал модератор, при возврате в очередь - никто
+			if errTx = r.addHistoryRecord(ctx, &flats[i], ChangeStatus, flats[i].ModeratorID); errTx != nil {
+				return errTx
+			}
+
+			if errTx = r.addFlatEvent(ctx, EventFlatStatusChanged, &flats[i]); errTx != nil {
+				return errTx
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return flats, nil
 }
 
-func (r *flatsRepository) CreateFlat(ctx context.Context, flatEntity CreateFlatEntity) (*FlatEntity, error) {
-	insertBuilder := squirrel.
-		Insert(tableName).
-		PlaceholderFormat(squirrel.Dollar).
-		Columns(houseIDColumn, priceColumn, roomsColumn, statusColumn).
-		Values(flatEntity.HouseID, flatEntity.Price, flatEntity.Rooms, StatusCreated).
-		Suffix("RETURNING id")
+// GetFlatByNumber ищет квартиру по номеру внутри дома
+func (r *flatsRepository) GetFlatByNumber(ctx context.Context, houseID int64, flatNumber int64) (*FlatEntity, error) {
+	selectBuilder := squirrel.
+		Select(flatColumns...).
+		From(tableName).
+		Where(squirrel.Eq{houseIDColumn: houseID, flatNumberColumn: flatNumber, deletedAtColumn: nil}).
+		PlaceholderFormat(squirrel.Dollar)
 
-	query, args, err := insertBuilder.ToSql()
+	query, args, err := selectBuilder.ToSql()
 	if err != nil {
 		return nil, err
 	}
 
 	q := db.Query{
-		Name:     "flatsRepository.CreateFlat",
+		Name:     "flatsRepository.GetFlatByNumber",
 		QueryRaw: query,
 	}
 
-	var flatID int64
+	var flat FlatEntity
 
-	err = r.db.DB().
-		QueryRowContext(ctx, q, args...).
-		Scan(&flatID)
+	err = scanFlat(r.db.DB().QueryRowContext(ctx, q, args...), &flat)
 	if err != nil {
-		if repo_errors.IsForeignKeyViolation(err) {
-			return nil, &repo_errors.ErrHouseNotFound{HouseID: flatEntity.HouseID}
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, &repo_errors.ErrFlatNumberNotFound{HouseID: houseID, FlatNumber: flatNumber}
 		}
 
 		return nil, err
 	}
 
-	var flat = &FlatEntity{
-		ID:      flatID,
-		HouseID: flatEntity.HouseID,
-		Price:   flatEntity.Price,
-		Rooms:   flatEntity.Rooms,
-		Status:  StatusCreated,
-	}
-
-	return flat, nil
+	return &flat, nil
 }
 
-func (r *flatsRepository) UpdateFlat(ctx context.Context, updateFlatEntity UpdateFlatEntity) (*FlatEntity, error) {
+// ChangeFlatPrice меняет цену квартиры и возвращает ее на модерацию. Квартира должна находиться
+// в одном из статусов FromStatuses и, если задана ExpectedVersion, иметь эту версию,
+// иначе возвращается repo_errors.ErrFlatStateConflict.
+func (r *flatsRepository) ChangeFlatPrice(ctx context.Context, changeFlatPriceEntity ChangeFlatPriceEntity) (*FlatEntity, error) {
 	updateBuilder := squirrel.
 		Update(tableName).
-		Set(statusColumn, updateFlatEntity.Status).
-		Set(moderatorIDColumn, updateFlatEntity.ModeratorID).
-		Set(updatedAtColumn, updateFlatEntity.UpdatedAt).
-		Where(squirrel.Eq{idColumn: updateFlatEntity.ID}).
-		Suffix("RETURNING id, house_id, price, rooms, status, moderator_id").
+		Set(priceColumn, changeFlatPriceEntity.Price).
+		Set(statusColumn, StatusCreated).
+		Set(moderatorIDColumn, nil).
+		Set(claimedAtColumn, nil).
+		Set(updatedAtColumn, squirrel.Expr("CURRENT_TIMESTAMP")).
+		Where(squirrel.Eq{
+			idColumn:        changeFlatPriceEntity.ID,
+			statusColumn:    changeFlatPriceEntity.FromStatuses,
+			deletedAtColumn: nil,
+		}).
+		Suffix(returningFlatColumns).
 		PlaceholderFormat(squirrel.Dollar)
 
+	if changeFlatPriceEntity.ExpectedVersion != nil {
+		updateBuilder = updateBuilder.Where(squirrel.Eq{versionColumn: *changeFlatPriceEntity.ExpectedVersion})
+	}
+
 	query, args, err := updateBuilder.ToSql()
 	if err != nil {
 		return nil, err
 	}
 
 	q := db.Query{
-		Name:     "flatsRepository.UpdateFlat",
+		Name:     "flatsRepository.ChangeFlatPrice",
 		QueryRaw: query,
 	}
 
 	var flat FlatEntity
-	err = r.db.DB().
-		QueryRowContext(ctx, q, args...).
-		Scan(&flat.ID, &flat.HouseID, &flat.Price, &flat.Rooms, &flat.Status, &flat.ModeratorID)
+
+	err = r.txManager.ReadCommitted(ctx, func(ctx context.Context) error {
+		errTx := scanFlat(r.db.DB().QueryRowContext(ctx, q, args...), &flat)
+		if errTx != nil {
+			if errors.Is(errTx, pgx.ErrNoRows) {
+				return repo_errors.ErrFlatStateConflict
+			}
+
+			return errTx
+		}
+
+		if errTx = r.addHistoryRecord(ctx, &flat, ChangePrice, &changeFlatPriceEntity.ChangedBy); errTx != nil {
+			return errTx
+		}
+
+		return r.addFlatEvent(ctx, EventFlatPriceChanged, &flat)
+	})
 	if err != nil {
 		return nil, err
 	}
 
 	return &flat, nil
 }
+
+// GetFlatHistory возвращает историю изменений цены и статуса квартиры от старых к новым
+func (r *flatsRepository) GetFlatHistory(ctx context.Context, flatID int64) ([]FlatHistoryEntity, error) {
+	selectBuilder := squirrel.
+		Select("id", "flat_id", "change_type", "price", "status", "changed_by", "created_at").
+		From(historyTableName).
+		Where(squirrel.Eq{"flat_id": flatID}).
+		OrderBy("id").
+		PlaceholderFormat(squirrel.Dollar)
+
+	query, args, err := selectBuilder.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	q := db.Query{
+		Name:     "flatsRepository.GetFlatHistory",
+		QueryRaw: query,
+	}
+
+	rows, err := r.db.DB().QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var history []FlatHistoryEntity
+
+	for rows.Next() {
+		var record FlatHistoryEntity
+
+		err := rows.Scan(&record.ID, &record.FlatID, &record.ChangeType, &record.Price, &record.Status, &record.ChangedBy, &record.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		history = append(history, record)
+	}
+
+	return history, rows.Err()
+}
+
+// ImportFlats массово создает квартиры дома: строки копируются через COPY во временную таблицу
+// и переносятся в flats одним запросом. Если часть номеров квартир уже занята,
+// возвращает repo_errors.ErrFlatNumbersTaken со списком занятых номеров.
+func (r *flatsRepository) ImportFlats(ctx context.Context, houseID int64, flatEntities []CreateFlatEntity) ([]FlatEntity, error) {
+	createStagingQuery := db.Query{
+		Name: "flatsRepository.ImportFlats.createStaging",
+		QueryRaw: `CREATE TEMP TABLE ` + importTableName + ` (
+				row_num INTEGER NOT NULL,
+				price INTEGER NOT NULL,
+				rooms INTEGER NOT NULL,
+				flat_number INTEGER,
+				total_area DOUBLE PRECISION,
+				living_area DOUBLE PRECISION,
+				floor INTEGER,
+				total_floors INTEGER,
+				layout VARCHAR(20),
+				has_balcony BOOLEAN,
+				description TEXT
+			) ON COMMIT DROP`,
+	}
+
+	takenNumbersQuery := db.Query{
+		Name: "flatsRepository.ImportFlats.takenNumbers",
+		QueryRaw: `SELECT DISTINCT i.flat_number
+			FROM ` + importTableName + ` i
+			JOIN flats f ON f.house_id = $1 AND f.flat_number = i.flat_number AND f.deleted_at IS NULL
+			ORDER BY i.flat_number`,
+	}
+
+	insertQuery := db.Query{
+		Name: "flatsRepository.ImportFlats.insert",
+		QueryRaw: `INSERT INTO flats (
+				house_id, price, rooms, status, flat_number,
+				total_area, living_area, floor, total_floors, layout, has_balcony, description
+			)
+			SELECT $1, price, rooms, $2, flat_number,
+				total_area, living_area, floor, total_floors, layout, has_balcony, description
+			FROM ` + importTableName + `
+			ORDER BY row_num
+			` + returningFlatColumns,
+	}
+
+	// вставка идет под точкой сохранения: после нарушения уникальности транзакцию
+	// можно продолжить и перечитать занятые номера для отчета
+	savepointQuery := db.Query{
+		Name:     "flatsRepository.ImportFlats.savepoint",
+		QueryRaw: `SAVEPOINT import_insert`,
+	}
+
+	rollbackToSavepointQuery := db.Query{
+		Name:     "flatsRepository.ImportFlats.rollbackToSavepoint",
+		QueryRaw: `ROLLBACK TO SAVEPOINT import_insert`,
+	}
+
+	stagingColumns := []string{
+		"row_num", priceColumn, roomsColumn, flatNumberColumn,
+		totalAreaColumn, livingAreaColumn, floorColumn, totalFloorsColumn, layoutColumn, hasBalconyColumn, descriptionColumn,
+	}
+
+	rows := make([][]interface{}, len(flatEntities))
+	for i, flatEntity := range flatEntities {
+		var layout *string
+		if flatEntity.Layout != nil {
+			layoutStr := string(*flatEntity.Layout)
+			layout = &layoutStr
+		}
+
+		rows[i] = []interface{}{
+			i + 1, flatEntity.Price, flatEntity.Rooms, flatEntity.FlatNumber,
+			flatEntity.TotalArea, flatEntity.LivingArea, flatEntity.Floor, flatEntity.TotalFloors,
+			layout, flatEntity.HasBalcony, flatEntity.Description,
+		}
+	}
+
+	var flats []FlatEntity
+
+	err := r.txManager.ReadCommitted(ctx, func(ctx context.Context) error {
+		if _, errTx := r.db.DB().ExecContext(ctx, createStagingQuery); errTx != nil {
+			return errTx
+		}
+
+		_, errTx := r.db.DB().CopyFromContext(ctx, pgx.Identifier{importTableName}, stagingColumns, pgx.CopyFromRows(rows))
+		if errTx != nil {
+			return errTx
+		}
+
+		takenNumbers, errTx := r.queryFlatNumbers(ctx, takenNumbersQuery, houseID)
+		if errTx != nil {
+			return errTx
+		}
+
+		if len(takenNumbers) > 0 {
+			return &repo_errors.ErrFlatNumbersTaken{HouseID: houseID, FlatNumbers: takenNumbers}
+		}
+
+		if _, errTx = r.db.DB().ExecContext(ctx, savepointQuery); errTx != nil {
+			return errTx
+		}
+
+		flats, errTx = r.queryFlats(ctx, insertQuery, houseID, StatusCreated)
+		if errTx != nil {
+			if repo_errors.IsForeignKeyViolation(errTx) {
+				return &repo_errors.ErrHouseNotFound{HouseID: houseID}
+			}
+
+			// номер успели занять после проверки, например, восстановлением удаленной квартиры
+			if repo_errors.IsUniqueViolation(errTx) {
+				return r.explainImportConflict(ctx, errTx, rollbackToSavepointQuery, takenNumbersQuery, houseID)
+			}
+
+			return errTx
+		}
+
+		for i := range flats {
+			if errTx = r.addHistoryRecord(ctx, &flats[i], ChangeCreated, nil); errTx != nil {
+				return errTx
+			}
+
+			if errTx = r.addFlatEvent(ctx, EventFlatCreated, &flats[i]); errTx != nil {
+				return errTx
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return flats, nil
+}
+
+// explainImportConflict откатывает неудачную вставку до точки сохранения и перечитывает занятые номера.
+// Если занятых номеров не нашлось, возвращается исходная ошибка.
+func (r *flatsRepository) explainImportConflict(
+	ctx context.Context,
+	insertErr error,
+	rollbackQuery db.Query,
+	takenNumbersQuery db.Query,
+	houseID int64,
+) error {
+	if _, err := r.db.DB().ExecContext(ctx, rollbackQuery); err != nil {
+		return err
+	}
+
+	takenNumbers, err := r.queryFlatNumbers(ctx, takenNumbersQuery, houseID)
+	if err != nil {
+		return err
+	}
+
+	if len(takenNumbers) == 0 {
+		return insertErr
+	}
+
+	return &repo_errors.ErrFlatNumbersTaken{HouseID: houseID, FlatNumbers: takenNumbers}
+}
+
+func (r *flatsRepository) queryFlatNumbers(ctx context.Context, q db.Query, args ...interface{}) ([]int64, error) {
+	rows, err := r.db.DB().QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var numbers []int64
+
+	for rows.Next() {
+		var number int64
+
+		if err := rows.Scan(&number); err != nil {
+			return nil, err
+		}
+
+		numbers = append(numbers, number)
+	}
+
+	return numbers, rows.Err()
+}
+
+// queryFlats выполняет запрос, возвращающий колонки flatColumns
+func (r *flatsRepository) queryFlats(ctx context.Context, q db.Query, args ...interface{}) ([]FlatEntity, error) {
+	rows, err := r.db.DB().QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var flats []FlatEntity
+
+	for rows.Next() {
+		var flat FlatEntity
+
+		if err := scanFlat(rows, &flat); err != nil {
+			return nil, err
+		}
+
+		flats = append(flats, flat)
+	}
+
+	return flats, rows.Err()
+}
+
+func scanFlat(row pgx.Row, flat *FlatEntity) error {
+	return row.Scan(flatScanTargets(flat)...)
+}
+
+// flatScanTargets поля квартиры в порядке flatColumns
+func flatScanTargets(flat *FlatEntity) []interface{} {
+	return []interface{}{
+		&flat.ID, &flat.HouseID, &flat.Price, &flat.Rooms, &flat.Status, &flat.ModeratorID, &flat.CreatedAt, &flat.FlatNumber,
+		&flat.TotalArea, &flat.LivingArea, &flat.Floor, &flat.TotalFloors, &flat.Layout, &flat.HasBalcony, &flat.Description,
+		&flat.Version,
+	}
+}
+
+func prefixColumns(alias string, columns []string) []string {
+	prefixed := make([]string, len(columns))
+	for i, column := range columns {
+		prefixed[i] = alias + "." + column
+	}
+	return prefixed
+}
+
+// resolvePendingPhotos переносит решение модератора по квартире на ее еще не проверенные фотографии
+func (r *flatsRepository) resolvePendingPhotos(ctx context.Context, flat *FlatEntity) error {
+	if flat.Status != StatusApproved && flat.Status != StatusDeclined {
+		return nil
+	}
+
+	updateBuilder := squirrel.
+		Update(photosTableName).
+		Set("status", string(flat.Status)).
+		Where(squirrel.Eq{"flat_id": flat.ID, "status": "pending"}).
+		PlaceholderFormat(squirrel.Dollar)
+
+	query, args, err := updateBuilder.ToSql()
+	if err != nil {
+		return err
+	}
+
+	q := db.Query{
+		Name:     "flatsRepository.resolvePendingPhotos",
+		QueryRaw: query,
+	}
+
+	_, err = r.db.DB().ExecContext(ctx, q, args...)
+	return err
+}
+
+// addHistoryRecord пишет состояние квартиры в историю в той же транзакции, что и изменение
+func (r *flatsRepository) addHistoryRecord(ctx context.Context, flat *FlatEntity, changeType FlatChangeType, changedBy *string) error {
+	insertBuilder := squirrel.
+		Insert(historyTableName).
+		PlaceholderFormat(squirrel.Dollar).
+		Columns("flat_id", "change_type", "price", "status", "changed_by").
+		Values(flat.ID, changeType, flat.Price, flat.Status, changedBy)
+
+	query, args, err := insertBuilder.ToSql()
+	if err != nil {
+		return err
+	}
+
+	q := db.Query{
+		Name:     "flatsRepository.addHistoryRecord",
+		QueryRaw: query,
+	}
+
+	_, err = r.db.DB().ExecContext(ctx, q, args...)
+	return err
+}
+
+// addFlatEvent пишет событие в outbox в той же транзакции, что и изменение квартиры
+func (r *flatsRepository) addFlatEvent(ctx context.Context, eventType string, flat *FlatEntity) error {
+	event, err := NewFlatEvent(eventType, flat)
+	if err != nil {
+		return err
+	}
+
+	return r.events.AddEvent(ctx, event)
+}
+
+// NewFlatEvent собирает событие об изменении квартиры для outbox
+func NewFlatEvent(eventType string, flat *FlatEntity) (outboxRepo.CreateEventEntity, error) {
+	payload, err := json.Marshal(FlatEventPayload{
+		FlatID:      flat.ID,
+		HouseID:     flat.HouseID,
+		Price:       flat.Price,
+		Rooms:       flat.Rooms,
+		FlatNumber:  flat.FlatNumber,
+		Status:      flat.Status,
+		ModeratorID: flat.ModeratorID,
+		OccurredAt:  time.Now().UTC(),
+	})
+	if err != nil {
+		return outboxRepo.CreateEventEntity{}, err
+	}
+
+	return outboxRepo.CreateEventEntity{
+		AggregateType: eventAggregateFlat,
+		AggregateID:   flat.ID,
+		EventType:     eventType,
+		Payload:       payload,
+	}, nil
+}
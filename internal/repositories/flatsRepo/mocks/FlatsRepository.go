@@ -4,9 +4,11 @@ package mocks
 
 import (
 	context "context"
-	flat "realty-avito/internal/repositories/flatsRepo"
+	flatsRepo "realty-avito/internal/repositories/flatsRepo"
 
 	mock "github.com/stretchr/testify/mock"
+
+	time "time"
 )
 
 // FlatsRepository is an autogenerated mock type for the FlatsRepository type
@@ -14,24 +16,76 @@ type FlatsRepository struct {
 	mock.Mock
 }
 
+// ChangeFlatPrice provides a mock function with given fields: ctx, changeFlatPriceEntity
+func (_m *FlatsRepository) ChangeFlatPrice(ctx context.Context, changeFlatPriceEntity flatsRepo.ChangeFlatPriceEntity) (*flatsRepo.FlatEntity, error) {
+	ret := _m.Called(ctx, changeFlatPriceEntity)
+
+	var r0 *flatsRepo.FlatEntity
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, flatsRepo.ChangeFlatPriceEntity) (*flatsRepo.FlatEntity, error)); ok {
+		return rf(ctx, changeFlatPriceEntity)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, flatsRepo.ChangeFlatPriceEntity) *flatsRepo.FlatEntity); ok {
+		r0 = rf(ctx, changeFlatPriceEntity)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*flatsRepo.FlatEntity)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, flatsRepo.ChangeFlatPriceEntity) error); ok {
+		r1 = rf(ctx, changeFlatPriceEntity)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ClaimFlats provides a mock function with given fields: ctx, moderatorID, count
+func (_m *FlatsRepository) ClaimFlats(ctx context.Context, moderatorID string, count uint64) ([]flatsRepo.FlatEntity, error) {
+	ret := _m.Called(ctx, moderatorID, count)
+
+	var r0 []flatsRepo.FlatEntity
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, uint64) ([]flatsRepo.FlatEntity, error)); ok {
+		return rf(ctx, moderatorID, count)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, uint64) []flatsRepo.FlatEntity); ok {
+		r0 = rf(ctx, moderatorID, count)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]flatsRepo.FlatEntity)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, uint64) error); ok {
+		r1 = rf(ctx, moderatorID, count)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // CreateFlat provides a mock function with given fields: ctx, flatModel
-func (_m *FlatsRepository) CreateFlat(ctx context.Context, flatModel flat.CreateFlatEntity) (*flat.FlatEntity, error) {
+func (_m *FlatsRepository) CreateFlat(ctx context.Context, flatModel flatsRepo.CreateFlatEntity) (*flatsRepo.FlatEntity, error) {
 	ret := _m.Called(ctx, flatModel)
 
-	var r0 *flat.FlatEntity
+	var r0 *flatsRepo.FlatEntity
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, flat.CreateFlatEntity) (*flat.FlatEntity, error)); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, flatsRepo.CreateFlatEntity) (*flatsRepo.FlatEntity, error)); ok {
 		return rf(ctx, flatModel)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, flat.CreateFlatEntity) *flat.FlatEntity); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, flatsRepo.CreateFlatEntity) *flatsRepo.FlatEntity); ok {
 		r0 = rf(ctx, flatModel)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*flat.FlatEntity)
+			r0 = ret.Get(0).(*flatsRepo.FlatEntity)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, flat.CreateFlatEntity) error); ok {
+	if rf, ok := ret.Get(1).(func(context.Context, flatsRepo.CreateFlatEntity) error); ok {
 		r1 = rf(ctx, flatModel)
 	} else {
 		r1 = ret.Error(1)
@@ -40,25 +94,65 @@ func (_m *FlatsRepository) CreateFlat(ctx context.Context, flatModel flat.Create
 	return r0, r1
 }
 
-// GetApprovedFlatsByHouseID provides a mock function with given fields: ctx, houseID
-func (_m *FlatsRepository) GetApprovedFlatsByHouseID(ctx context.Context, houseID int64) ([]flat.FlatEntity, error) {
-	ret := _m.Called(ctx, houseID)
+// DeleteFlat provides a mock function with given fields: ctx, flatID
+func (_m *FlatsRepository) DeleteFlat(ctx context.Context, flatID int64) (*flatsRepo.FlatEntity, error) {
+	ret := _m.Called(ctx, flatID)
 
-	var r0 []flat.FlatEntity
+	var r0 *flatsRepo.FlatEntity
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, int64) ([]flat.FlatEntity, error)); ok {
-		return rf(ctx, houseID)
+	if rf, ok := ret.Get(0).(func(context.Context, int64) (*flatsRepo.FlatEntity, error)); ok {
+		return rf(ctx, flatID)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, int64) []flat.FlatEntity); ok {
-		r0 = rf(ctx, houseID)
+	if rf, ok := ret.Get(0).(func(context.Context, int64) *flatsRepo.FlatEntity); ok {
+		r0 = rf(ctx, flatID)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]flat.FlatEntity)
+			r0 = ret.Get(0).(*flatsRepo.FlatEntity)
 		}
 	}
 
 	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
-		r1 = rf(ctx, houseID)
+		r1 = rf(ctx, flatID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ExportFlats provides a mock function with given fields: ctx, filter, fn
+func (_m *FlatsRepository) ExportFlats(ctx context.Context, filter flatsRepo.SearchFlatsFilter, fn func(*flatsRepo.ExportFlatEntity) error) error {
+	ret := _m.Called(ctx, filter, fn)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, flatsRepo.SearchFlatsFilter, func(*flatsRepo.ExportFlatEntity) error) error); ok {
+		r0 = rf(ctx, filter, fn)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetApprovedFlatsByHouseID provides a mock function with given fields: ctx, houseID, page
+func (_m *FlatsRepository) GetApprovedFlatsByHouseID(ctx context.Context, houseID int64, page flatsRepo.HouseFlatsPage) ([]flatsRepo.FlatEntity, error) {
+	ret := _m.Called(ctx, houseID, page)
+
+	var r0 []flatsRepo.FlatEntity
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, flatsRepo.HouseFlatsPage) ([]flatsRepo.FlatEntity, error)); ok {
+		return rf(ctx, houseID, page)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, flatsRepo.HouseFlatsPage) []flatsRepo.FlatEntity); ok {
+		r0 = rf(ctx, houseID, page)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]flatsRepo.FlatEntity)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, flatsRepo.HouseFlatsPage) error); ok {
+		r1 = rf(ctx, houseID, page)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -67,19 +161,19 @@ func (_m *FlatsRepository) GetApprovedFlatsByHouseID(ctx context.Context, houseI
 }
 
 // GetFlatByFlatID provides a mock function with given fields: ctx, flatID
-func (_m *FlatsRepository) GetFlatByFlatID(ctx context.Context, flatID int64) (*flat.FlatEntity, error) {
+func (_m *FlatsRepository) GetFlatByFlatID(ctx context.Context, flatID int64) (*flatsRepo.FlatEntity, error) {
 	ret := _m.Called(ctx, flatID)
 
-	var r0 *flat.FlatEntity
+	var r0 *flatsRepo.FlatEntity
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, int64) (*flat.FlatEntity, error)); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, int64) (*flatsRepo.FlatEntity, error)); ok {
 		return rf(ctx, flatID)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, int64) *flat.FlatEntity); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, int64) *flatsRepo.FlatEntity); ok {
 		r0 = rf(ctx, flatID)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*flat.FlatEntity)
+			r0 = ret.Get(0).(*flatsRepo.FlatEntity)
 		}
 	}
 
@@ -92,25 +186,233 @@ func (_m *FlatsRepository) GetFlatByFlatID(ctx context.Context, flatID int64) (*
 	return r0, r1
 }
 
-// GetFlatsByHouseID provides a mock function with given fields: ctx, houseID
-func (_m *FlatsRepository) GetFlatsByHouseID(ctx context.Context, houseID int64) ([]flat.FlatEntity, error) {
-	ret := _m.Called(ctx, houseID)
+// GetFlatByNumber provides a mock function with given fields: ctx, houseID, flatNumber
+func (_m *FlatsRepository) GetFlatByNumber(ctx context.Context, houseID int64, flatNumber int64) (*flatsRepo.FlatEntity, error) {
+	ret := _m.Called(ctx, houseID, flatNumber)
+
+	var r0 *flatsRepo.FlatEntity
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) (*flatsRepo.FlatEntity, error)); ok {
+		return rf(ctx, houseID, flatNumber)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) *flatsRepo.FlatEntity); ok {
+		r0 = rf(ctx, houseID, flatNumber)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*flatsRepo.FlatEntity)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, int64) error); ok {
+		r1 = rf(ctx, houseID, flatNumber)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetFlatHistory provides a mock function with given fields: ctx, flatID
+func (_m *FlatsRepository) GetFlatHistory(ctx context.Context, flatID int64) ([]flatsRepo.FlatHistoryEntity, error) {
+	ret := _m.Called(ctx, flatID)
 
-	var r0 []flat.FlatEntity
+	var r0 []flatsRepo.FlatHistoryEntity
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, int64) ([]flat.FlatEntity, error)); ok {
-		return rf(ctx, houseID)
+	if rf, ok := ret.Get(0).(func(context.Context, int64) ([]flatsRepo.FlatHistoryEntity, error)); ok {
+		return rf(ctx, flatID)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, int64) []flat.FlatEntity); ok {
-		r0 = rf(ctx, houseID)
+	if rf, ok := ret.Get(0).(func(context.Context, int64) []flatsRepo.FlatHistoryEntity); ok {
+		r0 = rf(ctx, flatID)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]flat.FlatEntity)
+			r0 = ret.Get(0).([]flatsRepo.FlatHistoryEntity)
 		}
 	}
 
 	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
-		r1 = rf(ctx, houseID)
+		r1 = rf(ctx, flatID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetFlatsByHouseID provides a mock function with given fields: ctx, houseID, page
+func (_m *FlatsRepository) GetFlatsByHouseID(ctx context.Context, houseID int64, page flatsRepo.HouseFlatsPage) ([]flatsRepo.FlatEntity, error) {
+	ret := _m.Called(ctx, houseID, page)
+
+	var r0 []flatsRepo.FlatEntity
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, flatsRepo.HouseFlatsPage) ([]flatsRepo.FlatEntity, error)); ok {
+		return rf(ctx, houseID, page)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, flatsRepo.HouseFlatsPage) []flatsRepo.FlatEntity); ok {
+		r0 = rf(ctx, houseID, page)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]flatsRepo.FlatEntity)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, flatsRepo.HouseFlatsPage) error); ok {
+		r1 = rf(ctx, houseID, page)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetHouseFlatsState provides a mock function with given fields: ctx, houseID, approvedOnly
+func (_m *FlatsRepository) GetHouseFlatsState(ctx context.Context, houseID int64, approvedOnly bool) (*flatsRepo.HouseFlatsState, error) {
+	ret := _m.Called(ctx, houseID, approvedOnly)
+
+	var r0 *flatsRepo.HouseFlatsState
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, bool) (*flatsRepo.HouseFlatsState, error)); ok {
+		return rf(ctx, houseID, approvedOnly)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, bool) *flatsRepo.HouseFlatsState); ok {
+		r0 = rf(ctx, houseID, approvedOnly)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*flatsRepo.HouseFlatsState)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, bool) error); ok {
+		r1 = rf(ctx, houseID, approvedOnly)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetModerationQueue provides a mock function with given fields: ctx, limit, after
+func (_m *FlatsRepository) GetModerationQueue(ctx context.Context, limit uint64, after *flatsRepo.QueuePosition) ([]flatsRepo.FlatEntity, error) {
+	ret := _m.Called(ctx, limit, after)
+
+	var r0 []flatsRepo.FlatEntity
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, uint64, *flatsRepo.QueuePosition) ([]flatsRepo.FlatEntity, error)); ok {
+		return rf(ctx, limit, after)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, uint64, *flatsRepo.QueuePosition) []flatsRepo.FlatEntity); ok {
+		r0 = rf(ctx, limit, after)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]flatsRepo.FlatEntity)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, uint64, *flatsRepo.QueuePosition) error); ok {
+		r1 = rf(ctx, limit, after)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ImportFlats provides a mock function with given fields: ctx, houseID, flatEntities
+func (_m *FlatsRepository) ImportFlats(ctx context.Context, houseID int64, flatEntities []flatsRepo.CreateFlatEntity) ([]flatsRepo.FlatEntity, error) {
+	ret := _m.Called(ctx, houseID, flatEntities)
+
+	var r0 []flatsRepo.FlatEntity
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, []flatsRepo.CreateFlatEntity) ([]flatsRepo.FlatEntity, error)); ok {
+		return rf(ctx, houseID, flatEntities)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, []flatsRepo.CreateFlatEntity) []flatsRepo.FlatEntity); ok {
+		r0 = rf(ctx, houseID, flatEntities)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]flatsRepo.FlatEntity)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, []flatsRepo.CreateFlatEntity) error); ok {
+		r1 = rf(ctx, houseID, flatEntities)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ReleaseStaleClaims provides a mock function with given fields: ctx, claimTimeout
+func (_m *FlatsRepository) ReleaseStaleClaims(ctx context.Context, claimTimeout time.Duration) ([]flatsRepo.FlatEntity, error) {
+	ret := _m.Called(ctx, claimTimeout)
+
+	var r0 []flatsRepo.FlatEntity
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, time.Duration) ([]flatsRepo.FlatEntity, error)); ok {
+		return rf(ctx, claimTimeout)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, time.Duration) []flatsRepo.FlatEntity); ok {
+		r0 = rf(ctx, claimTimeout)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]flatsRepo.FlatEntity)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, time.Duration) error); ok {
+		r1 = rf(ctx, claimTimeout)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RestoreFlat provides a mock function with given fields: ctx, flatID
+func (_m *FlatsRepository) RestoreFlat(ctx context.Context, flatID int64) (*flatsRepo.FlatEntity, error) {
+	ret := _m.Called(ctx, flatID)
+
+	var r0 *flatsRepo.FlatEntity
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) (*flatsRepo.FlatEntity, error)); ok {
+		return rf(ctx, flatID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) *flatsRepo.FlatEntity); ok {
+		r0 = rf(ctx, flatID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*flatsRepo.FlatEntity)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, flatID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SearchFlats provides a mock function with given fields: ctx, filter
+func (_m *FlatsRepository) SearchFlats(ctx context.Context, filter flatsRepo.SearchFlatsFilter) ([]flatsRepo.FlatEntity, error) {
+	ret := _m.Called(ctx, filter)
+
+	var r0 []flatsRepo.FlatEntity
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, flatsRepo.SearchFlatsFilter) ([]flatsRepo.FlatEntity, error)); ok {
+		return rf(ctx, filter)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, flatsRepo.SearchFlatsFilter) []flatsRepo.FlatEntity); ok {
+		r0 = rf(ctx, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]flatsRepo.FlatEntity)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, flatsRepo.SearchFlatsFilter) error); ok {
+		r1 = rf(ctx, filter)
 	} else {
 		r1 = ret.Error(1)
 	}
@@ -119,23 +421,23 @@ func (_m *FlatsRepository) GetFlatsByHouseID(ctx context.Context, houseID int64)
 }
 
 // UpdateFlat provides a mock function with given fields: ctx, updateFlatModel
-func (_m *FlatsRepository) UpdateFlat(ctx context.Context, updateFlatModel flat.UpdateFlatEntity) (*flat.FlatEntity, error) {
+func (_m *FlatsRepository) UpdateFlat(ctx context.Context, updateFlatModel flatsRepo.UpdateFlatEntity) (*flatsRepo.FlatEntity, error) {
 	ret := _m.Called(ctx, updateFlatModel)
 
-	var r0 *flat.FlatEntity
+	var r0 *flatsRepo.FlatEntity
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, flat.UpdateFlatEntity) (*flat.FlatEntity, error)); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, flatsRepo.UpdateFlatEntity) (*flatsRepo.FlatEntity, error)); ok {
 		return rf(ctx, updateFlatModel)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, flat.UpdateFlatEntity) *flat.FlatEntity); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, flatsRepo.UpdateFlatEntity) *flatsRepo.FlatEntity); ok {
 		r0 = rf(ctx, updateFlatModel)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*flat.FlatEntity)
+			r0 = ret.Get(0).(*flatsRepo.FlatEntity)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, flat.UpdateFlatEntity) error); ok {
+	if rf, ok := ret.Get(1).(func(context.Context, flatsRepo.UpdateFlatEntity) error); ok {
 		r1 = rf(ctx, updateFlatModel)
 	} else {
 		r1 = ret.Error(1)
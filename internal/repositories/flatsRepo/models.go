@@ -3,17 +3,42 @@ package flatsRepo
 import "time"
 
 type CreateFlatEntity struct {
-	HouseID int64
-	Price   int64
-	Rooms   int64
-	Status  FlatModerationStatus
+	HouseID    int64
+	Price      int64
+	Rooms      int64
+	Status     FlatModerationStatus
+	FlatNumber *int64
+	FlatAttributes
 }
 
+// FlatAttributes необязательные характеристики квартиры
+type FlatAttributes struct {
+	TotalArea   *float64
+	LivingArea  *float64
+	Floor       *int64
+	TotalFloors *int64
+	Layout      *FlatLayout
+	HasBalcony  *bool
+	Description *string
+}
+
+type FlatLayout string
+
+const (
+	LayoutStudio    FlatLayout = "studio"
+	LayoutIsolated  FlatLayout = "isolated"
+	LayoutAdjoining FlatLayout = "adjoining"
+	LayoutOpen      FlatLayout = "open"
+)
+
 type UpdateFlatEntity struct {
-	ID          int64
-	Status      FlatModerationStatus
-	ModeratorID *string
-	UpdatedAt   *time.Time
+	ID           int64
+	Status       FlatModerationStatus
+	FromStatuses []FlatModerationStatus
+	ModeratorID  *string
+	UpdatedAt    *time.Time
+	// ExpectedVersion версия, которую видел клиент; nil - не проверять
+	ExpectedVersion *int64
 }
 
 type FlatEntity struct {
@@ -23,6 +48,124 @@ type FlatEntity struct {
 	Rooms       int64
 	Status      FlatModerationStatus
 	ModeratorID *string
+	CreatedAt   time.Time
+	FlatNumber  *int64 // номер квартиры, уникален в пределах дома
+	FlatAttributes
+	Version int64 // растет при каждом изменении квартиры
+}
+
+// ExportFlatEntity квартира вместе с данными дома для выгрузки
+type ExportFlatEntity struct {
+	FlatEntity
+	HouseAddress   string
+	HouseYear      int
+	HouseDeveloper *string
+}
+
+type ChangeFlatPriceEntity struct {
+	ID              int64
+	Price           int64
+	FromStatuses    []FlatModerationStatus
+	ChangedBy       string
+	ExpectedVersion *int64 // если задано, цена меняется только при совпадении версии
+}
+
+type FlatChangeType string
+
+const (
+	ChangeCreated FlatChangeType = "created"
+	ChangePrice   FlatChangeType = "price"
+	ChangeStatus  FlatChangeType = "status"
+)
+
+// FlatHistoryEntity состояние квартиры после очередного изменения цены или статуса
+type FlatHistoryEntity struct {
+	ID         int64
+	FlatID     int64
+	ChangeType FlatChangeType
+	Price      int64
+	Status     FlatModerationStatus
+	ChangedBy  *string // uuid пользователя; пусто для автоматических изменений
+	CreatedAt  time.Time
+}
+
+// QueuePosition позиция в очереди модерации для keyset-пагинации
+type QueuePosition struct {
+	CreatedAt time.Time
+	ID        int64
+}
+
+type FlatSortField string
+
+const (
+	SortByPrice     FlatSortField = "price"
+	SortByCreatedAt FlatSortField = "created_at"
+)
+
+// SearchFlatsFilter параметры поиска квартир. Незаполненные поля выборку не ограничивают.
+type SearchFlatsFilter struct {
+	MinPrice  *int64
+	MaxPrice  *int64
+	Rooms     []int64
+	HouseID   *int64
+	Developer *string
+	MinYear   *int
+	MaxYear   *int
+	Statuses  []FlatModerationStatus
+	SortBy    FlatSortField
+	Desc      bool
+	Limit     uint64
+	After     *SearchPosition
+}
+
+// SearchPosition последняя отданная квартира для keyset-пагинации поиска.
+// Заполняется только поле, соответствующее SortBy.
+type SearchPosition struct {
+	Price     int64
+	CreatedAt time.Time
+	ID        int64
+}
+
+type HouseFlatsSortField string
+
+const (
+	HouseFlatsSortByID    HouseFlatsSortField = "id"
+	HouseFlatsSortByPrice HouseFlatsSortField = "price"
+	HouseFlatsSortByRooms HouseFlatsSortField = "rooms"
+)
+
+// HouseFlatsPage страница квартир дома. After - значение ключа сортировки и id последней отданной квартиры,
+// при сортировке по id значение не используется.
+type HouseFlatsPage struct {
+	SortBy HouseFlatsSortField
+	Desc   bool
+	Limit  uint64
+	After  *HouseFlatsPosition
+}
+
+// HouseFlatsState сводка по квартирам дома, которая меняется при любом изменении списка квартир.
+// По ней дешево строится ETag списка без чтения самих квартир.
+type HouseFlatsState struct {
+	HouseVersion int64
+	Count        int64
+	FlatsDigest  string // md5 пар id:version квартир по возрастанию id
+}
+
+type HouseFlatsPosition struct {
+	Value int64
+	ID    int64
+}
+
+// FlatEventPayload тело события об изменении квартиры, которое уходит в outbox
+type FlatEventPayload struct {
+	FlatID      int64                `json:"flat_id"`
+	HouseID     int64                `json:"house_id"`
+	Price       int64                `json:"price"`
+	Rooms       int64                `json:"rooms"`
+	FlatNumber  *int64               `json:"flat_number,omitempty"`
+	Status      FlatModerationStatus `json:"status"`
+	ModeratorID *string              `json:"moderator_id,omitempty"`
+	OccurredAt  time.Time            `json:"occurred_at"`
 }
 
 type FlatModerationStatus string
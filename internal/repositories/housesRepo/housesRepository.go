@@ -2,10 +2,16 @@ package housesRepo
 
 import (
 	"context"
+	"errors"
+	"strings"
 
 	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v4"
 
 	"realty-avito/internal/client/db"
+	repo_errors "realty-avito/internal/errors"
+	"realty-avito/internal/repositories/flatsRepo"
+	"realty-avito/internal/repositories/outboxRepo"
 )
 
 const (
@@ -15,21 +21,47 @@ const (
 	addressColumn   = "address"
 	yearColumn      = "year"
 	developerColumn = "developer"
+	createdAtColumn = "created_at"
 	updatedAtColumn = "updated_at"
+	deletedAtColumn = "deleted_at"
+	versionColumn   = "version"
 )
 
+// cascadedFlatColumns колонки квартиры, из которых собирается событие в outbox, в порядке cascadedFlat.dest
+const cascadedFlatColumns = "flats.id, flats.house_id, flats.price, flats.rooms, flats.flat_number, flats.status, flats.moderator_id"
+
+const joinedFlatColumns = "f.id, f.house_id, f.price, f.rooms, f.flat_number, f.status, f.moderator_id"
+
+// houseColumns колонки дома в том порядке, в котором их читает scanHouse
+var houseColumns = []string{idColumn, addressColumn, yearColumn, developerColumn, createdAtColumn, updatedAtColumn, versionColumn}
+
+// likeEscaper экранирует спецсимволы LIKE в пользовательской подстроке
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
 //go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=HousesRepository
 type HousesRepository interface {
 	CreateHouse(ctx context.Context, createHouseEntity CreateHouseEntity) (*HouseEntity, error)
 	UpdateHouseUpdatedAt(ctx context.Context, houseID int64) error
+	GetHouseByID(ctx context.Context, houseID int64) (*HouseEntity, error)
+	ListHouses(ctx context.Context, filter ListHousesFilter) ([]HouseEntity, error)
+	UpdateHouse(ctx context.Context, updateHouseEntity UpdateHouseEntity) (*HouseEntity, error)
+	DeleteHouse(ctx context.Context, houseID int64) error
+	RestoreHouse(ctx context.Context, houseID int64) (*HouseEntity, error)
+}
+
+// EventWriter пишет события о квартирах, удаленных или восстановленных вместе с домом, в outbox
+type EventWriter interface {
+	AddEvent(ctx context.Context, createEventEntity outboxRepo.CreateEventEntity) error
 }
 
 type housesRepository struct {
-	db db.Client
+	db        db.Client
+	txManager db.TxManager
+	events    EventWriter
 }
 
-func NewHousesRepository(db db.Client) HousesRepository {
-	return &housesRepository{db: db}
+func NewHousesRepository(db db.Client, txManager db.TxManager, events EventWriter) HousesRepository {
+	return &housesRepository{db: db, txManager: txManager, events: events}
 }
 
 func (r *housesRepository) CreateHouse(ctx context.Context, createHouseEntity CreateHouseEntity) (*HouseEntity, error) {
@@ -38,7 +70,7 @@ func (r *housesRepository) CreateHouse(ctx context.Context, createHouseEntity Cr
 		PlaceholderFormat(squirrel.Dollar).
 		Columns(addressColumn, yearColumn, developerColumn).
 		Values(createHouseEntity.Address, createHouseEntity.Year, createHouseEntity.Developer).
-		Suffix("RETURNING id, created_at, address, year, developer")
+		Suffix("RETURNING " + strings.Join(houseColumns, ", "))
 
 	query, args, err := insertBuilder.ToSql()
 	if err != nil {
@@ -52,9 +84,7 @@ func (r *housesRepository) CreateHouse(ctx context.Context, createHouseEntity Cr
 
 	var house HouseEntity
 
-	err = r.db.DB().
-		QueryRowContext(ctx, q, args...).
-		Scan(&house.ID, &house.CreatedAt, &house.Address, &house.Year, &house.Developer)
+	err = scanHouse(r.db.DB().QueryRowContext(ctx, q, args...), &house)
 	if err != nil {
 		return nil, err
 	}
@@ -62,11 +92,12 @@ func (r *housesRepository) CreateHouse(ctx context.Context, createHouseEntity Cr
 	return &house, nil
 }
 
+// UpdateHouseUpdatedAt отмечает добавление квартиры в дом. Для удаленного дома возвращает repo_errors.ErrHouseNotFound.
 func (r *housesRepository) UpdateHouseUpdatedAt(ctx context.Context, houseID int64) error {
 	updateBuilder := squirrel.
 		Update(tableName).
 		Set(updatedAtColumn, squirrel.Expr("CURRENT_TIMESTAMP")).
-		Where(squirrel.Eq{idColumn: houseID}).
+		Where(squirrel.Eq{idColumn: houseID, deletedAtColumn: nil}).
 		PlaceholderFormat(squirrel.Dollar)
 
 	query, args, err := updateBuilder.ToSql()
@@ -79,10 +110,319 @@ func (r *housesRepository) UpdateHouseUpdatedAt(ctx context.Context, houseID int
 		QueryRaw: query,
 	}
 
-	_, err = r.db.DB().ExecContext(ctx, q, args...)
+	tag, err := r.db.DB().ExecContext(ctx, q, args...)
 	if err != nil {
 		return err
 	}
 
+	if tag.RowsAffected() == 0 {
+		return &repo_errors.ErrHouseNotFound{HouseID: houseID}
+	}
+
 	return nil
 }
+
+func (r *housesRepository) GetHouseByID(ctx context.Context, houseID int64) (*HouseEntity, error) {
+	selectBuilder := squirrel.
+		Select(houseColumns...).
+		From(tableName).
+		Where(squirrel.Eq{idColumn: houseID, deletedAtColumn: nil}).
+		PlaceholderFormat(squirrel.Dollar)
+
+	query, args, err := selectBuilder.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	q := db.Query{
+		Name:     "housesRepository.GetHouseByID",
+		QueryRaw: query,
+	}
+
+	var house HouseEntity
+
+	err = scanHouse(r.db.DB().QueryRowContext(ctx, q, args...), &house)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, &repo_errors.ErrHouseNotFound{HouseID: houseID}
+		}
+
+		return nil, err
+	}
+
+	return &house, nil
+}
+
+// ListHouses возвращает дома по фильтру, упорядоченные по id
+func (r *housesRepository) ListHouses(ctx context.Context, filter ListHousesFilter) ([]HouseEntity, error) {
+	selectBuilder := squirrel.
+		Select(houseColumns...).
+		From(tableName).
+		Where(squirrel.Gt{idColumn: filter.AfterID}).
+		Where(squirrel.Eq{deletedAtColumn: nil}).
+		OrderBy(idColumn).
+		Limit(filter.Limit).
+		PlaceholderFormat(squirrel.Dollar)
+
+	if filter.Developer != nil {
+		selectBuilder = selectBuilder.Where(squirrel.Eq{developerColumn: *filter.Developer})
+	}
+	if filter.MinYear != nil {
+		selectBuilder = selectBuilder.Where(squirrel.GtOrEq{yearColumn: *filter.MinYear})
+	}
+	if filter.MaxYear != nil {
+		selectBuilder = selectBuilder.Where(squirrel.LtOrEq{yearColumn: *filter.MaxYear})
+	}
+	if filter.Address != nil {
+		selectBuilder = selectBuilder.Where(squirrel.ILike{addressColumn: "%" + likeEscaper.Replace(*filter.Address) + "%"})
+	}
+
+	query, args, err := selectBuilder.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	q := db.Query{
+		Name:     "housesRepository.ListHouses",
+		QueryRaw: query,
+	}
+
+	rows, err := r.db.DB().QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var houses []HouseEntity
+
+	for rows.Next() {
+		var house HouseEntity
+
+		if err := scanHouse(rows, &house); err != nil {
+			return nil, err
+		}
+
+		houses = append(houses, house)
+	}
+
+	return houses, rows.Err()
+}
+
+// UpdateHouse меняет адрес, год и застройщика. updated_at не трогает:
+// он означает дату добавления последней квартиры, а не редактирования дома.
+// Если задана ExpectedVersion, а дом уже в другой версии, возвращает repo_errors.ErrVersionMismatch.
+func (r *housesRepository) UpdateHouse(ctx context.Context, updateHouseEntity UpdateHouseEntity) (*HouseEntity, error) {
+	updateBuilder := squirrel.
+		Update(tableName).
+		Where(squirrel.Eq{idColumn: updateHouseEntity.ID, deletedAtColumn: nil}).
+		Suffix("RETURNING " + strings.Join(houseColumns, ", ")).
+		PlaceholderFormat(squirrel.Dollar)
+
+	if updateHouseEntity.Address != nil {
+		updateBuilder = updateBuilder.Set(addressColumn, *updateHouseEntity.Address)
+	}
+	if updateHouseEntity.Year != nil {
+		updateBuilder = updateBuilder.Set(yearColumn, *updateHouseEntity.Year)
+	}
+	if updateHouseEntity.Developer != nil {
+		updateBuilder = updateBuilder.Set(developerColumn, *updateHouseEntity.Developer)
+	}
+	if updateHouseEntity.ExpectedVersion != nil {
+		updateBuilder = updateBuilder.Where(squirrel.Eq{versionColumn: *updateHouseEntity.ExpectedVersion})
+	}
+
+	query, args, err := updateBuilder.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	q := db.Query{
+		Name:     "housesRepository.UpdateHouse",
+		QueryRaw: query,
+	}
+
+	var house HouseEntity
+
+	err = scanHouse(r.db.DB().QueryRowContext(ctx, q, args...), &house)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			if updateHouseEntity.ExpectedVersion == nil {
+				return nil, &repo_errors.ErrHouseNotFound{HouseID: updateHouseEntity.ID}
+			}
+
+			// строка не обновилась: дом удален или его успели изменить
+			if _, err := r.GetHouseByID(ctx, updateHouseEntity.ID); err != nil {
+				return nil, err
+			}
+
+			return nil, repo_errors.ErrVersionMismatch
+		}
+
+		return nil, err
+	}
+
+	return &house, nil
+}
+
+// DeleteHouse мягко удаляет дом вместе с его квартирами. Квартиры получают ту же метку deleted_at,
+// что и дом, по ней RestoreHouse восстанавливает ровно их. На каждую удаленную квартиру
+// в той же транзакции пишется событие flat.deleted.
+func (r *housesRepository) DeleteHouse(ctx context.Context, houseID int64) error {
+	q := db.Query{
+		Name: "housesRepository.DeleteHouse",
+		QueryRaw: `WITH house AS (
+				UPDATE houses SET deleted_at = CURRENT_TIMESTAMP
+				WHERE id = $1 AND deleted_at IS NULL
+				RETURNING id, deleted_at
+			), deleted_flats AS (
+				UPDATE flats SET deleted_at = house.deleted_at
+				FROM house
+				WHERE flats.house_id = house.id AND flats.deleted_at IS NULL
+				RETURNING ` + cascadedFlatColumns + `
+			)
+			SELECT house.id, ` + joinedFlatColumns + `
+			FROM house LEFT JOIN deleted_flats f ON TRUE
+			ORDER BY f.id`,
+	}
+
+	return r.txManager.ReadCommitted(ctx, func(ctx context.Context) error {
+		var deletedID int64
+
+		flats, found, errTx := r.queryCascade(ctx, q, houseID, &deletedID)
+		if errTx != nil {
+			return errTx
+		}
+
+		if !found {
+			return &repo_errors.ErrHouseNotFound{HouseID: houseID}
+		}
+
+		return r.addFlatEvents(ctx, flatsRepo.EventFlatDeleted, flats)
+	})
+}
+
+// RestoreHouse восстанавливает дом и квартиры, удаленные вместе с ним.
+// Квартиры, удаленные раньше дома по отдельности, остаются удаленными.
+// На каждую восстановленную квартиру в той же транзакции пишется событие flat.restored.
+func (r *housesRepository) RestoreHouse(ctx context.Context, houseID int64) (*HouseEntity, error) {
+	q := db.Query{
+		Name: "housesRepository.RestoreHouse",
+		QueryRaw: `WITH house AS (
+				SELECT id, deleted_at FROM houses
+				WHERE id = $1 AND deleted_at IS NOT NULL
+				FOR UPDATE
+			), restored_flats AS (
+				UPDATE flats SET deleted_at = NULL
+				FROM house
+				WHERE flats.house_id = house.id AND flats.deleted_at = house.deleted_at
+				RETURNING ` + cascadedFlatColumns + `
+			), restored_house AS (
+				UPDATE houses SET deleted_at = NULL
+				FROM house
+				WHERE houses.id = house.id
+				RETURNING houses.id, houses.address, houses.year, houses.developer, houses.created_at, houses.updated_at, houses.version
+			)
+			SELECT h.id, h.address, h.year, h.developer, h.created_at, h.updated_at, h.version, ` + joinedFlatColumns + `
+			FROM restored_house h LEFT JOIN restored_flats f ON TRUE
+			ORDER BY f.id`,
+	}
+
+	var house HouseEntity
+
+	err := r.txManager.ReadCommitted(ctx, func(ctx context.Context) error {
+		flats, found, errTx := r.queryCascade(ctx, q, houseID,
+			&house.ID, &house.Address, &house.Year, &house.Developer, &house.CreatedAt, &house.UpdatedAt, &house.Version,
+		)
+		if errTx != nil {
+			return errTx
+		}
+
+		if !found {
+			return &repo_errors.ErrHouseNotFound{HouseID: houseID}
+		}
+
+		return r.addFlatEvents(ctx, flatsRepo.EventFlatRestored, flats)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &house, nil
+}
+
+// queryCascade выполняет удаление или восстановление дома с квартирами. Каждая строка результата -
+// колонки дома, которые читаются в houseDest, и затронутая квартира или NULL, если квартир нет.
+// Возвращает false, если дом не найден.
+func (r *housesRepository) queryCascade(ctx context.Context, q db.Query, houseID int64, houseDest ...interface{}) ([]flatsRepo.FlatEntity, bool, error) {
+	rows, err := r.db.DB().QueryContext(ctx, q, houseID)
+	if err != nil {
+		return nil, false, err
+	}
+	defer rows.Close()
+
+	var (
+		flats []flatsRepo.FlatEntity
+		found bool
+	)
+
+	for rows.Next() {
+		var flat cascadedFlat
+
+		if err := rows.Scan(append(houseDest, flat.dest()...)...); err != nil {
+			return nil, false, err
+		}
+
+		found = true
+		if flat.ID != nil {
+			flats = append(flats, flat.entity())
+		}
+	}
+
+	return flats, found, rows.Err()
+}
+
+func (r *housesRepository) addFlatEvents(ctx context.Context, eventType string, flats []flatsRepo.FlatEntity) error {
+	for i := range flats {
+		event, err := flatsRepo.NewFlatEvent(eventType, &flats[i])
+		if err != nil {
+			return err
+		}
+
+		if err := r.events.AddEvent(ctx, event); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// cascadedFlat квартира из LEFT JOIN каскадного запроса, все поля пустые, если у дома нет затронутых квартир
+type cascadedFlat struct {
+	ID          *int64
+	HouseID     *int64
+	Price       *int64
+	Rooms       *int64
+	FlatNumber  *int64
+	Status      *string
+	ModeratorID *string
+}
+
+func (f *cascadedFlat) dest() []interface{} {
+	return []interface{}{&f.ID, &f.HouseID, &f.Price, &f.Rooms, &f.FlatNumber, &f.Status, &f.ModeratorID}
+}
+
+func (f *cascadedFlat) entity() flatsRepo.FlatEntity {
+	return flatsRepo.FlatEntity{
+		ID:          *f.ID,
+		HouseID:     *f.HouseID,
+		Price:       *f.Price,
+		Rooms:       *f.Rooms,
+		FlatNumber:  f.FlatNumber,
+		Status:      flatsRepo.FlatModerationStatus(*f.Status),
+		ModeratorID: f.ModeratorID,
+	}
+}
+
+func scanHouse(row pgx.Row, house *HouseEntity) error {
+	return row.Scan(&house.ID, &house.Address, &house.Year, &house.Developer, &house.CreatedAt, &house.UpdatedAt, &house.Version)
+}
@@ -4,7 +4,7 @@ package mocks
 
 import (
 	context "context"
-	house "realty-avito/internal/repositories/housesRepo"
+	housesRepo "realty-avito/internal/repositories/housesRepo"
 
 	mock "github.com/stretchr/testify/mock"
 )
@@ -15,23 +15,23 @@ type HousesRepository struct {
 }
 
 // CreateHouse provides a mock function with given fields: ctx, createHouseEntity
-func (_m *HousesRepository) CreateHouse(ctx context.Context, createHouseEntity house.CreateHouseEntity) (*house.HouseEntity, error) {
+func (_m *HousesRepository) CreateHouse(ctx context.Context, createHouseEntity housesRepo.CreateHouseEntity) (*housesRepo.HouseEntity, error) {
 	ret := _m.Called(ctx, createHouseEntity)
 
-	var r0 *house.HouseEntity
+	var r0 *housesRepo.HouseEntity
 	var r1 error
-	if rf, ok := ret.Get(0).(func(context.Context, house.CreateHouseEntity) (*house.HouseEntity, error)); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, housesRepo.CreateHouseEntity) (*housesRepo.HouseEntity, error)); ok {
 		return rf(ctx, createHouseEntity)
 	}
-	if rf, ok := ret.Get(0).(func(context.Context, house.CreateHouseEntity) *house.HouseEntity); ok {
+	if rf, ok := ret.Get(0).(func(context.Context, housesRepo.CreateHouseEntity) *housesRepo.HouseEntity); ok {
 		r0 = rf(ctx, createHouseEntity)
 	} else {
 		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*house.HouseEntity)
+			r0 = ret.Get(0).(*housesRepo.HouseEntity)
 		}
 	}
 
-	if rf, ok := ret.Get(1).(func(context.Context, house.CreateHouseEntity) error); ok {
+	if rf, ok := ret.Get(1).(func(context.Context, housesRepo.CreateHouseEntity) error); ok {
 		r1 = rf(ctx, createHouseEntity)
 	} else {
 		r1 = ret.Error(1)
@@ -40,6 +40,124 @@ func (_m *HousesRepository) CreateHouse(ctx context.Context, createHouseEntity h
 	return r0, r1
 }
 
+// DeleteHouse provides a mock function with given fields: ctx, houseID
+func (_m *HousesRepository) DeleteHouse(ctx context.Context, houseID int64) error {
+	ret := _m.Called(ctx, houseID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) error); ok {
+		r0 = rf(ctx, houseID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetHouseByID provides a mock function with given fields: ctx, houseID
+func (_m *HousesRepository) GetHouseByID(ctx context.Context, houseID int64) (*housesRepo.HouseEntity, error) {
+	ret := _m.Called(ctx, houseID)
+
+	var r0 *housesRepo.HouseEntity
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) (*housesRepo.HouseEntity, error)); ok {
+		return rf(ctx, houseID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) *housesRepo.HouseEntity); ok {
+		r0 = rf(ctx, houseID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*housesRepo.HouseEntity)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, houseID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ListHouses provides a mock function with given fields: ctx, filter
+func (_m *HousesRepository) ListHouses(ctx context.Context, filter housesRepo.ListHousesFilter) ([]housesRepo.HouseEntity, error) {
+	ret := _m.Called(ctx, filter)
+
+	var r0 []housesRepo.HouseEntity
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, housesRepo.ListHousesFilter) ([]housesRepo.HouseEntity, error)); ok {
+		return rf(ctx, filter)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, housesRepo.ListHousesFilter) []housesRepo.HouseEntity); ok {
+		r0 = rf(ctx, filter)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]housesRepo.HouseEntity)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, housesRepo.ListHousesFilter) error); ok {
+		r1 = rf(ctx, filter)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RestoreHouse provides a mock function with given fields: ctx, houseID
+func (_m *HousesRepository) RestoreHouse(ctx context.Context, houseID int64) (*housesRepo.HouseEntity, error) {
+	ret := _m.Called(ctx, houseID)
+
+	var r0 *housesRepo.HouseEntity
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) (*housesRepo.HouseEntity, error)); ok {
+		return rf(ctx, houseID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) *housesRepo.HouseEntity); ok {
+		r0 = rf(ctx, houseID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*housesRepo.HouseEntity)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, houseID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// UpdateHouse provides a mock function with given fields: ctx, updateHouseEntity
+func (_m *HousesRepository) UpdateHouse(ctx context.Context, updateHouseEntity housesRepo.UpdateHouseEntity) (*housesRepo.HouseEntity, error) {
+	ret := _m.Called(ctx, updateHouseEntity)
+
+	var r0 *housesRepo.HouseEntity
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, housesRepo.UpdateHouseEntity) (*housesRepo.HouseEntity, error)); ok {
+		return rf(ctx, updateHouseEntity)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, housesRepo.UpdateHouseEntity) *housesRepo.HouseEntity); ok {
+		r0 = rf(ctx, updateHouseEntity)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*housesRepo.HouseEntity)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, housesRepo.UpdateHouseEntity) error); ok {
+		r1 = rf(ctx, updateHouseEntity)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // UpdateHouseUpdatedAt provides a mock function with given fields: ctx, houseID
 func (_m *HousesRepository) UpdateHouseUpdatedAt(ctx context.Context, houseID int64) error {
 	ret := _m.Called(ctx, houseID)
@@ -14,4 +14,26 @@ type HouseEntity struct {
 	Year      int
 	Developer *string
 	CreatedAt time.Time
+	UpdatedAt *time.Time // дата добавления последней квартиры
+	Version   int64      // растет при каждом изменении дома
+}
+
+// UpdateHouseEntity изменяемые поля дома, nil означает "не менять"
+type UpdateHouseEntity struct {
+	ID        int64
+	Address   *string
+	Year      *int
+	Developer *string
+	// ExpectedVersion версия, которую видел клиент; nil - не проверять
+	ExpectedVersion *int64
+}
+
+// ListHousesFilter фильтр списка домов. Address ищется как подстрока без учета регистра.
+type ListHousesFilter struct {
+	Developer *string
+	MinYear   *int
+	MaxYear   *int
+	Address   *string
+	Limit     uint64
+	AfterID   int64
 }
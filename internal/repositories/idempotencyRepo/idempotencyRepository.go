@@ -0,0 +1,210 @@
+package idempotencyRepo
+
+import (
+	"context"
+	"errors"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v4"
+
+	"realty-avito/internal/client/db"
+)
+
+const (
+	tableName = "idempotency_keys"
+
+	subjectColumn      = "subject"
+	keyColumn          = "idempotency_key"
+	fingerprintColumn  = "fingerprint"
+	statusCodeColumn   = "status_code"
+	contentTypeColumn  = "content_type"
+	responseBodyColumn = "response_body"
+	expiresAtColumn    = "expires_at"
+	lockedUntilColumn  = "locked_until"
+	leaseIDColumn      = "lease_id"
+)
+
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=IdempotencyRepository
+type IdempotencyRepository interface {
+	ReserveKey(ctx context.Context, reserveKeyEntity ReserveKeyEntity) (*KeyEntity, bool, error)
+	SaveResponse(ctx context.Context, saveResponseEntity SaveResponseEntity) error
+	ReleaseKey(ctx context.Context, subject string, key string, leaseID string) error
+	DeleteExpiredKeys(ctx context.Context) (int64, error)
+}
+
+type idempotencyRepository struct {
+	db db.Client
+}
+
+func NewIdempotencyRepository(db db.Client) IdempotencyRepository {
+	return &idempotencyRepository{db: db}
+}
+
+// ReserveKey атомарно занимает ключ под новый запрос и возвращает true вместе с ключом,
+// LeaseID которого нужно передать в SaveResponse и ReleaseKey.
+// Если ключ уже занят и не истек, возвращает сохраненную запись и false.
+// Ключ без сохраненного ответа занимается заново, когда истекла его аренда locked_until:
+// значит, процесс, выполнявший первый запрос, упал, не успев ни сохранить ответ, ни освободить ключ.
+func (r *idempotencyRepository) ReserveKey(ctx context.Context, reserveKeyEntity ReserveKeyEntity) (*KeyEntity, bool, error) {
+	insertQuery := db.Query{
+		Name: "idempotencyRepository.ReserveKey",
+		QueryRaw: `INSERT INTO idempotency_keys (subject, idempotency_key, fingerprint, expires_at, locked_until, lease_id)
+			VALUES ($1, $2, $3, CURRENT_TIMESTAMP + $4 * INTERVAL '1 second', CURRENT_TIMESTAMP + $5 * INTERVAL '1 second', $6)
+			ON CONFLICT (subject, idempotency_key) DO UPDATE
+			SET fingerprint = EXCLUDED.fingerprint, status_code = NULL, content_type = NULL, response_body = NULL,
+				created_at = CURRENT_TIMESTAMP, expires_at = EXCLUDED.expires_at, locked_until = EXCLUDED.locked_until,
+				lease_id = EXCLUDED.lease_id
+			WHERE idempotency_keys.expires_at <= CURRENT_TIMESTAMP
+				OR (idempotency_keys.status_code IS NULL AND idempotency_keys.locked_until <= CURRENT_TIMESTAMP)
+			RETURNING subject`,
+	}
+
+	// между неудачной вставкой и чтением ключ могли освободить, тогда пробуем занять его еще раз
+	for attempt := 0; attempt < 2; attempt++ {
+		var subject string
+
+		leaseID := uuid.New().String()
+
+		err := r.db.DB().QueryRowContext(ctx, insertQuery,
+			reserveKeyEntity.Subject,
+			reserveKeyEntity.Key,
+			reserveKeyEntity.Fingerprint,
+			int64(reserveKeyEntity.TTL.Seconds()),
+			int64(reserveKeyEntity.LockTimeout.Seconds()),
+			leaseID,
+		).Scan(&subject)
+		if err == nil {
+			return &KeyEntity{
+				Subject:     reserveKeyEntity.Subject,
+				Key:         reserveKeyEntity.Key,
+				Fingerprint: reserveKeyEntity.Fingerprint,
+				LeaseID:     leaseID,
+			}, true, nil
+		}
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return nil, false, err
+		}
+
+		key, err := r.getKey(ctx, reserveKeyEntity.Subject, reserveKeyEntity.Key)
+		if err != nil {
+			return nil, false, err
+		}
+
+		if key != nil {
+			return key, false, nil
+		}
+	}
+
+	return nil, false, errors.New("idempotency key is released concurrently")
+}
+
+func (r *idempotencyRepository) getKey(ctx context.Context, subject string, key string) (*KeyEntity, error) {
+	selectBuilder := squirrel.
+		Select(subjectColumn, keyColumn, fingerprintColumn, statusCodeColumn, contentTypeColumn, responseBodyColumn).
+		From(tableName).
+		Where(squirrel.Eq{subjectColumn: subject, keyColumn: key}).
+		PlaceholderFormat(squirrel.Dollar)
+
+	query, args, err := selectBuilder.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	q := db.Query{
+		Name:     "idempotencyRepository.getKey",
+		QueryRaw: query,
+	}
+
+	var entity KeyEntity
+
+	err = r.db.DB().
+		QueryRowContext(ctx, q, args...).
+		Scan(&entity.Subject, &entity.Key, &entity.Fingerprint, &entity.StatusCode, &entity.ContentType, &entity.ResponseBody)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	return &entity, nil
+}
+
+// SaveResponse сохраняет ответ на первый запрос, дальше он отдается на повторы с тем же ключом.
+// Если аренду ключа уже перехватил повтор, ничего не делает.
+func (r *idempotencyRepository) SaveResponse(ctx context.Context, saveResponseEntity SaveResponseEntity) error {
+	updateBuilder := squirrel.
+		Update(tableName).
+		Set(statusCodeColumn, saveResponseEntity.StatusCode).
+		Set(contentTypeColumn, saveResponseEntity.ContentType).
+		Set(responseBodyColumn, saveResponseEntity.ResponseBody).
+		Set(lockedUntilColumn, nil).
+		Where(squirrel.Eq{
+			subjectColumn: saveResponseEntity.Subject,
+			keyColumn:     saveResponseEntity.Key,
+			leaseIDColumn: saveResponseEntity.LeaseID,
+		}).
+		PlaceholderFormat(squirrel.Dollar)
+
+	query, args, err := updateBuilder.ToSql()
+	if err != nil {
+		return err
+	}
+
+	q := db.Query{
+		Name:     "idempotencyRepository.SaveResponse",
+		QueryRaw: query,
+	}
+
+	_, err = r.db.DB().ExecContext(ctx, q, args...)
+	return err
+}
+
+// ReleaseKey освобождает ключ, запрос по которому не завершился, чтобы клиент мог его повторить.
+// Ключ, аренду которого уже перехватил повтор, не трогается.
+func (r *idempotencyRepository) ReleaseKey(ctx context.Context, subject string, key string, leaseID string) error {
+	deleteBuilder := squirrel.
+		Delete(tableName).
+		Where(squirrel.Eq{subjectColumn: subject, keyColumn: key, leaseIDColumn: leaseID, statusCodeColumn: nil}).
+		PlaceholderFormat(squirrel.Dollar)
+
+	query, args, err := deleteBuilder.ToSql()
+	if err != nil {
+		return err
+	}
+
+	q := db.Query{
+		Name:     "idempotencyRepository.ReleaseKey",
+		QueryRaw: query,
+	}
+
+	_, err = r.db.DB().ExecContext(ctx, q, args...)
+	return err
+}
+
+// DeleteExpiredKeys удаляет истекшие ключи и возвращает их количество
+func (r *idempotencyRepository) DeleteExpiredKeys(ctx context.Context) (int64, error) {
+	deleteBuilder := squirrel.
+		Delete(tableName).
+		Where(squirrel.Expr(expiresAtColumn + " <= CURRENT_TIMESTAMP")).
+		PlaceholderFormat(squirrel.Dollar)
+
+	query, args, err := deleteBuilder.ToSql()
+	if err != nil {
+		return 0, err
+	}
+
+	q := db.Query{
+		Name:     "idempotencyRepository.DeleteExpiredKeys",
+		QueryRaw: query,
+	}
+
+	tag, err := r.db.DB().ExecContext(ctx, q, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	return tag.RowsAffected(), nil
+}
@@ -0,0 +1,115 @@
+// Code generated by mockery v2.28.2. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+	idempotencyRepo "realty-avito/internal/repositories/idempotencyRepo"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// IdempotencyRepository is an autogenerated mock type for the IdempotencyRepository type
+type IdempotencyRepository struct {
+	mock.Mock
+}
+
+// DeleteExpiredKeys provides a mock function with given fields: ctx
+func (_m *IdempotencyRepository) DeleteExpiredKeys(ctx context.Context) (int64, error) {
+	ret := _m.Called(ctx)
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) (int64, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) int64); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ReleaseKey provides a mock function with given fields: ctx, subject, key, leaseID
+func (_m *IdempotencyRepository) ReleaseKey(ctx context.Context, subject string, key string, leaseID string) error {
+	ret := _m.Called(ctx, subject, key, leaseID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) error); ok {
+		r0 = rf(ctx, subject, key, leaseID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ReserveKey provides a mock function with given fields: ctx, reserveKeyEntity
+func (_m *IdempotencyRepository) ReserveKey(ctx context.Context, reserveKeyEntity idempotencyRepo.ReserveKeyEntity) (*idempotencyRepo.KeyEntity, bool, error) {
+	ret := _m.Called(ctx, reserveKeyEntity)
+
+	var r0 *idempotencyRepo.KeyEntity
+	var r1 bool
+	var r2 error
+	if rf, ok := ret.Get(0).(func(context.Context, idempotencyRepo.ReserveKeyEntity) (*idempotencyRepo.KeyEntity, bool, error)); ok {
+		return rf(ctx, reserveKeyEntity)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, idempotencyRepo.ReserveKeyEntity) *idempotencyRepo.KeyEntity); ok {
+		r0 = rf(ctx, reserveKeyEntity)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*idempotencyRepo.KeyEntity)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, idempotencyRepo.ReserveKeyEntity) bool); ok {
+		r1 = rf(ctx, reserveKeyEntity)
+	} else {
+		r1 = ret.Get(1).(bool)
+	}
+
+	if rf, ok := ret.Get(2).(func(context.Context, idempotencyRepo.ReserveKeyEntity) error); ok {
+		r2 = rf(ctx, reserveKeyEntity)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// SaveResponse provides a mock function with given fields: ctx, saveResponseEntity
+func (_m *IdempotencyRepository) SaveResponse(ctx context.Context, saveResponseEntity idempotencyRepo.SaveResponseEntity) error {
+	ret := _m.Called(ctx, saveResponseEntity)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, idempotencyRepo.SaveResponseEntity) error); ok {
+		r0 = rf(ctx, saveResponseEntity)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+type mockConstructorTestingTNewIdempotencyRepository interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewIdempotencyRepository creates a new instance of IdempotencyRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewIdempotencyRepository(t mockConstructorTestingTNewIdempotencyRepository) *IdempotencyRepository {
+	mock := &IdempotencyRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
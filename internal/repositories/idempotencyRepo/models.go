@@ -0,0 +1,31 @@
+package idempotencyRepo
+
+import "time"
+
+type ReserveKeyEntity struct {
+	Subject     string
+	Key         string
+	Fingerprint string
+	TTL         time.Duration
+	LockTimeout time.Duration // сколько ключ считается занятым выполняющимся запросом
+}
+
+// KeyEntity сохраненный ключ идемпотентности. StatusCode пустой, пока первый запрос еще выполняется.
+type KeyEntity struct {
+	Subject      string
+	Key          string
+	Fingerprint  string
+	LeaseID      string // аренда запроса, занявшего ключ
+	StatusCode   *int
+	ContentType  *string
+	ResponseBody []byte
+}
+
+type SaveResponseEntity struct {
+	Subject      string
+	Key          string
+	LeaseID      string
+	StatusCode   int
+	ContentType  string
+	ResponseBody []byte
+}
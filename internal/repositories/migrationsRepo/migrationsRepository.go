@@ -0,0 +1,53 @@
+package migrationsRepo
+
+import (
+	"context"
+	"errors"
+
+	"github.com/jackc/pgx/v4"
+
+	"realty-avito/internal/client/db"
+)
+
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=MigrationsRepository
+type MigrationsRepository interface {
+	GetAppliedVersion(ctx context.Context) (int64, error)
+}
+
+type migrationsRepository struct {
+	db db.Client
+}
+
+func NewMigrationsRepository(db db.Client) MigrationsRepository {
+	return &migrationsRepository{db: db}
+}
+
+// GetAppliedVersion текущая версия схемы по таблице goose. Откат миграции goose записывает отдельной строкой
+// с is_applied = false, поэтому для каждой версии смотрится только последняя запись.
+// Если миграции еще не применялись, возвращает 0.
+func (r *migrationsRepository) GetAppliedVersion(ctx context.Context) (int64, error) {
+	q := db.Query{
+		Name: "migrationsRepository.GetAppliedVersion",
+		QueryRaw: `SELECT version_id FROM (
+				SELECT DISTINCT ON (version_id) version_id, is_applied
+				FROM goose_db_version
+				ORDER BY version_id, id DESC
+			) versions
+			WHERE is_applied
+			ORDER BY version_id DESC
+			LIMIT 1`,
+	}
+
+	var version int64
+
+	err := r.db.DB().QueryRowContext(ctx, q).Scan(&version)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return 0, nil
+		}
+
+		return 0, err
+	}
+
+	return version, nil
+}
@@ -0,0 +1,53 @@
+// Code generated by mockery v2.28.2. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// MigrationsRepository is an autogenerated mock type for the MigrationsRepository type
+type MigrationsRepository struct {
+	mock.Mock
+}
+
+// GetAppliedVersion provides a mock function with given fields: ctx
+func (_m *MigrationsRepository) GetAppliedVersion(ctx context.Context) (int64, error) {
+	ret := _m.Called(ctx)
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) (int64, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) int64); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+type mockConstructorTestingTNewMigrationsRepository interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewMigrationsRepository creates a new instance of MigrationsRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewMigrationsRepository(t mockConstructorTestingTNewMigrationsRepository) *MigrationsRepository {
+	mock := &MigrationsRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,124 @@
+// Code generated by mockery v2.28.2. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+	outboxRepo "realty-avito/internal/repositories/outboxRepo"
+
+	mock "github.com/stretchr/testify/mock"
+
+	time "time"
+)
+
+// OutboxRepository is an autogenerated mock type for the OutboxRepository type
+type OutboxRepository struct {
+	mock.Mock
+}
+
+// AddEvent provides a mock function with given fields: ctx, createEventEntity
+func (_m *OutboxRepository) AddEvent(ctx context.Context, createEventEntity outboxRepo.CreateEventEntity) error {
+	ret := _m.Called(ctx, createEventEntity)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, outboxRepo.CreateEventEntity) error); ok {
+		r0 = rf(ctx, createEventEntity)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetPendingEvents provides a mock function with given fields: ctx, limit
+func (_m *OutboxRepository) GetPendingEvents(ctx context.Context, limit int) ([]outboxRepo.EventEntity, error) {
+	ret := _m.Called(ctx, limit)
+
+	var r0 []outboxRepo.EventEntity
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int) ([]outboxRepo.EventEntity, error)); ok {
+		return rf(ctx, limit)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int) []outboxRepo.EventEntity); ok {
+		r0 = rf(ctx, limit)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]outboxRepo.EventEntity)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int) error); ok {
+		r1 = rf(ctx, limit)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// MarkDelivered provides a mock function with given fields: ctx, eventID
+func (_m *OutboxRepository) MarkDelivered(ctx context.Context, eventID int64) error {
+	ret := _m.Called(ctx, eventID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) error); ok {
+		r0 = rf(ctx, eventID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// MarkFailed provides a mock function with given fields: ctx, eventID, retryAfter, lastError
+func (_m *OutboxRepository) MarkFailed(ctx context.Context, eventID int64, retryAfter time.Duration, lastError string) error {
+	ret := _m.Called(ctx, eventID, retryAfter, lastError)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, time.Duration, string) error); ok {
+		r0 = rf(ctx, eventID, retryAfter, lastError)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// TryLockRelay provides a mock function with given fields: ctx
+func (_m *OutboxRepository) TryLockRelay(ctx context.Context) (bool, error) {
+	ret := _m.Called(ctx)
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) (bool, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) bool); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+type mockConstructorTestingTNewOutboxRepository interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewOutboxRepository creates a new instance of OutboxRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewOutboxRepository(t mockConstructorTestingTNewOutboxRepository) *OutboxRepository {
+	mock := &OutboxRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
@@ -0,0 +1,21 @@
+package outboxRepo
+
+import "time"
+
+type CreateEventEntity struct {
+	AggregateType string
+	AggregateID   int64
+	EventType     string
+	Payload       []byte
+}
+
+type EventEntity struct {
+	ID            int64
+	AggregateType string
+	AggregateID   int64
+	EventType     string
+	Payload       []byte
+	Attempts      int
+	Ready         bool // наступило ли время следующей попытки доставки
+	CreatedAt     time.Time
+}
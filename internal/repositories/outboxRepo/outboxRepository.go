@@ -0,0 +1,220 @@
+package outboxRepo
+
+import (
+	"context"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+
+	"realty-avito/internal/client/db"
+)
+
+const (
+	tableName = "outbox"
+
+	idColumn            = "id"
+	aggregateTypeColumn = "aggregate_type"
+	aggregateIDColumn   = "aggregate_id"
+	eventTypeColumn     = "event_type"
+	payloadColumn       = "payload"
+	attemptsColumn      = "attempts"
+	lastErrorColumn     = "last_error"
+	nextAttemptAtColumn = "next_attempt_at"
+	createdAtColumn     = "created_at"
+	deliveredAtColumn   = "delivered_at"
+
+	// relayLockKey ключ advisory-блокировки, которая гарантирует, что события разбирает только один релей
+	relayLockKey = 20240820
+	// writeLockKey ключ advisory-блокировки, которая выстраивает запись событий в порядок коммитов
+	writeLockKey = 20240821
+)
+
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=OutboxRepository
+type OutboxRepository interface {
+	AddEvent(ctx context.Context, createEventEntity CreateEventEntity) error
+	TryLockRelay(ctx context.Context) (bool, error)
+	GetPendingEvents(ctx context.Context, limit int) ([]EventEntity, error)
+	MarkDelivered(ctx context.Context, eventID int64) error
+	MarkFailed(ctx context.Context, eventID int64, retryAfter time.Duration, lastError string) error
+}
+
+type outboxRepository struct {
+	db db.Client
+}
+
+func NewOutboxRepository(db db.Client) OutboxRepository {
+	return &outboxRepository{db: db}
+}
+
+// AddEvent пишет событие в outbox. Чтобы событие было атомарным с изменением данных,
+// метод нужно вызывать внутри транзакции db.TxManager.
+// id событий выдаются при вставке, а не при коммите, поэтому перед вставкой берется блокировка
+// до конца транзакции: пока не закоммичено событие с меньшим id, событие с большим id не появится,
+// и релей, идущий по id, не обгонит еще не закоммиченное событие.
+func (r *outboxRepository) AddEvent(ctx context.Context, createEventEntity CreateEventEntity) error {
+	if err := r.lockWrites(ctx); err != nil {
+		return err
+	}
+
+	insertBuilder := squirrel.
+		Insert(tableName).
+		PlaceholderFormat(squirrel.Dollar).
+		Columns(aggregateTypeColumn, aggregateIDColumn, eventTypeColumn, payloadColumn).
+		Values(
+			createEventEntity.AggregateType,
+			createEventEntity.AggregateID,
+			createEventEntity.EventType,
+			createEventEntity.Payload,
+		)
+
+	query, args, err := insertBuilder.ToSql()
+	if err != nil {
+		return err
+	}
+
+	q := db.Query{
+		Name:     "outboxRepository.AddEvent",
+		QueryRaw: query,
+	}
+
+	_, err = r.db.DB().ExecContext(ctx, q, args...)
+	return err
+}
+
+func (r *outboxRepository) lockWrites(ctx context.Context) error {
+	q := db.Query{
+		Name:     "outboxRepository.lockWrites",
+		QueryRaw: "SELECT pg_advisory_xact_lock($1)",
+	}
+
+	_, err := r.db.DB().ExecContext(ctx, q, writeLockKey)
+	return err
+}
+
+// TryLockRelay берет advisory-блокировку до конца текущей транзакции.
+// Возвращает false, если события уже разбирает другой экземпляр релея.
+func (r *outboxRepository) TryLockRelay(ctx context.Context) (bool, error) {
+	q := db.Query{
+		Name:     "outboxRepository.TryLockRelay",
+		QueryRaw: "SELECT pg_try_advisory_xact_lock($1)",
+	}
+
+	var locked bool
+
+	err := r.db.DB().
+		QueryRowContext(ctx, q, relayLockKey).
+		Scan(&locked)
+	if err != nil {
+		return false, err
+	}
+
+	return locked, nil
+}
+
+// GetPendingEvents возвращает недоставленные события в порядке их создания и блокирует их до конца транзакции
+func (r *outboxRepository) GetPendingEvents(ctx context.Context, limit int) ([]EventEntity, error) {
+	selectBuilder := squirrel.
+		Select(
+			idColumn,
+			aggregateTypeColumn,
+			aggregateIDColumn,
+			eventTypeColumn,
+			payloadColumn,
+			attemptsColumn,
+			nextAttemptAtColumn+" <= CURRENT_TIMESTAMP",
+			createdAtColumn,
+		).
+		From(tableName).
+		Where(squirrel.Eq{deliveredAtColumn: nil}).
+		OrderBy(idColumn).
+		Limit(uint64(limit)).
+		Suffix("FOR UPDATE SKIP LOCKED").
+		PlaceholderFormat(squirrel.Dollar)
+
+	query, args, err := selectBuilder.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	q := db.Query{
+		Name:     "outboxRepository.GetPendingEvents",
+		QueryRaw: query,
+	}
+
+	rows, err := r.db.DB().QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var events []EventEntity
+
+	for rows.Next() {
+		var event EventEntity
+
+		err := rows.Scan(
+			&event.ID,
+			&event.AggregateType,
+			&event.AggregateID,
+			&event.EventType,
+			&event.Payload,
+			&event.Attempts,
+			&event.Ready,
+			&event.CreatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		events = append(events, event)
+	}
+
+	return events, rows.Err()
+}
+
+func (r *outboxRepository) MarkDelivered(ctx context.Context, eventID int64) error {
+	updateBuilder := squirrel.
+		Update(tableName).
+		Set(deliveredAtColumn, squirrel.Expr("CURRENT_TIMESTAMP")).
+		Set(attemptsColumn, squirrel.Expr(attemptsColumn+" + 1")).
+		Set(lastErrorColumn, nil).
+		Where(squirrel.Eq{idColumn: eventID}).
+		PlaceholderFormat(squirrel.Dollar)
+
+	query, args, err := updateBuilder.ToSql()
+	if err != nil {
+		return err
+	}
+
+	q := db.Query{
+		Name:     "outboxRepository.MarkDelivered",
+		QueryRaw: query,
+	}
+
+	_, err = r.db.DB().ExecContext(ctx, q, args...)
+	return err
+}
+
+// MarkFailed откладывает следующую попытку доставки события на retryAfter
+func (r *outboxRepository) MarkFailed(ctx context.Context, eventID int64, retryAfter time.Duration, lastError string) error {
+	updateBuilder := squirrel.
+		Update(tableName).
+		Set(attemptsColumn, squirrel.Expr(attemptsColumn+" + 1")).
+		Set(lastErrorColumn, lastError).
+		Set(nextAttemptAtColumn, squirrel.Expr("CURRENT_TIMESTAMP + ? * INTERVAL '1 millisecond'", retryAfter.Milliseconds())).
+		Where(squirrel.Eq{idColumn: eventID}).
+		PlaceholderFormat(squirrel.Dollar)
+
+	query, args, err := updateBuilder.ToSql()
+	if err != nil {
+		return err
+	}
+
+	q := db.Query{
+		Name:     "outboxRepository.MarkFailed",
+		QueryRaw: query,
+	}
+
+	_, err = r.db.DB().ExecContext(ctx, q, args...)
+	return err
+}
@@ -0,0 +1,53 @@
+package outboxRepo
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jackc/pgconn"
+	"github.com/stretchr/testify/require"
+
+	"realty-avito/internal/client/db"
+)
+
+// recordingDB запоминает выполненные запросы, остальные методы db.DB не используются
+type recordingDB struct {
+	db.DB
+	queries []string
+	args    [][]interface{}
+}
+
+func (d *recordingDB) ExecContext(_ context.Context, q db.Query, args ...interface{}) (pgconn.CommandTag, error) {
+	d.queries = append(d.queries, q.Name)
+	d.args = append(d.args, args)
+	return nil, nil
+}
+
+type recordingClient struct {
+	db *recordingDB
+}
+
+func (c recordingClient) DB() db.DB {
+	return c.db
+}
+
+func (c recordingClient) Close() error {
+	return nil
+}
+
+func TestAddEventLocksWritesBeforeInsert(t *testing.T) {
+	recorder := &recordingDB{}
+	repo := NewOutboxRepository(recordingClient{db: recorder})
+
+	err := repo.AddEvent(context.Background(), CreateEventEntity{
+		AggregateType: "flat",
+		AggregateID:   1,
+		EventType:     "flat.created",
+		Payload:       []byte(`{}`),
+	})
+	require.NoError(t, err)
+
+	// id события выдается только после блокировки, которую держит транзакция с предыдущим событием
+	require.Equal(t, []string{"outboxRepository.lockWrites", "outboxRepository.AddEvent"}, recorder.queries)
+	require.Equal(t, []interface{}{writeLockKey}, recorder.args[0])
+}
@@ -0,0 +1,134 @@
+// Code generated by mockery v2.28.2. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+	photosRepo "realty-avito/internal/repositories/photosRepo"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// PhotosRepository is an autogenerated mock type for the PhotosRepository type
+type PhotosRepository struct {
+	mock.Mock
+}
+
+// CreatePhoto provides a mock function with given fields: ctx, createPhotoEntity
+func (_m *PhotosRepository) CreatePhoto(ctx context.Context, createPhotoEntity photosRepo.CreatePhotoEntity) (*photosRepo.PhotoEntity, error) {
+	ret := _m.Called(ctx, createPhotoEntity)
+
+	var r0 *photosRepo.PhotoEntity
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, photosRepo.CreatePhotoEntity) (*photosRepo.PhotoEntity, error)); ok {
+		return rf(ctx, createPhotoEntity)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, photosRepo.CreatePhotoEntity) *photosRepo.PhotoEntity); ok {
+		r0 = rf(ctx, createPhotoEntity)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*photosRepo.PhotoEntity)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, photosRepo.CreatePhotoEntity) error); ok {
+		r1 = rf(ctx, createPhotoEntity)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetPhoto provides a mock function with given fields: ctx, flatID, photoID
+func (_m *PhotosRepository) GetPhoto(ctx context.Context, flatID int64, photoID int64) (*photosRepo.PhotoEntity, error) {
+	ret := _m.Called(ctx, flatID, photoID)
+
+	var r0 *photosRepo.PhotoEntity
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) (*photosRepo.PhotoEntity, error)); ok {
+		return rf(ctx, flatID, photoID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64) *photosRepo.PhotoEntity); ok {
+		r0 = rf(ctx, flatID, photoID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*photosRepo.PhotoEntity)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, int64) error); ok {
+		r1 = rf(ctx, flatID, photoID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetPhotosByFlatIDs provides a mock function with given fields: ctx, flatIDs
+func (_m *PhotosRepository) GetPhotosByFlatIDs(ctx context.Context, flatIDs []int64) ([]photosRepo.PhotoEntity, error) {
+	ret := _m.Called(ctx, flatIDs)
+
+	var r0 []photosRepo.PhotoEntity
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, []int64) ([]photosRepo.PhotoEntity, error)); ok {
+		return rf(ctx, flatIDs)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, []int64) []photosRepo.PhotoEntity); ok {
+		r0 = rf(ctx, flatIDs)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]photosRepo.PhotoEntity)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, []int64) error); ok {
+		r1 = rf(ctx, flatIDs)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SetPhotoStatus provides a mock function with given fields: ctx, flatID, photoID, status
+func (_m *PhotosRepository) SetPhotoStatus(ctx context.Context, flatID int64, photoID int64, status photosRepo.PhotoStatus) (*photosRepo.PhotoEntity, error) {
+	ret := _m.Called(ctx, flatID, photoID, status)
+
+	var r0 *photosRepo.PhotoEntity
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, photosRepo.PhotoStatus) (*photosRepo.PhotoEntity, error)); ok {
+		return rf(ctx, flatID, photoID, status)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64, int64, photosRepo.PhotoStatus) *photosRepo.PhotoEntity); ok {
+		r0 = rf(ctx, flatID, photoID, status)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*photosRepo.PhotoEntity)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64, int64, photosRepo.PhotoStatus) error); ok {
+		r1 = rf(ctx, flatID, photoID, status)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+type mockConstructorTestingTNewPhotosRepository interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewPhotosRepository creates a new instance of PhotosRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewPhotosRepository(t mockConstructorTestingTNewPhotosRepository) *PhotosRepository {
+	mock := &PhotosRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
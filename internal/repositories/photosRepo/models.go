@@ -0,0 +1,35 @@
+package photosRepo
+
+import "time"
+
+// PhotoStatus решение модератора по фотографии, клиентам показываются только одобренные
+type PhotoStatus string
+
+const (
+	PhotoStatusPending  PhotoStatus = "pending"
+	PhotoStatusApproved PhotoStatus = "approved"
+	PhotoStatusDeclined PhotoStatus = "declined"
+)
+
+type CreatePhotoEntity struct {
+	FlatID       int64
+	BlobKey      string
+	ThumbnailKey string
+	ContentType  string
+	Size         int64
+	Width        int
+	Height       int
+}
+
+type PhotoEntity struct {
+	ID           int64
+	FlatID       int64
+	BlobKey      string
+	ThumbnailKey string
+	ContentType  string
+	Size         int64
+	Width        int
+	Height       int
+	Status       PhotoStatus
+	CreatedAt    time.Time
+}
@@ -0,0 +1,214 @@
+package photosRepo
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v4"
+
+	"realty-avito/internal/client/db"
+	repo_errors "realty-avito/internal/errors"
+)
+
+const (
+	tableName = "flat_photos"
+
+	idColumn           = "id"
+	flatIDColumn       = "flat_id"
+	blobKeyColumn      = "blob_key"
+	thumbnailKeyColumn = "thumbnail_key"
+	contentTypeColumn  = "content_type"
+	sizeColumn         = "size"
+	widthColumn        = "width"
+	heightColumn       = "height"
+	statusColumn       = "status"
+	createdAtColumn    = "created_at"
+)
+
+var photoColumns = []string{
+	idColumn, flatIDColumn, blobKeyColumn, thumbnailKeyColumn, contentTypeColumn, sizeColumn, widthColumn, heightColumn, statusColumn, createdAtColumn,
+}
+
+// запрос, меняющий фотографию, оборачивается в CTE, которое в том же запросе обновляет квартиру:
+// у квартиры растет версия, и ETag списков квартир дома меняется вместе с фотографиями
+var (
+	touchFlatPrefix = "WITH photo AS ("
+	touchFlatSuffix = "RETURNING " + strings.Join(photoColumns, ", ") + "), " +
+		"touched AS (UPDATE flats SET updated_at = CURRENT_TIMESTAMP WHERE id = (SELECT " + flatIDColumn + " FROM photo)) " +
+		"SELECT " + strings.Join(photoColumns, ", ") + " FROM photo"
+)
+
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=PhotosRepository
+type PhotosRepository interface {
+	CreatePhoto(ctx context.Context, createPhotoEntity CreatePhotoEntity) (*PhotoEntity, error)
+	GetPhoto(ctx context.Context, flatID int64, photoID int64) (*PhotoEntity, error)
+	GetPhotosByFlatIDs(ctx context.Context, flatIDs []int64) ([]PhotoEntity, error)
+	SetPhotoStatus(ctx context.Context, flatID int64, photoID int64, status PhotoStatus) (*PhotoEntity, error)
+}
+
+type photosRepository struct {
+	db db.Client
+}
+
+func NewPhotosRepository(db db.Client) PhotosRepository {
+	return &photosRepository{db: db}
+}
+
+func (r *photosRepository) CreatePhoto(ctx context.Context, createPhotoEntity CreatePhotoEntity) (*PhotoEntity, error) {
+	insertBuilder := squirrel.
+		Insert(tableName).
+		PlaceholderFormat(squirrel.Dollar).
+		Columns(flatIDColumn, blobKeyColumn, thumbnailKeyColumn, contentTypeColumn, sizeColumn, widthColumn, heightColumn).
+		Values(
+			createPhotoEntity.FlatID,
+			createPhotoEntity.BlobKey,
+			createPhotoEntity.ThumbnailKey,
+			createPhotoEntity.ContentType,
+			createPhotoEntity.Size,
+			createPhotoEntity.Width,
+			createPhotoEntity.Height,
+		).
+		Prefix(touchFlatPrefix).
+		Suffix(touchFlatSuffix)
+
+	query, args, err := insertBuilder.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	q := db.Query{
+		Name:     "photosRepository.CreatePhoto",
+		QueryRaw: query,
+	}
+
+	var photo PhotoEntity
+
+	err = scanPhoto(r.db.DB().QueryRowContext(ctx, q, args...), &photo)
+	if err != nil {
+		if repo_errors.IsForeignKeyViolation(err) {
+			return nil, &repo_errors.ErrFlatNotFound{FlatID: createPhotoEntity.FlatID}
+		}
+
+		return nil, err
+	}
+
+	return &photo, nil
+}
+
+func (r *photosRepository) GetPhoto(ctx context.Context, flatID int64, photoID int64) (*PhotoEntity, error) {
+	selectBuilder := squirrel.
+		Select(photoColumns...).
+		From(tableName).
+		Where(squirrel.Eq{idColumn: photoID, flatIDColumn: flatID}).
+		PlaceholderFormat(squirrel.Dollar)
+
+	query, args, err := selectBuilder.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	q := db.Query{
+		Name:     "photosRepository.GetPhoto",
+		QueryRaw: query,
+	}
+
+	var photo PhotoEntity
+
+	err = scanPhoto(r.db.DB().QueryRowContext(ctx, q, args...), &photo)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, &repo_errors.ErrPhotoNotFound{PhotoID: photoID}
+		}
+
+		return nil, err
+	}
+
+	return &photo, nil
+}
+
+// GetPhotosByFlatIDs возвращает фотографии сразу нескольких квартир, чтобы не ходить в базу за каждой
+func (r *photosRepository) GetPhotosByFlatIDs(ctx context.Context, flatIDs []int64) ([]PhotoEntity, error) {
+	if len(flatIDs) == 0 {
+		return nil, nil
+	}
+
+	selectBuilder := squirrel.
+		Select(photoColumns...).
+		From(tableName).
+		Where(squirrel.Eq{flatIDColumn: flatIDs}).
+		OrderBy(flatIDColumn, idColumn).
+		PlaceholderFormat(squirrel.Dollar)
+
+	query, args, err := selectBuilder.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	q := db.Query{
+		Name:     "photosRepository.GetPhotosByFlatIDs",
+		QueryRaw: query,
+	}
+
+	rows, err := r.db.DB().QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var photos []PhotoEntity
+
+	for rows.Next() {
+		var photo PhotoEntity
+
+		if err := scanPhoto(rows, &photo); err != nil {
+			return nil, err
+		}
+
+		photos = append(photos, photo)
+	}
+
+	return photos, rows.Err()
+}
+
+// SetPhotoStatus сохраняет решение модератора по отдельной фотографии
+func (r *photosRepository) SetPhotoStatus(ctx context.Context, flatID int64, photoID int64, status PhotoStatus) (*PhotoEntity, error) {
+	updateBuilder := squirrel.
+		Update(tableName).
+		Set(statusColumn, status).
+		Where(squirrel.Eq{idColumn: photoID, flatIDColumn: flatID}).
+		Prefix(touchFlatPrefix).
+		Suffix(touchFlatSuffix).
+		PlaceholderFormat(squirrel.Dollar)
+
+	query, args, err := updateBuilder.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	q := db.Query{
+		Name:     "photosRepository.SetPhotoStatus",
+		QueryRaw: query,
+	}
+
+	var photo PhotoEntity
+
+	err = scanPhoto(r.db.DB().QueryRowContext(ctx, q, args...), &photo)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, &repo_errors.ErrPhotoNotFound{PhotoID: photoID}
+		}
+
+		return nil, err
+	}
+
+	return &photo, nil
+}
+
+func scanPhoto(row pgx.Row, photo *PhotoEntity) error {
+	return row.Scan(
+		&photo.ID, &photo.FlatID, &photo.BlobKey, &photo.ThumbnailKey, &photo.ContentType,
+		&photo.Size, &photo.Width, &photo.Height, &photo.Status, &photo.CreatedAt,
+	)
+}
@@ -0,0 +1,82 @@
+// Code generated by mockery v2.28.2. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+	subscriptionsRepo "realty-avito/internal/repositories/subscriptionsRepo"
+
+	mock "github.com/stretchr/testify/mock"
+)
+
+// SubscriptionsRepository is an autogenerated mock type for the SubscriptionsRepository type
+type SubscriptionsRepository struct {
+	mock.Mock
+}
+
+// CreateSubscription provides a mock function with given fields: ctx, createSubscriptionEntity
+func (_m *SubscriptionsRepository) CreateSubscription(ctx context.Context, createSubscriptionEntity subscriptionsRepo.CreateSubscriptionEntity) (*subscriptionsRepo.SubscriptionEntity, error) {
+	ret := _m.Called(ctx, createSubscriptionEntity)
+
+	var r0 *subscriptionsRepo.SubscriptionEntity
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, subscriptionsRepo.CreateSubscriptionEntity) (*subscriptionsRepo.SubscriptionEntity, error)); ok {
+		return rf(ctx, createSubscriptionEntity)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, subscriptionsRepo.CreateSubscriptionEntity) *subscriptionsRepo.SubscriptionEntity); ok {
+		r0 = rf(ctx, createSubscriptionEntity)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*subscriptionsRepo.SubscriptionEntity)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, subscriptionsRepo.CreateSubscriptionEntity) error); ok {
+		r1 = rf(ctx, createSubscriptionEntity)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetSubscriptionsByHouseID provides a mock function with given fields: ctx, houseID
+func (_m *SubscriptionsRepository) GetSubscriptionsByHouseID(ctx context.Context, houseID int64) ([]subscriptionsRepo.SubscriptionEntity, error) {
+	ret := _m.Called(ctx, houseID)
+
+	var r0 []subscriptionsRepo.SubscriptionEntity
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) ([]subscriptionsRepo.SubscriptionEntity, error)); ok {
+		return rf(ctx, houseID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) []subscriptionsRepo.SubscriptionEntity); ok {
+		r0 = rf(ctx, houseID)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]subscriptionsRepo.SubscriptionEntity)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, houseID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+type mockConstructorTestingTNewSubscriptionsRepository interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewSubscriptionsRepository creates a new instance of SubscriptionsRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewSubscriptionsRepository(t mockConstructorTestingTNewSubscriptionsRepository) *SubscriptionsRepository {
+	mock := &SubscriptionsRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
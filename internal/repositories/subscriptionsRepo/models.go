@@ -0,0 +1,15 @@
+package subscriptionsRepo
+
+import "time"
+
+type CreateSubscriptionEntity struct {
+	HouseID int64
+	Email   string
+}
+
+type SubscriptionEntity struct {
+	ID        int64
+	HouseID   int64
+	Email     string
+	CreatedAt time.Time
+}
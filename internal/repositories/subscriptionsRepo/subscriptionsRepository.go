@@ -0,0 +1,108 @@
+package subscriptionsRepo
+
+import (
+	"context"
+
+	"github.com/Masterminds/squirrel"
+
+	"realty-avito/internal/client/db"
+	"realty-avito/internal/errors"
+)
+
+const (
+	tableName = "subscriptions"
+
+	idColumn        = "id"
+	houseIDColumn   = "house_id"
+	emailColumn     = "email"
+	createdAtColumn = "created_at"
+)
+
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=SubscriptionsRepository
+type SubscriptionsRepository interface {
+	CreateSubscription(ctx context.Context, createSubscriptionEntity CreateSubscriptionEntity) (*SubscriptionEntity, error)
+	GetSubscriptionsByHouseID(ctx context.Context, houseID int64) ([]SubscriptionEntity, error)
+}
+
+type subscriptionsRepository struct {
+	db db.Client
+}
+
+func NewSubscriptionsRepository(db db.Client) SubscriptionsRepository {
+	return &subscriptionsRepository{db: db}
+}
+
+func (r *subscriptionsRepository) CreateSubscription(ctx context.Context, createSubscriptionEntity CreateSubscriptionEntity) (*SubscriptionEntity, error) {
+	// Повторная подписка на тот же дом не считается ошибкой: возвращаем существующую запись
+	insertBuilder := squirrel.
+		Insert(tableName).
+		PlaceholderFormat(squirrel.Dollar).
+		Columns(houseIDColumn, emailColumn).
+		Values(createSubscriptionEntity.HouseID, createSubscriptionEntity.Email).
+		Suffix("ON CONFLICT (house_id, email) DO UPDATE SET email = EXCLUDED.email RETURNING id, house_id, email, created_at")
+
+	query, args, err := insertBuilder.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	q := db.Query{
+		Name:     "subscriptionsRepository.CreateSubscription",
+		QueryRaw: query,
+	}
+
+	var subscription SubscriptionEntity
+
+	err = r.db.DB().
+		QueryRowContext(ctx, q, args...).
+		Scan(&subscription.ID, &subscription.HouseID, &subscription.Email, &subscription.CreatedAt)
+	if err != nil {
+		if repo_errors.IsForeignKeyViolation(err) {
+			return nil, &repo_errors.ErrHouseNotFound{HouseID: createSubscriptionEntity.HouseID}
+		}
+
+		return nil, err
+	}
+
+	return &subscription, nil
+}
+
+func (r *subscriptionsRepository) GetSubscriptionsByHouseID(ctx context.Context, houseID int64) ([]SubscriptionEntity, error) {
+	selectBuilder := squirrel.
+		Select(idColumn, houseIDColumn, emailColumn, createdAtColumn).
+		From(tableName).
+		Where(squirrel.Eq{houseIDColumn: houseID}).
+		OrderBy(idColumn).
+		PlaceholderFormat(squirrel.Dollar)
+
+	query, args, err := selectBuilder.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	q := db.Query{
+		Name:     "subscriptionsRepository.GetSubscriptionsByHouseID",
+		QueryRaw: query,
+	}
+
+	rows, err := r.db.DB().QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var subscriptions []SubscriptionEntity
+
+	for rows.Next() {
+		var subscription SubscriptionEntity
+
+		err := rows.Scan(&subscription.ID, &subscription.HouseID, &subscription.Email, &subscription.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		subscriptions = append(subscriptions, subscription)
+	}
+
+	return subscriptions, rows.Err()
+}
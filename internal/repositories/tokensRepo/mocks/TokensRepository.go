@@ -0,0 +1,172 @@
+// Code generated by mockery v2.28.2. DO NOT EDIT.
+
+package mocks
+
+import (
+	context "context"
+	time "time"
+
+	mock "github.com/stretchr/testify/mock"
+
+	tokensRepo "realty-avito/internal/repositories/tokensRepo"
+)
+
+// TokensRepository is an autogenerated mock type for the TokensRepository type
+type TokensRepository struct {
+	mock.Mock
+}
+
+// CreateRefreshToken provides a mock function with given fields: ctx, createRefreshTokenEntity
+func (_m *TokensRepository) CreateRefreshToken(ctx context.Context, createRefreshTokenEntity tokensRepo.CreateRefreshTokenEntity) error {
+	ret := _m.Called(ctx, createRefreshTokenEntity)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, tokensRepo.CreateRefreshTokenEntity) error); ok {
+		r0 = rf(ctx, createRefreshTokenEntity)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteExpired provides a mock function with given fields: ctx
+func (_m *TokensRepository) DeleteExpired(ctx context.Context) (int64, error) {
+	ret := _m.Called(ctx)
+
+	var r0 int64
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) (int64, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) int64); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Get(0).(int64)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetRefreshTokenByHash provides a mock function with given fields: ctx, tokenHash
+func (_m *TokensRepository) GetRefreshTokenByHash(ctx context.Context, tokenHash string) (*tokensRepo.RefreshTokenEntity, error) {
+	ret := _m.Called(ctx, tokenHash)
+
+	var r0 *tokensRepo.RefreshTokenEntity
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (*tokensRepo.RefreshTokenEntity, error)); ok {
+		return rf(ctx, tokenHash)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) *tokensRepo.RefreshTokenEntity); ok {
+		r0 = rf(ctx, tokenHash)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*tokensRepo.RefreshTokenEntity)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, tokenHash)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// IsAccessTokenRevoked provides a mock function with given fields: ctx, jti
+func (_m *TokensRepository) IsAccessTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	ret := _m.Called(ctx, jti)
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (bool, error)); ok {
+		return rf(ctx, jti)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) bool); ok {
+		r0 = rf(ctx, jti)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, jti)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RevokeAccessToken provides a mock function with given fields: ctx, jti, ttl
+func (_m *TokensRepository) RevokeAccessToken(ctx context.Context, jti string, ttl time.Duration) error {
+	ret := _m.Called(ctx, jti, ttl)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, time.Duration) error); ok {
+		r0 = rf(ctx, jti, ttl)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// RevokeRefreshToken provides a mock function with given fields: ctx, tokenID
+func (_m *TokensRepository) RevokeRefreshToken(ctx context.Context, tokenID int64) (bool, error) {
+	ret := _m.Called(ctx, tokenID)
+
+	var r0 bool
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, int64) (bool, error)); ok {
+		return rf(ctx, tokenID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, int64) bool); ok {
+		r0 = rf(ctx, tokenID)
+	} else {
+		r0 = ret.Get(0).(bool)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, int64) error); ok {
+		r1 = rf(ctx, tokenID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// RevokeRefreshTokenFamily provides a mock function with given fields: ctx, familyID
+func (_m *TokensRepository) RevokeRefreshTokenFamily(ctx context.Context, familyID string) error {
+	ret := _m.Called(ctx, familyID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, familyID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+type mockConstructorTestingTNewTokensRepository interface {
+	mock.TestingT
+	Cleanup(func())
+}
+
+// NewTokensRepository creates a new instance of TokensRepository. It also registers a testing interface on the mock and a cleanup function to assert the mocks expectations.
+func NewTokensRepository(t mockConstructorTestingTNewTokensRepository) *TokensRepository {
+	mock := &TokensRepository{}
+	mock.Mock.Test(t)
+
+	t.Cleanup(func() { mock.AssertExpectations(t) })
+
+	return mock
+}
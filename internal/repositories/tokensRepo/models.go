@@ -0,0 +1,20 @@
+package tokensRepo
+
+import "time"
+
+type CreateRefreshTokenEntity struct {
+	TokenHash string
+	FamilyID  string
+	Subject   string
+	UserType  string
+	TTL       time.Duration
+}
+
+type RefreshTokenEntity struct {
+	ID        int64
+	FamilyID  string
+	Subject   string
+	UserType  string
+	Expired   bool
+	RevokedAt *time.Time
+}
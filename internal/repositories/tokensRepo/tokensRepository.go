@@ -0,0 +1,247 @@
+package tokensRepo
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgx/v4"
+
+	"realty-avito/internal/client/db"
+)
+
+const (
+	refreshTokensTable = "refresh_tokens"
+
+	idColumn        = "id"
+	tokenHashColumn = "token_hash"
+	familyIDColumn  = "family_id"
+	subjectColumn   = "subject"
+	userTypeColumn  = "user_type"
+	expiresAtColumn = "expires_at"
+	revokedAtColumn = "revoked_at"
+
+	revokedAccessTokensTable = "revoked_access_tokens"
+
+	jtiColumn = "jti"
+)
+
+var ErrRefreshTokenNotFound = errors.New("refresh token not found")
+
+//go:generate go run github.com/vektra/mockery/v2@v2.28.2 --name=TokensRepository
+type TokensRepository interface {
+	CreateRefreshToken(ctx context.Context, createRefreshTokenEntity CreateRefreshTokenEntity) error
+	GetRefreshTokenByHash(ctx context.Context, tokenHash string) (*RefreshTokenEntity, error)
+	RevokeRefreshToken(ctx context.Context, tokenID int64) (bool, error)
+	RevokeRefreshTokenFamily(ctx context.Context, familyID string) error
+	RevokeAccessToken(ctx context.Context, jti string, ttl time.Duration) error
+	IsAccessTokenRevoked(ctx context.Context, jti string) (bool, error)
+	DeleteExpired(ctx context.Context) (int64, error)
+}
+
+type tokensRepository struct {
+	db db.Client
+}
+
+func NewTokensRepository(db db.Client) TokensRepository {
+	return &tokensRepository{db: db}
+}
+
+func (r *tokensRepository) CreateRefreshToken(ctx context.Context, createRefreshTokenEntity CreateRefreshTokenEntity) error {
+	insertBuilder := squirrel.
+		Insert(refreshTokensTable).
+		PlaceholderFormat(squirrel.Dollar).
+		Columns(tokenHashColumn, familyIDColumn, subjectColumn, userTypeColumn, expiresAtColumn).
+		Values(
+			createRefreshTokenEntity.TokenHash,
+			createRefreshTokenEntity.FamilyID,
+			createRefreshTokenEntity.Subject,
+			createRefreshTokenEntity.UserType,
+			squirrel.Expr("CURRENT_TIMESTAMP + ? * INTERVAL '1 second'", int64(createRefreshTokenEntity.TTL.Seconds())),
+		)
+
+	query, args, err := insertBuilder.ToSql()
+	if err != nil {
+		return err
+	}
+
+	q := db.Query{
+		Name:     "tokensRepository.CreateRefreshToken",
+		QueryRaw: query,
+	}
+
+	_, err = r.db.DB().ExecContext(ctx, q, args...)
+	return err
+}
+
+// GetRefreshTokenByHash ищет refresh-токен и блокирует его строку до конца транзакции,
+// чтобы один и тот же токен нельзя было обменять дважды параллельными запросами
+func (r *tokensRepository) GetRefreshTokenByHash(ctx context.Context, tokenHash string) (*RefreshTokenEntity, error) {
+	selectBuilder := squirrel.
+		Select(idColumn, familyIDColumn, subjectColumn, userTypeColumn, expiresAtColumn+" <= CURRENT_TIMESTAMP", revokedAtColumn).
+		From(refreshTokensTable).
+		Where(squirrel.Eq{tokenHashColumn: tokenHash}).
+		Suffix("FOR UPDATE").
+		PlaceholderFormat(squirrel.Dollar)
+
+	query, args, err := selectBuilder.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	q := db.Query{
+		Name:     "tokensRepository.GetRefreshTokenByHash",
+		QueryRaw: query,
+	}
+
+	var token RefreshTokenEntity
+
+	err = r.db.DB().
+		QueryRowContext(ctx, q, args...).
+		Scan(&token.ID, &token.FamilyID, &token.Subject, &token.UserType, &token.Expired, &token.RevokedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrRefreshTokenNotFound
+		}
+
+		return nil, err
+	}
+
+	return &token, nil
+}
+
+// RevokeRefreshToken отзывает токен. Возвращает false, если токен уже был отозван.
+func (r *tokensRepository) RevokeRefreshToken(ctx context.Context, tokenID int64) (bool, error) {
+	updateBuilder := squirrel.
+		Update(refreshTokensTable).
+		Set(revokedAtColumn, squirrel.Expr("CURRENT_TIMESTAMP")).
+		Where(squirrel.Eq{idColumn: tokenID, revokedAtColumn: nil}).
+		PlaceholderFormat(squirrel.Dollar)
+
+	query, args, err := updateBuilder.ToSql()
+	if err != nil {
+		return false, err
+	}
+
+	q := db.Query{
+		Name:     "tokensRepository.RevokeRefreshToken",
+		QueryRaw: query,
+	}
+
+	tag, err := r.db.DB().ExecContext(ctx, q, args...)
+	if err != nil {
+		return false, err
+	}
+
+	return tag.RowsAffected() == 1, nil
+}
+
+// RevokeRefreshTokenFamily отзывает все токены, выпущенные в рамках одной сессии
+func (r *tokensRepository) RevokeRefreshTokenFamily(ctx context.Context, familyID string) error {
+	updateBuilder := squirrel.
+		Update(refreshTokensTable).
+		Set(revokedAtColumn, squirrel.Expr("CURRENT_TIMESTAMP")).
+		Where(squirrel.Eq{familyIDColumn: familyID, revokedAtColumn: nil}).
+		PlaceholderFormat(squirrel.Dollar)
+
+	query, args, err := updateBuilder.ToSql()
+	if err != nil {
+		return err
+	}
+
+	q := db.Query{
+		Name:     "tokensRepository.RevokeRefreshTokenFamily",
+		QueryRaw: query,
+	}
+
+	_, err = r.db.DB().ExecContext(ctx, q, args...)
+	return err
+}
+
+// RevokeAccessToken добавляет jti access-токена в denylist до истечения срока его жизни
+func (r *tokensRepository) RevokeAccessToken(ctx context.Context, jti string, ttl time.Duration) error {
+	insertBuilder := squirrel.
+		Insert(revokedAccessTokensTable).
+		PlaceholderFormat(squirrel.Dollar).
+		Columns(jtiColumn, expiresAtColumn).
+		Values(jti, squirrel.Expr("CURRENT_TIMESTAMP + ? * INTERVAL '1 second'", int64(ttl.Seconds())+1)).
+		Suffix("ON CONFLICT (jti) DO NOTHING")
+
+	query, args, err := insertBuilder.ToSql()
+	if err != nil {
+		return err
+	}
+
+	q := db.Query{
+		Name:     "tokensRepository.RevokeAccessToken",
+		QueryRaw: query,
+	}
+
+	_, err = r.db.DB().ExecContext(ctx, q, args...)
+	return err
+}
+
+func (r *tokensRepository) IsAccessTokenRevoked(ctx context.Context, jti string) (bool, error) {
+	selectBuilder := squirrel.
+		Select("1").
+		Prefix("SELECT EXISTS (").
+		From(revokedAccessTokensTable).
+		Where(squirrel.Eq{jtiColumn: jti}).
+		Where(expiresAtColumn + " > CURRENT_TIMESTAMP").
+		Suffix(")").
+		PlaceholderFormat(squirrel.Dollar)
+
+	query, args, err := selectBuilder.ToSql()
+	if err != nil {
+		return false, err
+	}
+
+	q := db.Query{
+		Name:     "tokensRepository.IsAccessTokenRevoked",
+		QueryRaw: query,
+	}
+
+	var revoked bool
+
+	err = r.db.DB().
+		QueryRowContext(ctx, q, args...).
+		Scan(&revoked)
+	if err != nil {
+		return false, err
+	}
+
+	return revoked, nil
+}
+
+// DeleteExpired удаляет истекшие refresh-токены и записи denylist, чей access-токен уже истек сам.
+// Возвращает общее количество удаленных строк.
+func (r *tokensRepository) DeleteExpired(ctx context.Context) (int64, error) {
+	var deleted int64
+
+	for _, table := range []string{revokedAccessTokensTable, refreshTokensTable} {
+		deleteBuilder := squirrel.
+			Delete(table).
+			Where(squirrel.Expr(expiresAtColumn + " <= CURRENT_TIMESTAMP")).
+			PlaceholderFormat(squirrel.Dollar)
+
+		query, args, err := deleteBuilder.ToSql()
+		if err != nil {
+			return deleted, err
+		}
+
+		q := db.Query{
+			Name:     "tokensRepository.DeleteExpired." + table,
+			QueryRaw: query,
+		}
+
+		tag, err := r.db.DB().ExecContext(ctx, q, args...)
+		if err != nil {
+			return deleted, err
+		}
+
+		deleted += tag.RowsAffected()
+	}
+
+	return deleted, nil
+}
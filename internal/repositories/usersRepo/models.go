@@ -9,6 +9,9 @@ const (
 	UserTypeModerator UserType = "moderator"
 )
 
+// DummyEmailDomain домен адресов dummy-пользователей, под ним нельзя зарегистрироваться
+const DummyEmailDomain = "dummy.local"
+
 type UserEntity struct {
 	ID           int64
 	Email        string
@@ -3,7 +3,6 @@ package usersRepo
 import (
 	"context"
 	"errors"
-	"log"
 	"strings"
 
 	"github.com/Masterminds/squirrel"
@@ -31,6 +30,7 @@ var (
 
 type UserRepository interface {
 	CreateUser(ctx context.Context, user UserEntity) (*UserEntity, error)
+	EnsureUser(ctx context.Context, user UserEntity) (*UserEntity, error)
 	GetUserByCredentials(ctx context.Context, cred UserCredentials) (*UserEntity, error)
 }
 
@@ -79,12 +79,41 @@ func (r *userRepository) CreateUser(ctx context.Context, user UserEntity) (*User
 	return &user, nil
 }
 
-func (r *userRepository) GetUserByCredentials(ctx context.Context, cred UserCredentials) (*UserEntity, error) {
-	log.Printf("userRepository.GetUserByCredentials cred.ID: %s", cred.ID)
-	log.Printf("userRepository.GetUserByCredentials cred.PasswordHash: %s", cred.PasswordHash)
+// EnsureUser возвращает пользователя с email из user, создавая его при первом обращении.
+// Существующая запись не меняется: вызывающий сам проверяет, что она ему подходит.
+func (r *userRepository) EnsureUser(ctx context.Context, user UserEntity) (*UserEntity, error) {
+	insertBuilder := squirrel.
+		Insert(usersTable).
+		PlaceholderFormat(squirrel.Dollar).
+		Columns(userEmailColumn, userPasswordHashColumn, userTypeColumn, userUUIDColumn).
+		Values(user.Email, user.PasswordHash, user.UserType, uuid.New().String()).
+		// пустое обновление нужно, чтобы RETURNING вернул уже существующую строку
+		Suffix("ON CONFLICT (" + userEmailColumn + ") DO UPDATE SET " + userEmailColumn + " = EXCLUDED." + userEmailColumn +
+			" RETURNING " + strings.Join([]string{userIDColumn, userUUIDColumn, userPasswordHashColumn, userTypeColumn, createdAtColumn}, ", "))
+
+	query, args, err := insertBuilder.ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	q := db.Query{
+		Name:     "userRepository.EnsureUser",
+		QueryRaw: query,
+	}
+
+	err = r.db.DB().
+		QueryRowContext(ctx, q, args...).
+		Scan(&user.ID, &user.UUID, &user.PasswordHash, &user.UserType, &user.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+
+	return &user, nil
+}
 
+func (r *userRepository) GetUserByCredentials(ctx context.Context, cred UserCredentials) (*UserEntity, error) {
 	builder := squirrel.
-		Select(userIDColumn, userEmailColumn, userPasswordHashColumn, userTypeColumn, createdAtColumn).
+		Select(userIDColumn, userUUIDColumn, userEmailColumn, userPasswordHashColumn, userTypeColumn, createdAtColumn).
 		From(usersTable).
 		Where(squirrel.Eq{
 			userUUIDColumn: cred.ID,
@@ -104,7 +133,7 @@ func (r *userRepository) GetUserByCredentials(ctx context.Context, cred UserCred
 	var user UserEntity
 	err = r.db.DB().
 		QueryRowContext(ctx, q, args...).
-		Scan(&user.ID, &user.Email, &user.PasswordHash, &user.UserType, &user.CreatedAt)
+		Scan(&user.ID, &user.UUID, &user.Email, &user.PasswordHash, &user.UserType, &user.CreatedAt)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, ErrUserNotFound
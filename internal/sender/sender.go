@@ -0,0 +1,68 @@
+package sender
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"golang.org/x/exp/slog"
+)
+
+// Sender отправляет сообщение на указанный email
+type Sender interface {
+	SendEmail(ctx context.Context, email string, message string) error
+}
+
+// StubSender локальная заглушка вместо почтового сервиса.
+// Пишет письма в файл, а если путь к файлу не задан - в лог.
+type StubSender struct {
+	log  *slog.Logger
+	mu   sync.Mutex
+	file *os.File
+}
+
+func NewStubSender(log *slog.Logger, filePath string) (*StubSender, error) {
+	s := &StubSender{
+		log: log.With(slog.String("component", "sender/stub")),
+	}
+
+	if filePath == "" {
+		return s, nil
+	}
+
+	file, err := os.OpenFile(filePath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open sender file %s: %w", filePath, err)
+	}
+
+	s.file = file
+
+	return s, nil
+}
+
+func (s *StubSender) SendEmail(ctx context.Context, email string, message string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	if s.file == nil {
+		s.log.Info("email sent", slog.String("email", email), slog.String("message", message))
+		return nil
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err := fmt.Fprintf(s.file, "%s\t%s\t%s\n", time.Now().Format(time.RFC3339), email, message)
+	return err
+}
+
+func (s *StubSender) Close() error {
+	if s.file == nil {
+		return nil
+	}
+
+	return s.file.Close()
+}
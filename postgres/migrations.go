@@ -0,0 +1,47 @@
+package postgres
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"strconv"
+	"strings"
+)
+
+//go:embed migrations/*.sql
+var migrations embed.FS
+
+// LatestMigrationVersion версия последней миграции, с которой собран бинарник.
+// Goose берет версию из числового префикса имени файла.
+func LatestMigrationVersion() (int64, error) {
+	files, err := fs.Glob(migrations, "migrations/*.sql")
+	if err != nil {
+		return 0, err
+	}
+
+	var latest int64
+
+	for _, file := range files {
+		name := strings.TrimPrefix(file, "migrations/")
+
+		prefix, _, ok := strings.Cut(name, "_")
+		if !ok {
+			return 0, fmt.Errorf("migration %s has no version prefix", name)
+		}
+
+		version, err := strconv.ParseInt(prefix, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("migration %s has invalid version: %w", name, err)
+		}
+
+		if version > latest {
+			latest = version
+		}
+	}
+
+	if latest == 0 {
+		return 0, fmt.Errorf("no migrations embedded")
+	}
+
+	return latest, nil
+}
@@ -0,0 +1,11 @@
+-- +goose Up
+CREATE TABLE subscriptions (
+    id SERIAL PRIMARY KEY,
+    house_id INTEGER NOT NULL REFERENCES houses(id) ON DELETE CASCADE,
+    email VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (house_id, email)
+);
+
+-- +goose Down
+DROP TABLE IF EXISTS subscriptions;
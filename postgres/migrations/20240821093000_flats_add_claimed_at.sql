@@ -0,0 +1,11 @@
+-- +goose Up
+ALTER TABLE flats ADD COLUMN claimed_at TIMESTAMP DEFAULT NULL;
+
+UPDATE flats SET claimed_at = COALESCE(updated_at, created_at) WHERE status = 'on moderation';
+
+CREATE INDEX idx_flats_status_created_at ON flats(status, created_at, id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_flats_status_created_at;
+
+ALTER TABLE flats DROP COLUMN claimed_at;
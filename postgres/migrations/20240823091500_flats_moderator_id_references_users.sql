@@ -0,0 +1,22 @@
+-- +goose Up
+-- раньше в moderator_id сохранялся JWT модератора; такие значения не связать с пользователем,
+-- поэтому квартиры, которые они держали на модерации, возвращаются в очередь
+UPDATE flats
+SET status = 'created', moderator_id = NULL, claimed_at = NULL
+WHERE status = 'on moderation'
+  AND (moderator_id IS NULL OR moderator_id NOT IN (SELECT uuid::text FROM users));
+
+UPDATE flats
+SET moderator_id = NULL
+WHERE moderator_id IS NOT NULL
+  AND moderator_id NOT IN (SELECT uuid::text FROM users);
+
+ALTER TABLE flats ALTER COLUMN moderator_id TYPE UUID USING moderator_id::uuid;
+
+ALTER TABLE flats
+    ADD CONSTRAINT fk_flats_moderator_id FOREIGN KEY (moderator_id) REFERENCES users(uuid) ON DELETE SET NULL;
+
+-- +goose Down
+ALTER TABLE flats DROP CONSTRAINT IF EXISTS fk_flats_moderator_id;
+
+ALTER TABLE flats ALTER COLUMN moderator_id TYPE VARCHAR(255) USING moderator_id::text;
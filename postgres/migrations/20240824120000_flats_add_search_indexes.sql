@@ -0,0 +1,17 @@
+-- +goose Up
+CREATE INDEX idx_flats_status_price ON flats(status, price, id);
+
+CREATE INDEX idx_flats_price ON flats(price, id);
+
+CREATE INDEX idx_flats_created_at ON flats(created_at, id);
+
+CREATE INDEX idx_houses_developer_year ON houses(developer, year);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_houses_developer_year;
+
+DROP INDEX IF EXISTS idx_flats_created_at;
+
+DROP INDEX IF EXISTS idx_flats_price;
+
+DROP INDEX IF EXISTS idx_flats_status_price;
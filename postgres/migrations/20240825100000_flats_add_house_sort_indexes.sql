@@ -0,0 +1,9 @@
+-- +goose Up
+CREATE INDEX idx_flats_house_id_price ON flats(house_id, price, id);
+
+CREATE INDEX idx_flats_house_id_rooms ON flats(house_id, rooms, id);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_flats_house_id_rooms;
+
+DROP INDEX IF EXISTS idx_flats_house_id_price;
@@ -0,0 +1,33 @@
+-- +goose Up
+ALTER TABLE houses ADD COLUMN deleted_at TIMESTAMP DEFAULT NULL;
+
+ALTER TABLE flats ADD COLUMN deleted_at TIMESTAMP DEFAULT NULL;
+
+-- дома удаляются мягко, физическое удаление дома не должно молча удалять его квартиры
+ALTER TABLE flats DROP CONSTRAINT flats_house_id_fkey;
+
+ALTER TABLE flats
+    ADD CONSTRAINT flats_house_id_fkey FOREIGN KEY (house_id) REFERENCES houses(id) ON DELETE RESTRICT;
+
+CREATE TABLE audit_log (
+    id BIGSERIAL PRIMARY KEY,
+    actor_id UUID,
+    action VARCHAR(50) NOT NULL,
+    entity_type VARCHAR(50) NOT NULL,
+    entity_id BIGINT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_audit_log_entity ON audit_log(entity_type, entity_id, created_at);
+
+-- +goose Down
+DROP TABLE IF EXISTS audit_log;
+
+ALTER TABLE flats DROP CONSTRAINT flats_house_id_fkey;
+
+ALTER TABLE flats
+    ADD CONSTRAINT flats_house_id_fkey FOREIGN KEY (house_id) REFERENCES houses(id) ON DELETE CASCADE;
+
+ALTER TABLE flats DROP COLUMN deleted_at;
+
+ALTER TABLE houses DROP COLUMN deleted_at;
@@ -0,0 +1,11 @@
+-- +goose Up
+ALTER TABLE flats ADD COLUMN flat_number INTEGER DEFAULT NULL;
+
+-- номер квартиры уникален в пределах дома среди неудаленных квартир
+CREATE UNIQUE INDEX uq_flats_house_id_flat_number ON flats(house_id, flat_number)
+    WHERE flat_number IS NOT NULL AND deleted_at IS NULL;
+
+-- +goose Down
+DROP INDEX IF EXISTS uq_flats_house_id_flat_number;
+
+ALTER TABLE flats DROP COLUMN flat_number;
@@ -0,0 +1,29 @@
+-- +goose Up
+ALTER TABLE flats
+    ADD COLUMN total_area DOUBLE PRECISION DEFAULT NULL,
+    ADD COLUMN living_area DOUBLE PRECISION DEFAULT NULL,
+    ADD COLUMN floor INTEGER DEFAULT NULL,
+    ADD COLUMN total_floors INTEGER DEFAULT NULL,
+    ADD COLUMN layout VARCHAR(20) DEFAULT NULL,
+    ADD COLUMN has_balcony BOOLEAN DEFAULT NULL,
+    ADD COLUMN description TEXT DEFAULT NULL;
+
+ALTER TABLE flats
+    ADD CONSTRAINT chk_flats_living_area CHECK (living_area IS NULL OR total_area IS NULL OR living_area <= total_area),
+    ADD CONSTRAINT chk_flats_floor CHECK (floor IS NULL OR total_floors IS NULL OR floor <= total_floors),
+    ADD CONSTRAINT chk_flats_layout CHECK (layout IS NULL OR layout IN ('studio', 'isolated', 'adjoining', 'open'));
+
+-- +goose Down
+ALTER TABLE flats
+    DROP CONSTRAINT IF EXISTS chk_flats_layout,
+    DROP CONSTRAINT IF EXISTS chk_flats_floor,
+    DROP CONSTRAINT IF EXISTS chk_flats_living_area;
+
+ALTER TABLE flats
+    DROP COLUMN description,
+    DROP COLUMN has_balcony,
+    DROP COLUMN layout,
+    DROP COLUMN total_floors,
+    DROP COLUMN floor,
+    DROP COLUMN living_area,
+    DROP COLUMN total_area;
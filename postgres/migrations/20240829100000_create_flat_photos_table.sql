@@ -0,0 +1,17 @@
+-- +goose Up
+CREATE TABLE flat_photos (
+    id BIGSERIAL PRIMARY KEY,
+    flat_id INTEGER NOT NULL REFERENCES flats(id) ON DELETE CASCADE,
+    blob_key VARCHAR(255) NOT NULL,
+    thumbnail_key VARCHAR(255) NOT NULL,
+    content_type VARCHAR(50) NOT NULL,
+    size BIGINT NOT NULL,
+    width INTEGER NOT NULL,
+    height INTEGER NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_flat_photos_flat_id ON flat_photos(flat_id, id);
+
+-- +goose Down
+DROP TABLE IF EXISTS flat_photos;
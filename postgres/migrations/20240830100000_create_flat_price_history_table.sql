@@ -0,0 +1,19 @@
+-- +goose Up
+CREATE TABLE flat_price_history (
+    id BIGSERIAL PRIMARY KEY,
+    flat_id INTEGER NOT NULL REFERENCES flats(id) ON DELETE CASCADE,
+    change_type VARCHAR(20) NOT NULL, -- created, price, status
+    price INTEGER NOT NULL,
+    status VARCHAR(50) NOT NULL,
+    changed_by UUID REFERENCES users(uuid) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_flat_price_history_flat_id ON flat_price_history(flat_id, id);
+
+-- у существующих квартир история начинается с текущего состояния
+INSERT INTO flat_price_history (flat_id, change_type, price, status, created_at)
+SELECT id, 'created', price, status, COALESCE(created_at, CURRENT_TIMESTAMP) FROM flats;
+
+-- +goose Down
+DROP TABLE IF EXISTS flat_price_history;
@@ -0,0 +1,17 @@
+-- +goose Up
+CREATE TABLE idempotency_keys (
+    subject VARCHAR(255) NOT NULL, -- ключ уникален в пределах пользователя
+    idempotency_key VARCHAR(255) NOT NULL,
+    fingerprint VARCHAR(64) NOT NULL, -- sha256 метода, пути и тела запроса
+    status_code INTEGER DEFAULT NULL, -- NULL, пока первый запрос выполняется
+    content_type VARCHAR(255) DEFAULT NULL,
+    response_body BYTEA DEFAULT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (subject, idempotency_key)
+);
+
+CREATE INDEX idx_idempotency_keys_expires_at ON idempotency_keys(expires_at);
+
+-- +goose Down
+DROP TABLE IF EXISTS idempotency_keys;
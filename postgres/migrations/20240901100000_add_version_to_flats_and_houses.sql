@@ -0,0 +1,27 @@
+-- +goose Up
+ALTER TABLE flats ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE houses ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+
+-- версия растет при любом изменении строки, поэтому ее не нужно увеличивать в каждом запросе
+-- +goose StatementBegin
+CREATE FUNCTION bump_row_version() RETURNS TRIGGER AS $$
+BEGIN
+    NEW.version := OLD.version + 1;
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+CREATE TRIGGER flats_bump_version BEFORE UPDATE ON flats
+    FOR EACH ROW EXECUTE FUNCTION bump_row_version();
+
+CREATE TRIGGER houses_bump_version BEFORE UPDATE ON houses
+    FOR EACH ROW EXECUTE FUNCTION bump_row_version();
+
+-- +goose Down
+DROP TRIGGER IF EXISTS houses_bump_version ON houses;
+DROP TRIGGER IF EXISTS flats_bump_version ON flats;
+DROP FUNCTION IF EXISTS bump_row_version();
+
+ALTER TABLE houses DROP COLUMN IF EXISTS version;
+ALTER TABLE flats DROP COLUMN IF EXISTS version;
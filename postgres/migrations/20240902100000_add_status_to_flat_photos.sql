@@ -0,0 +1,12 @@
+-- +goose Up
+-- новые фотографии попадают к клиентам только после одобрения модератором
+ALTER TABLE flat_photos ADD COLUMN status VARCHAR(20) NOT NULL DEFAULT 'pending';
+
+-- уже загруженные фотографии получают решение, принятое по их квартире
+UPDATE flat_photos p
+SET status = f.status
+FROM flats f
+WHERE f.id = p.flat_id AND f.status IN ('approved', 'declined');
+
+-- +goose Down
+ALTER TABLE flat_photos DROP COLUMN IF EXISTS status;
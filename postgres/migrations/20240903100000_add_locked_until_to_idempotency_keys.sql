@@ -0,0 +1,9 @@
+-- +goose Up
+-- аренда ключа на время выполнения первого запроса: если процесс упал, не сохранив ответ,
+-- после locked_until ключ может занять повтор
+ALTER TABLE idempotency_keys ADD COLUMN locked_until TIMESTAMP DEFAULT NULL;
+
+UPDATE idempotency_keys SET locked_until = created_at + INTERVAL '1 minute' WHERE status_code IS NULL;
+
+-- +goose Down
+ALTER TABLE idempotency_keys DROP COLUMN IF EXISTS locked_until;
@@ -0,0 +1,8 @@
+-- +goose Up
+-- для периодического удаления истекших токенов
+CREATE INDEX idx_refresh_tokens_expires_at ON refresh_tokens(expires_at);
+CREATE INDEX idx_revoked_access_tokens_expires_at ON revoked_access_tokens(expires_at);
+
+-- +goose Down
+DROP INDEX IF EXISTS idx_revoked_access_tokens_expires_at;
+DROP INDEX IF EXISTS idx_refresh_tokens_expires_at;
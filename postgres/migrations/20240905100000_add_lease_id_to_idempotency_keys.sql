@@ -0,0 +1,7 @@
+-- +goose Up
+-- идентификатор аренды: сохранить ответ или освободить ключ может только тот запрос,
+-- который его занял, а не запрос, чья аренда истекла и была перехвачена повтором
+ALTER TABLE idempotency_keys ADD COLUMN lease_id UUID DEFAULT NULL;
+
+-- +goose Down
+ALTER TABLE idempotency_keys DROP COLUMN IF EXISTS lease_id;